@@ -1,24 +1,138 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"compress/zlib"
+	"context"
+	crand "crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"html"
+	"io"
 	"math"
 	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/harmonica"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/mattn/go-runewidth"
+	"github.com/muesli/termenv"
+
+	"main/color"
 )
 
+// Tamaño del slide fijo del modo proyector y mínimo del modo adaptable
 const (
-	slideWidth  = 40
-	slideHeight = 15
+	projectorWidth  = 40
+	projectorHeight = 15
+	minSlideWidth   = 30
+	minSlideHeight  = 10
+)
+
+// Tamaño actual de los slides; cambia con la terminal salvo en modo
+// proyector (ver setSlideSize)
+var (
+	slideWidth  = projectorWidth
+	slideHeight = projectorHeight
 )
 
 type model struct {
-	slides     []slide
+	deck
 	currentIdx int
+	trans      *transition
+	// transGen numera las transiciones: los ticks de una anterior que
+	// siguen pendientes se descartan, así no la aceleran
+	transGen int
+
+	// Fragmentos visibles de cada slide; al volver a un slide se ven los
+	// mismos que cuando se lo dejó
+	fragment map[int]int
+
+	// Generación de cada slide (ver slideMsg), y si la animación del slide
+	// actual está en pausa con un tick retenido
+	gen    map[int]int
+	paused bool
+	held   bool
+
+	// Vista general, salto a un slide y búsqueda. prompt es "g" o "/"
+	// mientras se escribe el número o el texto a buscar.
+	overview bool
+	selected int
+	prompt   string
+	input    string
+	query    string
+	matches  []int
+
+	// Tamaño de la terminal; en modo proyector los slides no lo siguen
+	width, height int
+	projector     bool
+
+	// Avance automático: el tiempo que lleva visible el paso actual, sin
+	// contar las pausas, y si se vuelve al primer slide después del último
+	auto       bool
+	autoPaused bool
+	shownFor   time.Duration
+	lastTick   time.Time
+	loop       bool
+
+	// Vista del presentador y sincronización con la audiencia
+	presenter bool
+	link      *syncLink
+	started   time.Time
+	now       time.Time
+	talk      time.Duration
+
+	// Control remoto por HTTP; el código para emparejarlo se ve hasta que
+	// se conecta el primero. blank deja la pantalla en negro. titles guarda
+	// los títulos que ya se le mandaron (ver title).
+	remote *remoteServer
+	paired bool
+	blank  bool
+	titles []string
+}
+
+// deck agrupa los slides con lo que se configura para cada uno de ellos
+type deck struct {
+	slides      []slide
+	transitions []transitionSpec
+	notes       []string
+	// freeze indica los slides que no repiten su animación al volver a
+	// ellos: siguen donde quedaron
+	freeze []bool
+	// advance dice cuándo pasar solo al slide siguiente
+	advance []advanceSpec
+}
+
+// advanceSpec es el avance automático de un slide: después de un tiempo,
+// cuando el slide termina (ver completer) o nunca. El valor cero es "sin
+// configurar" y lo completa el valor por defecto de --advance.
+type advanceSpec struct {
+	after time.Duration
+	onEnd bool
+	off   bool
 }
 
 type slide interface {
@@ -27,10 +141,72 @@ type slide interface {
 	Init() tea.Cmd
 }
 
+// stepper lo implementan los slides que avanzan por pasos internos antes de
+// ceder ← → a la navegación del deck. next y prev devuelven false cuando ya
+// no quedan pasos en esa dirección.
+type stepper interface {
+	next() bool
+	prev() bool
+}
+
+// fragmenter lo implementan los slides cuyo contenido aparece de a partes
+// con →, antes de pasar al slide siguiente. fragments es la cantidad de
+// partes ocultas al entrar y showFragments muestra las primeras n.
+type fragmenter interface {
+	fragments() int
+	showFragments(n int) tea.Cmd
+}
+
+// leaver lo implementan los slides que dejan algo en marcha (un proceso,
+// una conexión) que hay que cortar al salir de ellos.
+type leaver interface {
+	leave()
+}
+
+// resetter lo implementan los slides animados: reset los vuelve al estado
+// inicial para que la animación se repita la próxima vez que se entre.
+type resetter interface {
+	reset()
+}
+
+// completer lo implementan los slides que tienen un final natural (los
+// créditos que terminan de pasar, un gráfico que termina de crecer), para
+// que el avance automático pueda pasar al siguiente apenas terminan.
+// complete devuelve si el slide terminó; ends es false si, tal como está
+// configurado, el slide no tiene final (un código sin typewriter).
+type completer interface {
+	complete() (done, ends bool)
+}
+
+// Ciclo de vida de los slides:
+//
+//   - enter: al volverse el slide actual se incrementa su generación, se
+//     reinicia (si el slide repite su animación) y se llama a Init.
+//   - leave: al dejarlo se incrementa su generación y se corta lo que tenga
+//     en marcha.
+//   - pause y resume: con "." se retiene el próximo tick del slide actual y
+//     se le entrega al reanudar, así la animación sigue donde estaba.
+//   - reset: "R" vuelve a empezar la animación del slide actual.
+//
+// Todo mensaje que producen los comandos de un slide viaja en un slideMsg
+// con el índice del slide y su generación. Los de otro slide o de una
+// generación anterior se descartan: así un tick que quedó pendiente al
+// navegar no arranca una segunda cadena de ticks.
+type slideMsg struct {
+	idx, gen int
+	msg      tea.Msg
+}
+
+// creditsSlide hace subir la lista de créditos y termina con la tarjeta
+// final. position son las líneas que subió la lista, con fracciones, y
+// endCard el fundido de la tarjeta final.
 type creditsSlide struct {
-	credits     []string
-	currentLine int
-	showAll     bool
+	roll     creditsRoll
+	lines    []creditLine
+	speed    float64
+	position float64
+	endCard  float64
+	showAll  bool
 }
 
 type contentSlide struct {
@@ -38,18 +214,65 @@ type contentSlide struct {
 	body  string
 }
 
+// markdownSlide es un slide escrito en Markdown dentro de un deck. Si se
+// divide en fragmentos, parts[0] se ve al entrar y cada parte siguiente
+// aparece con →.
+type markdownSlide struct {
+	source string
+	parts  []string
+	shown  int
+}
+
+// barChartSlide dibuja una o más series por categoría, en barras
+// verticales u horizontales, agrupadas o apiladas. Los ejes se escalan solos
+// (incluyendo el cero) y los bordes de las barras usan bloques de octavos.
 type barChartSlide struct {
-	values      []int
-	targets     []int
-	labels      []string
-	maxValue    int
-	animating   bool
-	initialized bool
+	chartData
+	seriesReveal
+	title      string
+	horizontal bool
+	stacked    bool
+	progress   float64
+	animating  bool
+}
+
+// chartData son las categorías (o instantes) de un gráfico y sus series.
+// minValue y maxValue amplían el eje aunque ningún valor los alcance.
+type chartData struct {
+	labels   []string
+	series   []chartSeries
+	minValue float64
+	maxValue float64
+}
+
+type chartSeries struct {
+	name    string
+	targets []float64
+	// from son los valores desde los que crecen las barras; nil es desde
+	// cero
+	from []float64
+}
+
+// seriesReveal muestra las series de un gráfico de a una, como fragmentos.
+// Los ejes se calculan con todas, así no cambian al aparecer cada serie.
+type seriesReveal struct {
+	enabled bool
+	shown   int
+}
+
+func (r seriesReveal) visible(s int) bool {
+	return !r.enabled || s < r.shown
+}
+
+// animated indica si la serie s es la que se está dibujando; sin fragmentos
+// se dibujan todas juntas
+func (r seriesReveal) animated(s int) bool {
+	return !r.enabled || s == r.shown-1
 }
 
 type particleSlide struct {
-	particles   []particle
-	initialized bool
+	title     string
+	particles []particle
 }
 
 type particle struct {
@@ -60,82 +283,86 @@ type particle struct {
 	currentLife int
 }
 
+// gradientSlide pinta un texto con un degradé (ver color.Gradient). mode elige
+// por dónde corre: time pinta todo el texto de un color que va y vuelve
+// entre las puntas, chars lo reparte a lo largo de las líneas, lines de
+// arriba abajo y rainbow lo hace correr por los caracteres.
 type gradientSlide struct {
-	text         string
-	startColor   lipgloss.Color
-	endColor     lipgloss.Color
-	progress     float64
-	direction    int
-	initialized  bool
+	title     string
+	text      string
+	gradient  color.Gradient
+	mode      string
+	progress  float64
+	direction int
 }
 
 type tickMsg struct{}
 
 func initialModel() model {
-	creditLines := []string{
-		"Starring",
-		"dev1 Agustín",
-		"",
-		"Lead Architect",
-		"dev1 Agustín",
-		"",
-		"Backend Developer",
-		"Jane Doe",
-		"",
-		"Frontend Developer",
-		"John Smith",
-		"",
-		"Database Administrator",
-		"Alex Johnson",
-		"",
-		"DevOps Engineer",
-		"Maria García",
-		"",
-		"Project Manager",
-		"Chris Williams",
-		"",
-		"A Charm Production",
-		"2025",
-	}
-
-	barChartData := []int{0, 0, 0, 0, 0}
-	barChartTargets := []int{22, 16, 31, 18, 27}
+	credits := creditsRoll{
+		Sections: []creditSection{{
+			Roles: []creditRole{
+				{Role: "Starring", Names: []string{"dev1 Agustín"}},
+				{Role: "Lead Architect", Names: []string{"dev1 Agustín"}},
+				{Role: "Backend Developer", Names: []string{"Jane Doe"}},
+				{Role: "Frontend Developer", Names: []string{"John Smith"}},
+				{Role: "Database Administrator", Names: []string{"Alex Johnson"}},
+				{Role: "DevOps Engineer", Names: []string{"Maria García"}},
+				{Role: "Project Manager", Names: []string{"Chris Williams"}},
+			},
+		}},
+		End: creditEnd{Title: "Fin", Lines: []string{"A Charm Production", "2025"}},
+	}
+
+	barChartTargets := []float64{22, 16, 31, 18, 27}
 	barChartLabels := []string{"Proyecto A", "Proyecto B", "Proyecto C", "Proyecto D", "Proyecto E"}
 
 	slides := []slide{
 		&creditsSlide{
-			credits:     creditLines,
-			currentLine: -5,
+			roll:  credits,
+			lines: credits.lines(),
+			speed: defaultCreditsSpeed,
 		},
 		&contentSlide{
 			title: "Navegación de Slides",
 			body:  "Este es un proyecto demostrativo de una CLI con slides.\n\nUsa las flechas ← → para navegar entre slides.\n\nPresiona 'q' para salir.",
 		},
 		&barChartSlide{
-			values:      barChartData,
-			targets:     barChartTargets,
-			labels:      barChartLabels,
-			maxValue:    35,
-			animating:   true,
-			initialized: false,
+			chartData: chartData{
+				series:   []chartSeries{{name: "Rendimiento", targets: barChartTargets}},
+				labels:   barChartLabels,
+				maxValue: 35,
+			},
+			title:     "Rendimiento por Proyecto",
+			animating: true,
 		},
 		&particleSlide{
-			particles:   make([]particle, 0),
-			initialized: false,
+			title:     "Simulación de Partículas",
+			particles: make([]particle, 0),
 		},
 		&gradientSlide{
-			text:        "Este texto cambiará de color gradualmente",
-			startColor:  lipgloss.Color("#FF0000"),
-			endColor:    lipgloss.Color("#0000FF"),
-			progress:    0.0,
-			direction:   1,
-			initialized: false,
+			title: "Cambios de Estilo Progresivos",
+			text:  "Este texto cambiará de color gradualmente",
+			gradient: color.Gradient{
+				Stops: []color.Stop{{Color: colorful.Color{R: 1}}, {Color: colorful.Color{B: 1}, At: 1}},
+				Space: "oklab",
+			},
+			mode:      "time",
+			direction: 1,
 		},
 	}
 
 	return model{
-		slides:     slides,
+		deck: deck{
+			slides:      slides,
+			transitions: make([]transitionSpec, len(slides)),
+			notes:       make([]string, len(slides)),
+			freeze:      make([]bool, len(slides)),
+			advance:     make([]advanceSpec, len(slides)),
+		},
 		currentIdx: 0,
+		fragment:   map[int]int{},
+		gen:        map[int]int{},
 	}
 }
 
@@ -164,32 +391,103 @@ var (
 	creditStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#FAFAFA")).
 			Align(lipgloss.Center).
-			Width(slideWidth)
+			Width(slideWidth - 4)
 
 	creditTitleStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FFFF00")).
+				Foreground(lipgloss.Color("#FFFF00")).
+				Align(lipgloss.Center).
+				Width(slideWidth - 4)
+
+	creditSectionStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("#7D56F4")).
+				Align(lipgloss.Center).
+				Width(slideWidth - 4)
+
+	creditLogoStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#10F0FF")).
 			Align(lipgloss.Center).
-			Width(slideWidth)
+			Width(slideWidth - 4)
 
-	barStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#7D56F4")).
-			Background(lipgloss.Color("#7D56F4"))
+	creditEndStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#FAFAFA"))
 
-	labelStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FAFAFA")).
-			Width(12).
-			Align(lipgloss.Left)
+	axisStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#6C6C80"))
 
 	particleStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#FFFF00"))
+
+	warningStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FF1050")).
+			Bold(true)
+
+	// Barra del avance automático
+	progressStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4")).Background(lipgloss.Color("#2A2A3A"))
+	progressPausedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#6C6C80")).Background(lipgloss.Color("#2A2A3A"))
+
+	// Slide de comandos
+	promptStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#10FF50")).Bold(true)
+	runningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFF10"))
+	doneStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#10FF50"))
+	failedStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF1050"))
 )
 
-func (c *creditsSlide) Init() tea.Cmd {
-	return tea.Tick(250*time.Millisecond, func(t time.Time) tea.Msg {
+// setSlideSize cambia el tamaño de los slides y reajusta los estilos que
+// dependen de él
+func setSlideSize(width, height int) {
+	slideWidth, slideHeight = width, height
+	titleStyle = titleStyle.Width(slideWidth - 4)
+	slideStyle = slideStyle.Width(slideWidth).Height(slideHeight)
+	bodyStyle = bodyStyle.Width(slideWidth - 4)
+	creditStyle = creditStyle.Width(slideWidth - 4)
+	creditTitleStyle = creditTitleStyle.Width(slideWidth - 4)
+	creditSectionStyle = creditSectionStyle.Width(slideWidth - 4)
+	creditLogoStyle = creditLogoStyle.Width(slideWidth - 4)
+}
+
+// renderFrame dibuja el marco del slide recortando el contenido que no
+// entra, para que todos los slides midan lo mismo. Las líneas se cortan al
+// ancho antes de recortar, así las que no entran no agrandan el marco.
+func renderFrame(content string) string {
+	lines := strings.Split(lipgloss.NewStyle().Width(slideWidth-4).Render(content), "\n")
+	if len(lines) > slideHeight {
+		lines = lines[:slideHeight]
+	}
+	return slideStyle.Render(strings.Join(lines, "\n"))
+}
+
+// Cada cuánto avanzan los créditos y cuántas líneas por segundo suben si
+// el slide no fija su speed
+const (
+	creditsFrame        = 50 * time.Millisecond
+	defaultCreditsSpeed = 4.0
+	// Duración del fundido de la tarjeta final, en segundos
+	creditsEndFade = 1.0
+)
+
+func creditsTick() tea.Cmd {
+	return tea.Tick(creditsFrame, func(t time.Time) tea.Msg {
 		return tickMsg{}
 	})
 }
 
+func (c *creditsSlide) Init() tea.Cmd {
+	if c.showAll {
+		return nil
+	}
+	return creditsTick()
+}
+
+func (c *creditsSlide) reset() {
+	c.position, c.endCard, c.showAll = 0, 0, false
+}
+
+func (c *creditsSlide) complete() (bool, bool) {
+	return c.showAll, true
+}
+
 func (c *creditsSlide) Update(msg tea.Msg) (slide, tea.Cmd) {
 	switch msg.(type) {
 	case tickMsg:
@@ -197,339 +495,8282 @@ func (c *creditsSlide) Update(msg tea.Msg) (slide, tea.Cmd) {
 			return c, nil
 		}
 
-		c.currentLine++
+		step := creditsFrame.Seconds()
+		if c.position < float64(len(c.lines)+slideHeight) {
+			c.position += c.speed * step
+			return c, creditsTick()
+		}
 
-		if c.currentLine > len(c.credits)+slideHeight {
-			c.showAll = true
+		// Terminada la lista aparece la tarjeta final
+		c.endCard += step / creditsEndFade
+		if c.endCard >= 1 {
+			c.endCard, c.showAll = 1, true
 			return c, nil
 		}
-
-		return c, tea.Tick(250*time.Millisecond, func(t time.Time) tea.Msg {
-			return tickMsg{}
-		})
+		return c, creditsTick()
 	}
 
 	return c, nil
 }
 
+// View muestra la parte de la lista que está en pantalla. La posición
+// avanza de a fracciones de línea: la línea que sale por arriba se apaga y
+// la que entra por abajo se enciende de a poco, así el paso de una fila a
+// la siguiente no se ve a los saltos.
 func (c *creditsSlide) View() string {
-	if c.showAll {
-		var sb strings.Builder
-		for i, line := range c.credits {
-			if i%2 == 0 {
-				sb.WriteString(creditTitleStyle.Render(line))
-			} else {
-				sb.WriteString(creditStyle.Render(line))
-			}
-			sb.WriteString("\n")
-		}
-		return slideStyle.Render(sb.String())
+	if c.position >= float64(len(c.lines)+slideHeight) {
+		return c.endCardView()
 	}
 
+	offset := int(c.position)
+	frac := c.position - float64(offset)
 	var sb strings.Builder
-	for i := 0; i < slideHeight; i++ {
-		lineIdx := c.currentLine - slideHeight + i
-		if lineIdx >= 0 && lineIdx < len(c.credits) {
-			if lineIdx%2 == 0 {
-				sb.WriteString(creditTitleStyle.Render(c.credits[lineIdx]))
-			} else {
-				sb.WriteString(creditStyle.Render(c.credits[lineIdx]))
+	for row := 0; row < slideHeight; row++ {
+		if i := offset - slideHeight + row; i >= 0 && i < len(c.lines) {
+			line := c.lines[i].render()
+			switch row {
+			case 0:
+				line = fadeText(line, 1-frac)
+			case slideHeight - 1:
+				line = fadeText(line, frac)
 			}
+			sb.WriteString(line)
 		}
 		sb.WriteString("\n")
 	}
+	return renderFrame(sb.String())
+}
+
+// endCardView es la tarjeta final, al estilo de las películas: el título
+// con letras espaciadas entre dos líneas y debajo el resto
+func (c *creditsSlide) endCardView() string {
+	end := c.roll.End
+	if end.Title == "" {
+		end.Title = "Fin"
+	}
+	title := strings.Join(strings.Split(strings.ToUpper(end.Title), ""), " ")
+	rule := axisStyle.Render(strings.Repeat("─", lipgloss.Width(title)+6))
 
-	return slideStyle.Render(sb.String())
+	block := []string{rule, creditEndStyle.Render(title), rule}
+	if len(end.Lines) > 0 {
+		block = append(block, "")
+	}
+	for _, line := range end.Lines {
+		block = append(block, creditStyle.Render(line))
+	}
+	card := lipgloss.Place(slideWidth-4, slideHeight, lipgloss.Center, lipgloss.Center,
+		lipgloss.JoinVertical(lipgloss.Center, block...))
+	return renderFrame(fadeText(card, c.endCard))
 }
 
-func (s *contentSlide) Init() tea.Cmd {
-	return nil
+// fadeText apaga un texto con estilos hacia el negro; k es 1 para dejarlo
+// como está y 0 para apagarlo del todo
+func fadeText(text string, k float64) string {
+	if k >= 1 {
+		return text
+	}
+	grid := parseCells(text)
+	for _, row := range grid {
+		for i := range row {
+			row[i].sgr = fadeSGR(row[i].sgr, k)
+		}
+	}
+	return renderCells(grid)
 }
 
-func (s *contentSlide) Update(msg tea.Msg) (slide, tea.Cmd) {
-	return s, nil
+// creditsRoll son los créditos: secciones con un título opcional, un logo
+// en arte ASCII y roles con uno o más nombres, y la tarjeta final. Es el
+// formato de los archivos JSON y YAML de la clave file.
+type creditsRoll struct {
+	Sections []creditSection `json:"sections"`
+	End      creditEnd       `json:"end"`
 }
 
-func (s *contentSlide) View() string {
-	return slideStyle.Render(
-		fmt.Sprintf("%s\n%s",
-			titleStyle.Render(s.title),
-			bodyStyle.Render(s.body),
-		),
-	)
+type creditSection struct {
+	Title string       `json:"title,omitempty"`
+	Logo  string       `json:"logo,omitempty"`
+	Roles []creditRole `json:"roles"`
 }
 
-func (b *barChartSlide) Init() tea.Cmd {
-	if !b.initialized {
-		b.initialized = true
-		return tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
-			return tickMsg{}
-		})
-	}
-	return nil
+type creditRole struct {
+	Role  string   `json:"role,omitempty"`
+	Names []string `json:"names"`
 }
 
-func (b *barChartSlide) Update(msg tea.Msg) (slide, tea.Cmd) {
-	switch msg.(type) {
-	case tickMsg:
-		if !b.animating {
-			return b, nil
-		}
+type creditEnd struct {
+	Title string   `json:"title,omitempty"`
+	Lines []string `json:"lines,omitempty"`
+}
 
-		allComplete := true
-		for i := range b.values {
-			if b.values[i] < b.targets[i] {
-				b.values[i] += 1 + rand.Intn(2)
-				if b.values[i] > b.targets[i] {
-					b.values[i] = b.targets[i]
-				} else {
-					allComplete = false
-				}
-			}
-		}
+// creditLine es una línea de la lista ya armada; kind elige su estilo
+type creditLine struct {
+	text string
+	kind int
+}
 
-		if allComplete {
-			b.animating = false
-			return b, nil
-		}
+const (
+	creditBlank = iota
+	creditSectionLine
+	creditRoleLine
+	creditNameLine
+	creditLogoLine
+)
 
-		return b, tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
-			return tickMsg{}
-		})
+func (l creditLine) render() string {
+	switch l.kind {
+	case creditSectionLine:
+		return creditSectionStyle.Render(l.text)
+	case creditRoleLine:
+		return creditTitleStyle.Render(l.text)
+	case creditNameLine:
+		return creditStyle.Render(l.text)
+	case creditLogoLine:
+		return creditLogoStyle.Render(l.text)
 	}
-	return b, nil
+	return ""
 }
 
-func (b *barChartSlide) View() string {
-	chartHeight := 10
-	barWidth := 8
-	maxChartValue := b.maxValue
-
-	title := titleStyle.Render("Rendimiento por Proyecto")
-	var sb strings.Builder
-	sb.WriteString(title + "\n\n")
-
-	for i, value := range b.values {
-		barLength := int(float64(value) / float64(maxChartValue) * float64(chartHeight))
-		label := b.labels[i]
-
-		sb.WriteString(labelStyle.Render(label) + " ")
-
-		barText := fmt.Sprintf("%d", value)
-		bar := strings.Repeat(" ", barWidth)
-		barRendered := barStyle.Copy().Width(barWidth).Render(bar)
-
-		for row := 0; row < chartHeight; row++ {
-			y := chartHeight - row - 1
-			if y < barLength {
-				if row == chartHeight - barLength {
-					valueStyle := lipgloss.NewStyle().
-						Foreground(lipgloss.Color("#000000")).
-						Background(lipgloss.Color("#7D56F4")).
-						Width(barWidth).
-						Align(lipgloss.Center)
-					sb.WriteString(valueStyle.Render(barText))
-				} else {
-					sb.WriteString(barRendered)
-				}
-			} else {
-				sb.WriteString(strings.Repeat(" ", barWidth))
+// lines arma la lista que sube por la pantalla. Las líneas de un logo se
+// completan al mismo ancho para que al centrarlas no se deforme el dibujo.
+func (r creditsRoll) lines() []creditLine {
+	var lines []creditLine
+	blank := creditLine{kind: creditBlank}
+	for i, section := range r.Sections {
+		if i > 0 {
+			lines = append(lines, blank, blank)
+		}
+		if section.Logo != "" {
+			logo := strings.Split(strings.Trim(section.Logo, "\n"), "\n")
+			width := 0
+			for _, l := range logo {
+				width = max(width, lipgloss.Width(strings.TrimRight(l, " ")))
+			}
+			for _, l := range logo {
+				lines = append(lines, creditLine{text: padRight(strings.TrimRight(l, " "), width), kind: creditLogoLine})
+			}
+			lines = append(lines, blank)
+		}
+		if section.Title != "" {
+			lines = append(lines, creditLine{text: strings.ToUpper(section.Title), kind: creditSectionLine}, blank)
+		}
+		for j, role := range section.Roles {
+			if j > 0 {
+				lines = append(lines, blank)
+			}
+			if role.Role != "" {
+				lines = append(lines, creditLine{text: role.Role, kind: creditRoleLine})
+			}
+			for _, name := range role.Names {
+				lines = append(lines, creditLine{text: name, kind: creditNameLine})
 			}
-			sb.WriteString(" ")
 		}
-		sb.WriteString("\n")
 	}
-
-	return slideStyle.Render(sb.String())
+	return lines
 }
 
-func (p *particleSlide) Init() tea.Cmd {
-	if !p.initialized {
-		p.initialized = true
-		p.particles = make([]particle, 0)
-		return tea.Tick(50*time.Millisecond, func(t time.Time) tea.Msg {
-			return tickMsg{}
-		})
+// parseCreditsBody lee los créditos del cuerpo de un slide: cada grupo de
+// líneas separado por una línea en blanco es un rol (la primera línea) y
+// sus nombres (las demás)
+func parseCreditsBody(body string) creditsRoll {
+	var section creditSection
+	var role *creditRole
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			role = nil
+		case role == nil:
+			section.Roles = append(section.Roles, creditRole{Role: line})
+			role = &section.Roles[len(section.Roles)-1]
+		default:
+			role.Names = append(role.Names, line)
+		}
 	}
-	return nil
+	return creditsRoll{Sections: []creditSection{section}}
 }
 
-func (p *particleSlide) Update(msg tea.Msg) (slide, tea.Cmd) {
-	switch msg.(type) {
-	case tickMsg:
-		if rand.Intn(3) == 0 {
-			if len(p.particles) < 100 {
-				chars := []rune{'*', '+', '.', '·', '•', '°', '✧', '✦', '✴', '✹'}
-				x := float64(slideWidth/2) + rand.Float64()*4 - 2
-				y := float64(slideHeight/2)
-				angle := rand.Float64() * 2 * math.Pi
-				speed := 0.2 + rand.Float64()*0.4
-
-				p.particles = append(p.particles, particle{
-					x:           x,
-					y:           y,
-					vx:          math.Cos(angle) * speed,
-					vy:          math.Sin(angle) * speed,
-					char:        chars[rand.Intn(len(chars))],
-					lifespan:    50 + rand.Intn(50),
-					currentLife: 0,
-				})
-			}
+// loadCredits lee un archivo de créditos en JSON o, si termina en .yaml o
+// .yml, en YAML (ver parseSimpleYAML)
+func loadCredits(path string) (creditsRoll, error) {
+	var roll creditsRoll
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return roll, err
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		value, err := parseSimpleYAML(string(data))
+		if err != nil {
+			return roll, fmt.Errorf("%s: %w", path, err)
 		}
+		if data, err = json.Marshal(value); err != nil {
+			return roll, err
+		}
+	}
+	if err := json.Unmarshal(data, &roll); err != nil {
+		return roll, fmt.Errorf("%s: %w", path, err)
+	}
+	return roll, nil
+}
 
-		for i := 0; i < len(p.particles); i++ {
-			p.particles[i].x += p.particles[i].vx
-			p.particles[i].y += p.particles[i].vy
-			p.particles[i].currentLife++
+// gitCredits arma los créditos con los autores de un repositorio, de más
+// a menos commits, según git shortlog
+func gitCredits(repo string) (creditsRoll, error) {
+	out, err := exec.Command("git", "-C", repo, "shortlog", "-sn", "--no-merges", "HEAD").Output()
+	if exit, ok := err.(*exec.ExitError); ok && len(exit.Stderr) > 0 {
+		err = fmt.Errorf("%s", strings.TrimSpace(string(exit.Stderr)))
+	}
+	if err != nil {
+		return creditsRoll{}, fmt.Errorf("git shortlog en %s: %w", repo, err)
+	}
+	names := parseShortlog(string(out))
+	if len(names) == 0 {
+		return creditsRoll{}, fmt.Errorf("git shortlog en %s: el repositorio no tiene commits", repo)
+	}
+	title := filepath.Base(repo)
+	if abs, err := filepath.Abs(repo); err == nil {
+		title = filepath.Base(abs)
+	}
+	return creditsRoll{
+		Sections: []creditSection{{Title: "Contribuidores", Roles: []creditRole{{Names: names}}}},
+		End:      creditEnd{Title: "Fin", Lines: []string{title}},
+	}, nil
+}
 
-			if p.particles[i].currentLife >= p.particles[i].lifespan ||
-				 p.particles[i].x < 0 || p.particles[i].x >= float64(slideWidth) ||
-				 p.particles[i].y < 0 || p.particles[i].y >= float64(slideHeight) {
-				p.particles = append(p.particles[:i], p.particles[i+1:]...)
-				i--
+// parseShortlog saca los nombres de la salida de git shortlog, tanto con
+// -s ("  42<tab>Nombre") como sin él ("Nombre (42):" y los commits
+// indentados debajo). Con -e se descarta el correo.
+func parseShortlog(out string) []string {
+	var names []string
+	for _, line := range strings.Split(out, "\n") {
+		var name string
+		if count, rest, ok := strings.Cut(strings.TrimSpace(line), "\t"); ok && strings.Trim(count, "0123456789") == "" {
+			name = rest
+		} else if line != "" && line[0] != ' ' && line[0] != '\t' && strings.HasSuffix(line, "):") {
+			if i := strings.LastIndex(line, " ("); i > 0 {
+				name = line[:i]
 			}
 		}
-
-		return p, tea.Tick(50*time.Millisecond, func(t time.Time) tea.Msg {
-			return tickMsg{}
-		})
+		if i := strings.Index(name, " <"); i > 0 {
+			name = name[:i]
+		}
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
 	}
-	return p, nil
+	return names
 }
 
-func (p *particleSlide) View() string {
-	grid := make([][]rune, slideHeight)
-	for i := range grid {
-		grid[i] = make([]rune, slideWidth)
-		for j := range grid[i] {
-			grid[i][j] = ' '
-		}
+// runCredits implementa "slides credits": escribe en JSON los créditos de
+// un repositorio según git shortlog, como punto de partida de un archivo
+// de créditos para la clave file
+func runCredits(args []string) error {
+	fs := flag.NewFlagSet("credits", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Uso: slides credits [repositorio] > creditos.json\n")
+		fs.PrintDefaults()
 	}
+	fs.Parse(args)
 
-	for _, particle := range p.particles {
-		x, y := int(particle.x), int(particle.y)
-		if x >= 0 && x < slideWidth && y >= 0 && y < slideHeight {
-			grid[y][x] = particle.char
-		}
+	repo := "."
+	if fs.NArg() > 0 {
+		repo = fs.Arg(0)
+	}
+	roll, err := gitCredits(repo)
+	if err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(roll, "", "  ")
+	if err != nil {
+		return err
 	}
+	fmt.Println(string(out))
+	return nil
+}
 
-	var sb strings.Builder
-	sb.WriteString(titleStyle.Render("Simulación de Partículas") + "\n\n")
+// newCreditsSlide arma los créditos desde el cuerpo del slide (ver
+// parseCreditsBody), un archivo JSON o YAML (clave file, ver creditsRoll)
+// o los autores de un repositorio (clave git, ver gitCredits). speed son
+// las líneas por segundo que sube la lista.
+func newCreditsSlide(meta map[string]string, body, dir string) (*creditsSlide, error) {
+	resolve := func(path string) string {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		return path
+	}
 
-	for _, row := range grid {
-		sb.WriteString(particleStyle.Render(string(row)) + "\n")
+	roll := parseCreditsBody(body)
+	var err error
+	switch {
+	case meta["file"] != "":
+		roll, err = loadCredits(resolve(meta["file"]))
+	case meta["git"] != "":
+		roll, err = gitCredits(resolve(meta["git"]))
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return slideStyle.Render(sb.String())
+	speed := defaultCreditsSpeed
+	if value, ok := meta["speed"]; ok {
+		speed, err = strconv.ParseFloat(value, 64)
+		if err != nil || speed <= 0 {
+			return nil, fmt.Errorf("velocidad %q inválida (se esperan líneas por segundo)", value)
+		}
+	}
+	return &creditsSlide{roll: roll, lines: roll.lines(), speed: speed}, nil
 }
 
-func (g *gradientSlide) Init() tea.Cmd {
-	if !g.initialized {
-		g.initialized = true
-		return tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
-			return tickMsg{}
-		})
+// parseSimpleYAML lee el subconjunto de YAML que usan los archivos de
+// créditos: mapas y listas por indentación, escalares con o sin comillas,
+// listas [a, b] y bloques literales "|" (para los logos). Los escalares
+// quedan como texto. Devuelve los mismos tipos que encoding/json, así el
+// resultado se decodifica igual que un JSON.
+func parseSimpleYAML(src string) (any, error) {
+	p := &yamlParser{lines: strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")}
+	value, err := p.node(0)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	if p.skip(); p.pos < len(p.lines) {
+		return nil, fmt.Errorf("línea %d: indentación inesperada", p.pos+1)
+	}
+	return value, nil
 }
 
-func (g *gradientSlide) Update(msg tea.Msg) (slide, tea.Cmd) {
-	switch msg.(type) {
-	case tickMsg:
-		g.progress += 0.02 * float64(g.direction)
+type yamlParser struct {
+	lines []string
+	pos   int
+	// Un "- clave: valor" abre un mapa en medio de la línea: override es su
+	// texto y su indentación, que reemplazan a los de la línea actual
+	override       string
+	overrideIndent int
+}
 
-		if g.progress >= 1.0 {
-			g.progress = 1.0
-			g.direction = -1
-		} else if g.progress <= 0.0 {
-			g.progress = 0.0
-			g.direction = 1
+// skip saltea las líneas vacías y los comentarios
+func (p *yamlParser) skip() {
+	if p.override != "" {
+		return
+	}
+	for p.pos < len(p.lines) {
+		if text := strings.TrimSpace(p.lines[p.pos]); text != "" && !strings.HasPrefix(text, "#") {
+			return
 		}
-
-		return g, tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
-			return tickMsg{}
-		})
+		p.pos++
 	}
-	return g, nil
 }
 
-func (g *gradientSlide) View() string {
-	startRed, startGreen, startBlue := parseHexColor(string(g.startColor))
-	endRed, endGreen, endBlue := parseHexColor(string(g.endColor))
-
-	currentRed := int(float64(startRed) + g.progress*float64(endRed-startRed))
-	currentGreen := int(float64(startGreen) + g.progress*float64(endGreen-startGreen))
-	currentBlue := int(float64(startBlue) + g.progress*float64(endBlue-startBlue))
-
-	currentColor := fmt.Sprintf("#%02X%02X%02X", currentRed, currentGreen, currentBlue)
-
-	textStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color(currentColor)).
-		Bold(true).
-		Width(slideWidth - 4).
-		Align(lipgloss.Center)
-
-	var sb strings.Builder
-	sb.WriteString(titleStyle.Render("Cambios de Estilo Progresivos") + "\n\n")
-	sb.WriteString("\n\n\n")
-	sb.WriteString(textStyle.Render(g.text) + "\n\n")
-	sb.WriteString(textStyle.Render(fmt.Sprintf("Color actual: %s (Progreso: %.0f%%)", currentColor, g.progress*100)))
-
-	return slideStyle.Render(sb.String())
+// current devuelve la línea actual, sin indentación, y su indentación
+func (p *yamlParser) current() (string, int, bool) {
+	p.skip()
+	if p.override != "" {
+		return p.override, p.overrideIndent, true
+	}
+	if p.pos >= len(p.lines) {
+		return "", 0, false
+	}
+	line := p.lines[p.pos]
+	text := strings.TrimLeft(line, " ")
+	return strings.TrimRight(text, " "), len(line) - len(text), true
 }
 
-func parseHexColor(hexColor string) (int, int, int) {
-	if hexColor[0] == '#' {
-		hexColor = hexColor[1:]
+func (p *yamlParser) advance() {
+	if p.override != "" {
+		p.override = ""
+		return
 	}
+	p.pos++
+}
 
-	var r, g, b int
-	fmt.Sscanf(hexColor, "%02X%02X%02X", &r, &g, &b)
-	return r, g, b
+// node lee el valor que empieza en la línea actual, si está indentado al
+// menos min espacios
+func (p *yamlParser) node(min int) (any, error) {
+	text, indent, ok := p.current()
+	if !ok || indent < min {
+		return nil, nil
+	}
+	if text == "-" || strings.HasPrefix(text, "- ") {
+		return p.list(indent)
+	}
+	return p.mapping(indent)
 }
 
-func (m model) Init() tea.Cmd {
-	return m.slides[m.currentIdx].Init()
+func (p *yamlParser) list(indent int) (any, error) {
+	items := []any{}
+	for {
+		// Una lista a la altura de su clave termina en la clave siguiente
+		text, at, ok := p.current()
+		isItem := text == "-" || strings.HasPrefix(text, "- ")
+		if !ok || at < indent || at == indent && !isItem {
+			return items, nil
+		}
+		if at > indent {
+			return nil, fmt.Errorf("línea %d: se esperaba un elemento de la lista", p.pos+1)
+		}
+		item := strings.TrimSpace(strings.TrimPrefix(text, "-"))
+		switch _, _, isKey := yamlKey(item); {
+		case item == "":
+			p.advance()
+			value, err := p.node(indent + 1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		case isKey:
+			// El mapa sigue en las líneas de abajo, alineado con la clave
+			p.pos++
+			p.override, p.overrideIndent = item, at+len(text)-len(item)
+			value, err := p.mapping(p.overrideIndent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		default:
+			p.advance()
+			items = append(items, yamlScalar(item))
+		}
+	}
 }
 
+func (p *yamlParser) mapping(indent int) (any, error) {
+	m := map[string]any{}
+	for {
+		text, at, ok := p.current()
+		if !ok || at < indent {
+			return m, nil
+		}
+		key, rest, isKey := yamlKey(text)
+		if at > indent || !isKey {
+			return nil, fmt.Errorf("línea %d: se esperaba \"clave: valor\"", p.pos+1)
+		}
+		p.advance()
+
+		switch rest {
+		case "|", "|-":
+			m[key] = p.block(indent)
+		case "":
+			// El valor está en las líneas siguientes; una lista puede ir a
+			// la misma altura que su clave
+			next, nextIndent, ok := p.current()
+			var value any
+			var err error
+			if ok && nextIndent == indent && (next == "-" || strings.HasPrefix(next, "- ")) {
+				value, err = p.list(indent)
+			} else {
+				value, err = p.node(indent + 1)
+			}
+			if err != nil {
+				return nil, err
+			}
+			m[key] = value
+		default:
+			m[key] = yamlScalar(rest)
+		}
+	}
+}
+
+// block lee un bloque literal: las líneas más indentadas que su clave,
+// incluidas las vacías, sin la indentación común
+func (p *yamlParser) block(indent int) string {
+	var lines []string
+	common := -1
+	for ; p.pos < len(p.lines); p.pos++ {
+		line := p.lines[p.pos]
+		text := strings.TrimLeft(line, " ")
+		if text == "" {
+			lines = append(lines, "")
+			continue
+		}
+		at := len(line) - len(text)
+		if at <= indent {
+			break
+		}
+		if common < 0 || at < common {
+			common = at
+		}
+		lines = append(lines, line)
+	}
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = line[common:]
+		}
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// yamlKey separa "clave: valor"; el valor puede estar vacío
+func yamlKey(text string) (key, rest string, ok bool) {
+	if strings.HasPrefix(text, "\"") || strings.HasPrefix(text, "'") || strings.HasPrefix(text, "[") {
+		return "", "", false
+	}
+	if strings.HasSuffix(text, ":") {
+		return strings.TrimSuffix(text, ":"), "", true
+	}
+	key, rest, ok = strings.Cut(text, ": ")
+	return strings.TrimSpace(key), strings.TrimSpace(rest), ok
+}
+
+// yamlScalar interpreta un valor: entre comillas, una lista [a, b] o texto
+// suelto, que termina en un comentario " #"
+func yamlScalar(text string) any {
+	switch {
+	case strings.HasPrefix(text, "\""):
+		if quoted, err := strconv.QuotedPrefix(text); err == nil {
+			s, _ := strconv.Unquote(quoted)
+			return s
+		}
+	case strings.HasPrefix(text, "'"):
+		// Dentro de comillas simples, '' es una comilla
+		for i := 1; i < len(text); i++ {
+			if text[i] != '\'' {
+				continue
+			}
+			if i+1 < len(text) && text[i+1] == '\'' {
+				i++
+				continue
+			}
+			return strings.ReplaceAll(text[1:i], "''", "'")
+		}
+	case strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]"):
+		items := []any{}
+		for _, item := range yamlFlowItems(text[1 : len(text)-1]) {
+			items = append(items, yamlScalar(item))
+		}
+		return items
+	}
+	if i := strings.Index(text, " #"); i >= 0 {
+		text = strings.TrimSpace(text[:i])
+	}
+	return text
+}
+
+// yamlFlowItems separa los elementos de una lista [a, "b, c"]: las comas
+// entre comillas son parte del elemento
+func yamlFlowItems(text string) []string {
+	var items []string
+	var quote rune
+	escaped := false
+	start := 0
+	add := func(item string) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	for i, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			add(text[start:i])
+			start = i + 1
+		}
+	}
+	add(text[start:])
+	return items
+}
+
+func (s *contentSlide) Init() tea.Cmd {
+	return nil
+}
+
+func (s *contentSlide) Update(msg tea.Msg) (slide, tea.Cmd) {
+	return s, nil
+}
+
+func (s *contentSlide) View() string {
+	return renderFrame(
+		fmt.Sprintf("%s\n%s",
+			titleStyle.Render(s.title),
+			bodyStyle.Render(s.body),
+		),
+	)
+}
+
+func (b *barChartSlide) Init() tea.Cmd {
+	if b.animating {
+		return tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
+			return tickMsg{}
+		})
+	}
+	return nil
+}
+
+func (b *barChartSlide) reset() {
+	b.progress, b.animating = 0, true
+}
+
+func (b *barChartSlide) complete() (bool, bool) {
+	return !b.animating, true
+}
+
+func (b *barChartSlide) Update(msg tea.Msg) (slide, tea.Cmd) {
+	switch msg.(type) {
+	case tickMsg:
+		if !b.animating {
+			return b, nil
+		}
+
+		b.progress += 0.05
+		if b.progress >= 1 {
+			b.progress = 1
+			b.animating = false
+			return b, nil
+		}
+
+		return b, tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
+			return tickMsg{}
+		})
+	}
+	return b, nil
+}
+
+// Colores de las series, en orden
+var seriesColors = []lipgloss.Color{"#7D56F4", "#FF10F0", "#10F0FF", "#10FF50", "#FFFF10", "#FF8C10"}
+
+// Bloques de octavos: del lado de abajo (barras verticales) y de la
+// izquierda (barras horizontales); el índice es la cantidad de octavos
+var (
+	lowerBlocks = []string{" ", "▁", "▂", "▃", "▄", "▅", "▆", "▇", "█"}
+	leftBlocks  = []string{" ", "▏", "▎", "▍", "▌", "▋", "▊", "▉", "█"}
+)
+
+// value es el valor animado de la serie s en la categoría i
+func (b *barChartSlide) value(s, i int) float64 {
+	switch {
+	case !b.visible(s):
+		return 0
+	case !b.animated(s):
+		return b.series[s].targets[i]
+	}
+	from := 0.0
+	if b.series[s].from != nil {
+		from = b.series[s].from[i]
+	}
+	t := 1 - b.progress
+	return from + (b.series[s].targets[i]-from)*(1-t*t*t)
+}
+
+func (b *barChartSlide) fragments() int {
+	if !b.enabled {
+		return 0
+	}
+	return len(b.series)
+}
+
+// showFragments muestra las primeras n series; si aparece una nueva, crece
+// desde cero
+func (b *barChartSlide) showFragments(n int) tea.Cmd {
+	if !b.enabled {
+		return nil
+	}
+	grow := n > b.shown
+	b.shown = n
+	if !grow {
+		b.progress, b.animating = 1, false
+		return nil
+	}
+
+	b.progress = 0
+	if b.animating {
+		return nil
+	}
+	b.animating = true
+	return tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
+		return tickMsg{}
+	})
+}
+
+// bounds calcula el rango del eje: incluye el cero, los valores (o las
+// pilas si stacked) y los límites pedidos, redondeado a un paso "lindo"
+func (d chartData) bounds(stacked bool) (lo, hi, step float64) {
+	lo, hi = math.Min(0, d.minValue), math.Max(0, d.maxValue)
+	for i := range d.labels {
+		pos, neg := 0.0, 0.0
+		for _, s := range d.series {
+			v := s.targets[i]
+			if !stacked {
+				lo, hi = math.Min(lo, v), math.Max(hi, v)
+			} else if v > 0 {
+				pos += v
+			} else {
+				neg += v
+			}
+		}
+		lo, hi = math.Min(lo, neg), math.Max(hi, pos)
+	}
+	if hi == lo {
+		hi = lo + 1
+	}
+
+	step = niceStep((hi - lo) / 4)
+	return math.Floor(lo/step) * step, math.Ceil(hi/step) * step, step
+}
+
+// niceStep redondea un paso de eje a 1, 2 o 5 por una potencia de diez
+func niceStep(raw float64) float64 {
+	exp := math.Pow(10, math.Floor(math.Log10(raw)))
+	switch f := raw / exp; {
+	case f <= 1:
+		return exp
+	case f <= 2:
+		return 2 * exp
+	case f <= 5:
+		return 5 * exp
+	default:
+		return 10 * exp
+	}
+}
+
+// barSegment es un tramo de barra en octavos de celda a lo largo del eje
+type barSegment struct {
+	from, to int
+	color    lipgloss.Color
+}
+
+// bars devuelve los tramos de cada barra de la categoría i: una barra por
+// serie si están agrupadas, o una sola con los tramos apilados
+func (b *barChartSlide) bars(i int, toEighths func(float64) int) [][]barSegment {
+	zero := toEighths(0)
+	var bars [][]barSegment
+	pos, neg := 0.0, 0.0
+	var stack []barSegment
+	for s := range b.series {
+		v := b.value(s, i)
+		color := seriesColors[s%len(seriesColors)]
+		if !b.stacked {
+			a, c := zero, toEighths(v)
+			bars = append(bars, []barSegment{{min(a, c), max(a, c), color}})
+			continue
+		}
+		if v >= 0 {
+			stack = append(stack, barSegment{toEighths(pos), toEighths(pos + v), color})
+			pos += v
+		} else {
+			stack = append(stack, barSegment{toEighths(neg + v), toEighths(neg), color})
+			neg += v
+		}
+	}
+	if b.stacked {
+		bars = append(bars, stack)
+	}
+	return bars
+}
+
+// trackCells convierte los tramos de una barra en celdas, desde el inicio
+// del eje. Cada celda muestra el tramo que más la cubre: si arranca en el
+// borde inicial usa un bloque parcial; si llega al borde final invierte el
+// bloque complementario para pintar solo el extremo.
+func trackCells(length int, segments []barSegment, blocks []string) []string {
+	cells := make([]string, length)
+	for k := range cells {
+		start, end := k*8, k*8+8
+		best, overlap := barSegment{}, 0
+		for _, seg := range segments {
+			if ov := min(end, seg.to) - max(start, seg.from); ov > overlap {
+				best, overlap = seg, ov
+			}
+		}
+
+		// Si otro tramo ocupa el resto de la celda, se usa de fondo
+		style := lipgloss.NewStyle().Foreground(best.color)
+		for _, seg := range segments {
+			if seg != best && seg.from < end && seg.to > start {
+				style = style.Background(seg.color)
+			}
+		}
+		switch {
+		case overlap <= 0:
+			cells[k] = " "
+		case overlap == 8 || best.from <= start:
+			cells[k] = style.Render(blocks[overlap])
+		case best.to >= end:
+			cells[k] = style.Reverse(true).Render(blocks[8-overlap])
+		default:
+			cells[k] = style.Render(blocks[overlap])
+		}
+	}
+	return cells
+}
+
+func formatTick(v float64) string {
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
+
+// valueTicks ubica las marcas de un eje vertical de height filas: devuelve
+// la etiqueta de cada fila con marca y el ancho de la más larga
+func valueTicks(lo, hi, step float64, height int) (map[int]string, int) {
+	ticks := map[int]string{}
+	width := 0
+	for v := lo; v <= hi+step/2; v += step {
+		row := height - 1 - min(height-1, int(math.Round((v-lo)/(hi-lo)*float64(height*8)))/8)
+		ticks[row] = formatTick(v)
+		width = max(width, len(ticks[row]))
+	}
+	return ticks, width
+}
+
+// axisCell es el tramo del eje vertical en una fila, con su marca si la tiene
+func axisCell(ticks map[int]string, row, width int) string {
+	if tick, ok := ticks[row]; ok {
+		return axisStyle.Render(fmt.Sprintf("%*s┤", width, tick))
+	}
+	return axisStyle.Render(strings.Repeat(" ", width) + "│")
+}
+
+func (d chartData) legend() string {
+	if len(d.series) < 2 {
+		return ""
+	}
+	var parts []string
+	for s, series := range d.series {
+		color := seriesColors[s%len(seriesColors)]
+		parts = append(parts, lipgloss.NewStyle().Foreground(color).Render("■")+" "+series.name)
+	}
+	return strings.Join(parts, "  ")
+}
+
+func (b *barChartSlide) View() string {
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(b.title) + "\n\n")
+
+	height := slideHeight - 3
+	if legend := b.legend(); legend != "" {
+		sb.WriteString(legend + "\n")
+		height--
+	}
+
+	if b.horizontal {
+		sb.WriteString(b.horizontalView(height))
+	} else {
+		sb.WriteString(b.verticalView(height))
+	}
+	return renderFrame(sb.String())
+}
+
+// verticalView dibuja las categorías en columnas con el eje de valores a la
+// izquierda y las etiquetas debajo
+func (b *barChartSlide) verticalView(height int) string {
+	lo, hi, step := b.bounds(b.stacked)
+	toEighths := func(v float64) int {
+		return int(math.Round((v - lo) / (hi - lo) * float64(height*8)))
+	}
+	ticks, axisWidth := valueTicks(lo, hi, step, height)
+
+	plotWidth := slideWidth - 4 - axisWidth - 1
+	groupWidth := max(2, plotWidth/max(1, len(b.labels)))
+	barWidth := max(1, (groupWidth-1)/len(b.series))
+	if b.stacked {
+		barWidth = groupWidth - 1
+	}
+
+	// Columnas de celdas de abajo hacia arriba
+	var columns [][]string
+	for i := range b.labels {
+		used := 0
+		for _, bar := range b.bars(i, toEighths) {
+			cells := trackCells(height, bar, lowerBlocks)
+			for w := 0; w < barWidth; w++ {
+				columns = append(columns, cells)
+			}
+			used += barWidth
+		}
+		for ; used < groupWidth; used++ {
+			columns = append(columns, nil)
+		}
+	}
+
+	var lines []string
+	for row := 0; row < height; row++ {
+		var line strings.Builder
+		line.WriteString(axisCell(ticks, row, axisWidth))
+		for _, col := range columns {
+			if col == nil {
+				line.WriteString(" ")
+			} else {
+				line.WriteString(col[height-1-row])
+			}
+		}
+		lines = append(lines, line.String())
+	}
+
+	var labels strings.Builder
+	labels.WriteString(strings.Repeat(" ", axisWidth+1))
+	for _, label := range b.labels {
+		labels.WriteString(lipgloss.PlaceHorizontal(groupWidth, lipgloss.Left, truncate(label, groupWidth-1)))
+	}
+	lines = append(lines, labels.String())
+
+	return strings.Join(lines, "\n")
+}
+
+// horizontalView dibuja una fila por barra con las etiquetas a la izquierda
+// y el eje de valores debajo
+func (b *barChartSlide) horizontalView(height int) string {
+	lo, hi, step := b.bounds(b.stacked)
+
+	labelWidth := 0
+	for _, label := range b.labels {
+		labelWidth = max(labelWidth, min(10, lipgloss.Width(label)))
+	}
+	length := slideWidth - 4 - labelWidth - 2
+	toEighths := func(v float64) int {
+		return int(math.Round((v - lo) / (hi - lo) * float64(length*8)))
+	}
+
+	barsPerCategory := len(b.series)
+	if b.stacked {
+		barsPerCategory = 1
+	}
+	spaced := len(b.labels)*(barsPerCategory+1) <= height-2
+
+	var lines []string
+	for i, label := range b.labels {
+		for j, bar := range b.bars(i, toEighths) {
+			name := ""
+			if j == 0 {
+				name = truncate(label, labelWidth)
+			}
+			lines = append(lines, padRight(name, labelWidth)+axisStyle.Render(" │")+
+				strings.Join(trackCells(length, bar, leftBlocks), ""))
+		}
+		if spaced && i < len(b.labels)-1 {
+			lines = append(lines, strings.Repeat(" ", labelWidth)+axisStyle.Render(" │"))
+		}
+	}
+
+	// Eje con las marcas; se omiten las que se pisarían con la anterior
+	axis := []rune(strings.Repeat("─", length))
+	tickLine := []rune(strings.Repeat(" ", length+labelWidth+2))
+	next := 0
+	for v := lo; v <= hi+step/2; v += step {
+		x := min(length-1, toEighths(v)/8)
+		axis[x] = '┬'
+		label := []rune(formatTick(v))
+		at := labelWidth + 2 + x - len(label)/2
+		at = max(at, next)
+		if at+len(label) > len(tickLine) {
+			continue
+		}
+		copy(tickLine[at:], label)
+		next = at + len(label) + 1
+	}
+	lines = append(lines,
+		strings.Repeat(" ", labelWidth)+axisStyle.Render(" └"+string(axis)),
+		axisStyle.Render(string(tickLine)))
+
+	return strings.Join(lines, "\n")
+}
+
+// lineChartSlide dibuja series en el tiempo: líneas en braille, áreas
+// rellenas con bloques o una fila de sparklines por serie. Al entrar, las
+// series se dibujan de izquierda a derecha.
+type lineChartSlide struct {
+	chartData
+	seriesReveal
+	title     string
+	kind      string // "line", "area" o "sparkline"
+	progress  float64
+	animating bool
+}
+
+func (l *lineChartSlide) Init() tea.Cmd {
+	if l.animating {
+		return tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
+			return tickMsg{}
+		})
+	}
+	return nil
+}
+
+func (l *lineChartSlide) reset() {
+	l.progress, l.animating = 0, true
+}
+
+func (l *lineChartSlide) complete() (bool, bool) {
+	return !l.animating, true
+}
+
+func (l *lineChartSlide) Update(msg tea.Msg) (slide, tea.Cmd) {
+	switch msg.(type) {
+	case tickMsg:
+		if !l.animating {
+			return l, nil
+		}
+
+		l.progress += 0.05
+		if l.progress >= 1 {
+			l.progress = 1
+			l.animating = false
+			return l, nil
+		}
+
+		return l, tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
+			return tickMsg{}
+		})
+	}
+	return l, nil
+}
+
+func (l *lineChartSlide) View() string {
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(l.title) + "\n\n")
+
+	height := slideHeight - 3
+	if legend := l.legend(); legend != "" && l.kind != "sparkline" {
+		sb.WriteString(legend + "\n")
+		height--
+	}
+
+	switch l.kind {
+	case "sparkline":
+		sb.WriteString(l.sparklineView())
+	case "area":
+		sb.WriteString(l.areaView(height))
+	default:
+		sb.WriteString(l.lineView(height))
+	}
+	return renderFrame(sb.String())
+}
+
+// revealed es la fracción del eje horizontal ya dibujada de la serie s
+func (l *lineChartSlide) revealed(s int) float64 {
+	switch {
+	case !l.visible(s):
+		return 0
+	case !l.animated(s):
+		return 1
+	}
+	t := 1 - l.progress
+	return 1 - t*t*t
+}
+
+func (l *lineChartSlide) fragments() int {
+	if !l.enabled {
+		return 0
+	}
+	return len(l.series)
+}
+
+// showFragments muestra las primeras n series; si aparece una nueva, se
+// dibuja de izquierda a derecha
+func (l *lineChartSlide) showFragments(n int) tea.Cmd {
+	if !l.enabled {
+		return nil
+	}
+	grow := n > l.shown
+	l.shown = n
+	if !grow {
+		l.progress, l.animating = 1, false
+		return nil
+	}
+
+	l.progress = 0
+	if l.animating {
+		return nil
+	}
+	l.animating = true
+	return tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
+		return tickMsg{}
+	})
+}
+
+// lineView dibuja cada serie como una línea en braille: cada celda tiene
+// 2×4 puntos, así que la resolución es el doble en x y el cuádruple en y
+func (l *lineChartSlide) lineView(height int) string {
+	lo, hi, step := l.bounds(false)
+	ticks, axisWidth := valueTicks(lo, hi, step, height)
+	width := slideWidth - 4 - axisWidth - 1
+
+	canvas := newBrailleCanvas(width, height)
+	dotsX, dotsY := float64(width*2-1), float64(height*4-1)
+	n := len(l.labels)
+	for s, series := range l.series {
+		limit := l.revealed(s) * dotsX
+		if limit == 0 {
+			continue
+		}
+		color := seriesColors[s%len(seriesColors)]
+		point := func(i int) (float64, float64) {
+			x := dotsX / 2
+			if n > 1 {
+				x = float64(i) * dotsX / float64(n-1)
+			}
+			return x, (hi - series.targets[i]) / (hi - lo) * dotsY
+		}
+
+		x0, y0 := point(0)
+		if n == 1 && x0 <= limit {
+			canvas.set(int(x0), int(math.Round(y0)), color)
+		}
+		for i := 1; i < n && x0 <= limit; i++ {
+			x1, y1 := point(i)
+			if x1 > limit {
+				// El último tramo se corta donde llega la animación
+				y1 = y0 + (y1-y0)*(limit-x0)/(x1-x0)
+				x1 = limit
+			}
+			canvas.line(int(math.Round(x0)), int(math.Round(y0)), int(math.Round(x1)), int(math.Round(y1)), color)
+			x0, y0 = x1, y1
+		}
+	}
+
+	var lines []string
+	for row, cells := range canvas.rows() {
+		lines = append(lines, axisCell(ticks, row, axisWidth)+cells)
+	}
+	return strings.Join(append(lines, l.timeAxis(axisWidth, width, 2)...), "\n")
+}
+
+// areaView rellena con bloques de octavos el espacio entre el cero y cada
+// serie; donde se superponen se ve la serie más cercana al cero
+func (l *lineChartSlide) areaView(height int) string {
+	lo, hi, step := l.bounds(false)
+	ticks, axisWidth := valueTicks(lo, hi, step, height)
+	width := slideWidth - 4 - axisWidth - 1
+	toEighths := func(v float64) int {
+		return int(math.Round((v - lo) / (hi - lo) * float64(height*8)))
+	}
+
+	n := len(l.labels)
+	columns := make([][]string, width)
+	for x := range columns {
+
+		// Valor interpolado de cada serie en esta columna
+		at := float64(n-1) / 2
+		if width > 1 {
+			at = float64(x) * float64(n-1) / float64(width-1)
+		}
+		i := min(int(at), n-1)
+		next := min(i+1, n-1)
+
+		type band struct {
+			value float64
+			color lipgloss.Color
+		}
+		var bands []band
+		for s, series := range l.series {
+			if x >= int(math.Round(l.revealed(s)*float64(width))) {
+				continue
+			}
+			v := series.targets[i] + (series.targets[next]-series.targets[i])*(at-float64(i))
+			bands = append(bands, band{v, seriesColors[s%len(seriesColors)]})
+		}
+		sort.SliceStable(bands, func(a, b int) bool {
+			return math.Abs(bands[a].value) < math.Abs(bands[b].value)
+		})
+
+		// Cada serie ocupa desde el borde de la anterior (del mismo lado
+		// del cero) hasta su valor
+		zero := toEighths(0)
+		var segments []barSegment
+		pos, neg := zero, zero
+		for _, b := range bands {
+			e := toEighths(b.value)
+			if b.value >= 0 && e > pos {
+				segments = append(segments, barSegment{pos, e, b.color})
+				pos = e
+			} else if b.value < 0 && e < neg {
+				segments = append(segments, barSegment{e, neg, b.color})
+				neg = e
+			}
+		}
+		columns[x] = trackCells(height, segments, lowerBlocks)
+	}
+
+	var lines []string
+	for row := 0; row < height; row++ {
+		var line strings.Builder
+		line.WriteString(axisCell(ticks, row, axisWidth))
+		for _, col := range columns {
+			if col == nil {
+				line.WriteString(" ")
+			} else {
+				line.WriteString(col[height-1-row])
+			}
+		}
+		lines = append(lines, line.String())
+	}
+	return strings.Join(append(lines, l.timeAxis(axisWidth, width, 1)...), "\n")
+}
+
+// timeAxis es la línea de las etiquetas bajo un gráfico de width celdas con
+// dots puntos por celda; se omiten las que se pisarían con la anterior
+func (l *lineChartSlide) timeAxis(axisWidth, width, dots int) []string {
+	line := []rune(strings.Repeat(" ", axisWidth+1+width))
+	n := len(l.labels)
+	next := 0
+	for i, label := range l.labels {
+		x := width / 2
+		if n > 1 {
+			x = i * (width*dots - 1) / (n - 1) / dots
+		}
+		text := []rune(label)
+		at := max(next, axisWidth+1+x-len(text)/2)
+		if at+len(text) > len(line) {
+			at = len(line) - len(text)
+		}
+		if at < next || at < 0 {
+			continue
+		}
+		copy(line[at:], text)
+		next = at + len(text) + 1
+	}
+	return []string{axisStyle.Render(strings.TrimRight(string(line), " "))}
+}
+
+// sparklineView muestra una fila por serie: nombre, la curva escalada a su
+// propio rango, y el último valor con el mínimo y el máximo
+func (l *lineChartSlide) sparklineView() string {
+	nameWidth := 0
+	for _, s := range l.series {
+		nameWidth = max(nameWidth, min(14, runewidth.StringWidth(s.name)))
+	}
+	// Columna con el último valor y el rango: si no queda lugar para la
+	// línea, se omite
+	stats := true
+	width := slideWidth - 4 - nameWidth - 2 - 24
+	if width < 8 {
+		stats = false
+		width = max(1, slideWidth-4-nameWidth-2)
+	}
+
+	var lines []string
+	for s, series := range l.series {
+		values := series.targets
+		if len(values) > width {
+			values = values[len(values)-width:]
+		}
+		lo, hi := values[0], values[0]
+		for _, v := range values {
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+		}
+
+		visible := int(math.Round(l.revealed(s) * float64(len(values))))
+		var spark strings.Builder
+		for _, v := range values[:visible] {
+			level := 8
+			if hi > lo {
+				level = 1 + int(math.Round((v-lo)/(hi-lo)*7))
+			}
+			spark.WriteString(lowerBlocks[level])
+		}
+
+		color := seriesColors[s%len(seriesColors)]
+		line := padRight(truncate(series.name, nameWidth), nameWidth) + "  " +
+			lipgloss.NewStyle().Foreground(color).Render(padRight(spark.String(), width))
+		if stats && visible > 0 {
+			line += fmt.Sprintf(" %7s", formatTick(values[visible-1])) +
+				axisStyle.Render(fmt.Sprintf(" ↓%s ↑%s", formatTick(lo), formatTick(hi)))
+		}
+		lines = append(lines, line, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// brailleCanvas es una grilla de puntos en braille; cada celda guarda sus
+// 8 puntos y el color del último trazo que pasó por ella
+type brailleCanvas struct {
+	width, height int
+	dots          [][]rune
+	colors        [][]lipgloss.Color
+}
+
+// brailleBits son los bits de cada punto de una celda, por [y][x]
+var brailleBits = [4][2]rune{{0x01, 0x08}, {0x02, 0x10}, {0x04, 0x20}, {0x40, 0x80}}
+
+func newBrailleCanvas(width, height int) *brailleCanvas {
+	c := &brailleCanvas{width: width, height: height}
+	c.dots = make([][]rune, height)
+	c.colors = make([][]lipgloss.Color, height)
+	for y := range c.dots {
+		c.dots[y] = make([]rune, width)
+		c.colors[y] = make([]lipgloss.Color, width)
+	}
+	return c
+}
+
+// set prende el punto (x, y), en puntos desde la esquina superior izquierda
+func (c *brailleCanvas) set(x, y int, color lipgloss.Color) {
+	if x < 0 || y < 0 || x >= c.width*2 || y >= c.height*4 {
+		return
+	}
+	c.dots[y/4][x/2] |= brailleBits[y%4][x%2]
+	c.colors[y/4][x/2] = color
+}
+
+// line traza una recta entre dos puntos con el algoritmo de Bresenham
+func (c *brailleCanvas) line(x0, y0, x1, y1 int, color lipgloss.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		c.set(x0, y0, color)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func (c *brailleCanvas) rows() []string {
+	rows := make([]string, c.height)
+	for y := range rows {
+		var sb strings.Builder
+		for x, dots := range c.dots[y] {
+			if dots == 0 {
+				sb.WriteString(" ")
+				continue
+			}
+			sb.WriteString(lipgloss.NewStyle().Foreground(c.colors[y][x]).Render(string(0x2800 + dots)))
+		}
+		rows[y] = sb.String()
+	}
+	return rows
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// pieSlide dibuja las proporciones de una serie como torta o dona, con el
+// porcentaje sobre cada porción y una leyenda al costado. Las porciones
+// aparecen barriendo en sentido horario desde arriba.
+//
+// Las celdas de la terminal no son cuadradas: aspect es cuánto más alta que
+// ancha es una celda, y el círculo se calcula en esas unidades físicas para
+// que no salga como una elipse.
+type pieSlide struct {
+	title     string
+	labels    []string
+	values    []float64
+	hole      float64 // radio del agujero relativo al total; 0 es torta
+	aspect    float64
+	braille   bool
+	progress  float64
+	animating bool
+}
+
+func (p *pieSlide) Init() tea.Cmd {
+	if p.animating {
+		return tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
+			return tickMsg{}
+		})
+	}
+	return nil
+}
+
+func (p *pieSlide) reset() {
+	p.progress, p.animating = 0, true
+}
+
+func (p *pieSlide) complete() (bool, bool) {
+	return !p.animating, true
+}
+
+func (p *pieSlide) Update(msg tea.Msg) (slide, tea.Cmd) {
+	switch msg.(type) {
+	case tickMsg:
+		if !p.animating {
+			return p, nil
+		}
+
+		p.progress += 0.05
+		if p.progress >= 1 {
+			p.progress = 1
+			p.animating = false
+			return p, nil
+		}
+
+		return p, tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
+			return tickMsg{}
+		})
+	}
+	return p, nil
+}
+
+// sweeps devuelve el ángulo final de cada porción, en radianes desde arriba
+func (p *pieSlide) sweeps() []float64 {
+	total := 0.0
+	for _, v := range p.values {
+		total += v
+	}
+	ends := make([]float64, len(p.values))
+	acc := 0.0
+	for i, v := range p.values {
+		acc += v
+		ends[i] = acc / total * 2 * math.Pi
+	}
+	return ends
+}
+
+func (p *pieSlide) View() string {
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(p.title) + "\n\n")
+
+	total := 0.0
+	for _, v := range p.values {
+		total += v
+	}
+
+	// Leyenda: nombre, valor y porcentaje de cada porción. Si con ella la
+	// torta queda muy angosta, se achican los nombres y se omite el valor.
+	available := slideWidth - 4
+	labelWidth, values := 14, true
+	if available-2-(2+labelWidth+14) < minPieWidth {
+		labelWidth, values = max(3, min(14, available-2-minPieWidth-9)), false
+	}
+	height := slideHeight - 3
+	var legend []string
+	legendWidth := 0
+	for i, label := range p.labels {
+		color := seriesColors[i%len(seriesColors)]
+		line := lipgloss.NewStyle().Foreground(color).Render("■") + " " +
+			padRight(truncate(label, labelWidth), labelWidth)
+		if values {
+			line += fmt.Sprintf(" %6s", formatTick(p.values[i]))
+		}
+		line += fmt.Sprintf(" %5.1f%%", p.values[i]/total*100)
+		legend = append(legend, line)
+		// Con lugar, una línea en blanco entre porciones
+		if 2*len(p.labels) <= height {
+			legend = append(legend, "")
+		}
+		legendWidth = max(legendWidth, lipgloss.Width(line))
+	}
+
+	width := max(1, available-legendWidth-2)
+	chart := p.render(width, height)
+	sb.WriteString(lipgloss.JoinHorizontal(lipgloss.Center,
+		strings.Join(chart, "\n"), "  ", strings.Join(legend, "\n")))
+	return renderFrame(sb.String())
+}
+
+// Ancho mínimo de la torta antes de achicar la leyenda
+const minPieWidth = 12
+
+// render dibuja la torta en width×height celdas
+func (p *pieSlide) render(width, height int) []string {
+	// Puntos por celda según el backend
+	dotsX, dotsY := 1, 2
+	if p.braille {
+		dotsX, dotsY = 2, 4
+	}
+
+	// Todo se mide en anchos de celda; una celda mide 1×aspect
+	cx, cy := float64(width)/2, float64(height)*p.aspect/2
+	radius := math.Min(cx, cy) - 0.5
+	ends := p.sweeps()
+	t := 1 - p.progress
+	limit := (1 - t*t*t) * 2 * math.Pi
+
+	segmentAt := func(x, y int) int {
+		px := (float64(x)+0.5)/float64(dotsX) - cx
+		py := (float64(y)+0.5)/float64(dotsY)*p.aspect - cy
+		r := math.Hypot(px, py)
+		if r > radius || r < radius*p.hole {
+			return -1
+		}
+		angle := math.Atan2(px, -py)
+		if angle < 0 {
+			angle += 2 * math.Pi
+		}
+		if angle > limit {
+			return -1
+		}
+		for i, end := range ends {
+			if angle <= end {
+				return i
+			}
+		}
+		return len(ends) - 1
+	}
+
+	cells := make([][]string, height)
+	for row := range cells {
+		cells[row] = make([]string, width)
+		for col := range cells[row] {
+			if p.braille {
+				cells[row][col] = brailleCell(col, row, segmentAt)
+			} else {
+				cells[row][col] = halfBlockCell(col, row, segmentAt)
+			}
+		}
+	}
+
+	// Porcentajes en el medio de cada porción ya visible, si entran
+	start := 0.0
+	total := ends[len(ends)-1]
+	labelRadius := radius * (0.6 + p.hole*0.4)
+	if p.hole > 0 {
+		labelRadius = radius * (1 + p.hole) / 2
+	}
+	for i, end := range ends {
+		share := (end - start) / total
+		mid := (start + end) / 2
+		start = end
+		if end > limit || share < 0.06 {
+			continue
+		}
+
+		text := fmt.Sprintf("%.0f%%", share*100)
+		row := int((cy - labelRadius*math.Cos(mid)) / p.aspect)
+		col := int(cx+labelRadius*math.Sin(mid)) - len(text)/2
+		if row < 0 || row >= height || col < 0 || col+len(text) > width {
+			continue
+		}
+		style := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FFFFFF")).
+			Background(seriesColors[i%len(seriesColors)])
+		for k, r := range text {
+			cells[row][col+k] = style.Render(string(r))
+		}
+	}
+
+	rows := make([]string, height)
+	for row := range rows {
+		rows[row] = strings.Join(cells[row], "")
+	}
+	return rows
+}
+
+// brailleCell arma la celda (col, row) con los 2×4 puntos que caen dentro de
+// alguna porción; la celda toma el color de la porción con más puntos
+func brailleCell(col, row int, segmentAt func(x, y int) int) string {
+	var dots rune
+	count := map[int]int{}
+	best := -1
+	for dy := 0; dy < 4; dy++ {
+		for dx := 0; dx < 2; dx++ {
+			i := segmentAt(col*2+dx, row*4+dy)
+			if i < 0 {
+				continue
+			}
+			dots |= brailleBits[dy][dx]
+			count[i]++
+			if best < 0 || count[i] > count[best] {
+				best = i
+			}
+		}
+	}
+	if best < 0 {
+		return " "
+	}
+	return lipgloss.NewStyle().Foreground(seriesColors[best%len(seriesColors)]).Render(string(0x2800 + dots))
+}
+
+// halfBlockCell arma la celda (col, row) con dos píxeles: el de arriba en el
+// color del texto de "▀" y el de abajo en el fondo
+func halfBlockCell(col, row int, segmentAt func(x, y int) int) string {
+	top, bottom := segmentAt(col, row*2), segmentAt(col, row*2+1)
+	switch {
+	case top < 0 && bottom < 0:
+		return " "
+	case bottom < 0:
+		return lipgloss.NewStyle().Foreground(seriesColors[top%len(seriesColors)]).Render("▀")
+	case top < 0:
+		return lipgloss.NewStyle().Foreground(seriesColors[bottom%len(seriesColors)]).Render("▄")
+	default:
+		return lipgloss.NewStyle().
+			Foreground(seriesColors[top%len(seriesColors)]).
+			Background(seriesColors[bottom%len(seriesColors)]).
+			Render("▀")
+	}
+}
+
+// diagramSlide dibuja un diagrama de cajas y flechas descrito con un texto
+// (ver newDiagramSlide). Las cajas se acomodan en capas, de arriba abajo o
+// de izquierda a derecha, y las flechas van con tramos rectos de una capa a
+// la siguiente. Con reveal "edges" las flechas aparecen de a una con →;
+// cada una se traza desde su origen hasta la punta.
+type diagramSlide struct {
+	seriesReveal
+	title     string
+	right     bool // de izquierda a derecha; si no, de arriba abajo
+	nodes     []diagramNode
+	edges     []diagramEdge
+	ranks     []int   // la capa de cada caja
+	layers    [][]int // las cajas de cada capa, en orden
+	progress  float64
+	animating bool
+}
+
+type diagramNode struct {
+	id, label string
+}
+
+type diagramEdge struct {
+	from, to int
+	label    string
+}
+
+// newDiagramSlide arma un diagrama con el cuerpo del slide, una orden por
+// línea (puede ir dentro de un bloque ```):
+//
+//	direction: right     de izquierda a derecha; por defecto down
+//	db: Base de datos    una caja y su texto
+//	web -> api: HTTPS    una flecha con su etiqueta
+//	api -> auth -> db    varias flechas seguidas; la etiqueta, si la hay,
+//	                     es de la última
+//
+// Las cajas que no se declaran muestran su nombre y las líneas que empiezan
+// con "#" son comentarios. En los metadatos van title, direction y reveal.
+func newDiagramSlide(meta map[string]string, body string) (*diagramSlide, error) {
+	if reveal := meta["reveal"]; reveal != "" && reveal != "edges" {
+		return nil, fmt.Errorf("reveal desconocido %q (se espera edges)", reveal)
+	}
+	d := &diagramSlide{
+		seriesReveal: seriesReveal{enabled: meta["reveal"] == "edges"},
+		title:        metaOr(meta, "title", "Diagrama"),
+		animating:    true,
+	}
+	direction := metaOr(meta, "direction", "down")
+
+	index := map[string]int{}
+	node := func(id string) (int, error) {
+		if id == "" {
+			return 0, fmt.Errorf("falta el nombre de una caja")
+		}
+		if i, ok := index[id]; ok {
+			return i, nil
+		}
+		index[id] = len(d.nodes)
+		d.nodes = append(d.nodes, diagramNode{id: id, label: id})
+		return index[id], nil
+	}
+
+	for n, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "```") {
+			continue
+		}
+
+		var err error
+		if strings.Contains(line, "->") {
+			err = d.parseEdges(line, node)
+		} else {
+			id, label, _ := strings.Cut(line, ":")
+			id, label = strings.TrimSpace(id), strings.TrimSpace(label)
+			if id == "direction" {
+				direction = label
+				continue
+			}
+			var i int
+			if i, err = node(id); err == nil && label != "" {
+				d.nodes[i].label = label
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("línea %d: %w", n+1, err)
+		}
+	}
+
+	switch direction {
+	case "down":
+	case "right":
+		d.right = true
+	default:
+		return nil, fmt.Errorf("dirección desconocida %q (se espera down o right)", direction)
+	}
+	if len(d.nodes) == 0 {
+		return nil, fmt.Errorf("el diagrama no tiene cajas")
+	}
+	d.rank()
+	return d, nil
+}
+
+// parseEdges lee una línea "a -> b -> c: etiqueta"
+func (d *diagramSlide) parseEdges(line string, node func(string) (int, error)) error {
+	ids := strings.Split(line, "->")
+	last, label, _ := strings.Cut(ids[len(ids)-1], ":")
+	ids[len(ids)-1] = last
+
+	prev, err := node(strings.TrimSpace(ids[0]))
+	if err != nil {
+		return err
+	}
+	for k, id := range ids[1:] {
+		next, err := node(strings.TrimSpace(id))
+		if err != nil {
+			return err
+		}
+		if next == prev {
+			return fmt.Errorf("la flecha de %q vuelve a la misma caja", d.nodes[prev].id)
+		}
+		e := diagramEdge{from: prev, to: next}
+		if k == len(ids)-2 {
+			e.label = strings.TrimSpace(label)
+		}
+		d.edges = append(d.edges, e)
+		prev = next
+	}
+	return nil
+}
+
+// rank reparte las cajas en capas: cada flecha baja al menos una capa,
+// salvo las que cierran un ciclo, que se cuentan al revés. Dentro de cada
+// capa las cajas se ordenan según la posición media de sus vecinas, para
+// que se crucen menos flechas.
+func (d *diagramSlide) rank() {
+	back := make([]bool, len(d.edges))
+	state := make([]int, len(d.nodes)) // 0 sin visitar, 1 en curso, 2 lista
+	var visit func(n int)
+	visit = func(n int) {
+		state[n] = 1
+		for i, e := range d.edges {
+			if e.from != n {
+				continue
+			}
+			switch state[e.to] {
+			case 0:
+				visit(e.to)
+			case 1:
+				back[i] = true
+			}
+		}
+		state[n] = 2
+	}
+	for n := range d.nodes {
+		if state[n] == 0 {
+			visit(n)
+		}
+	}
+
+	d.ranks = make([]int, len(d.nodes))
+	for changed := true; changed; {
+		changed = false
+		for i, e := range d.edges {
+			from, to := e.from, e.to
+			if back[i] {
+				from, to = to, from
+			}
+			if d.ranks[to] < d.ranks[from]+1 {
+				d.ranks[to] = d.ranks[from] + 1
+				changed = true
+			}
+		}
+	}
+
+	d.layers = make([][]int, slices.Max(d.ranks)+1)
+	for n, r := range d.ranks {
+		d.layers[r] = append(d.layers[r], n)
+	}
+	pos := make([]float64, len(d.nodes))
+	place := func(layer []int) {
+		for i, n := range layer {
+			pos[n] = float64(i)
+		}
+	}
+	order := func(r, neighbor int) {
+		key := map[int]float64{}
+		for _, n := range d.layers[r] {
+			sum, count := 0.0, 0
+			for _, e := range d.edges {
+				switch {
+				case e.from == n && d.ranks[e.to] == neighbor:
+					sum += pos[e.to]
+					count++
+				case e.to == n && d.ranks[e.from] == neighbor:
+					sum += pos[e.from]
+					count++
+				}
+			}
+			key[n] = pos[n]
+			if count > 0 {
+				key[n] = sum / float64(count)
+			}
+		}
+		sort.SliceStable(d.layers[r], func(i, j int) bool { return key[d.layers[r][i]] < key[d.layers[r][j]] })
+		place(d.layers[r])
+	}
+	for _, layer := range d.layers {
+		place(layer)
+	}
+	for r := 1; r < len(d.layers); r++ {
+		order(r, r-1)
+	}
+	for r := len(d.layers) - 2; r >= 0; r-- {
+		order(r, r+1)
+	}
+}
+
+func (d *diagramSlide) Init() tea.Cmd {
+	if d.animating {
+		return tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
+			return tickMsg{}
+		})
+	}
+	return nil
+}
+
+func (d *diagramSlide) reset() {
+	d.progress, d.animating = 0, true
+}
+
+func (d *diagramSlide) complete() (bool, bool) {
+	return !d.animating, true
+}
+
+func (d *diagramSlide) Update(msg tea.Msg) (slide, tea.Cmd) {
+	switch msg.(type) {
+	case tickMsg:
+		if !d.animating {
+			return d, nil
+		}
+
+		d.progress += 0.1
+		if d.progress >= 1 {
+			d.progress = 1
+			d.animating = false
+			return d, nil
+		}
+
+		return d, tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
+			return tickMsg{}
+		})
+	}
+	return d, nil
+}
+
+func (d *diagramSlide) fragments() int {
+	if !d.enabled {
+		return 0
+	}
+	return len(d.edges)
+}
+
+// showFragments muestra las primeras n flechas; si aparece una nueva, se
+// traza desde su origen
+func (d *diagramSlide) showFragments(n int) tea.Cmd {
+	if !d.enabled {
+		return nil
+	}
+	grow := n > d.shown
+	d.shown = n
+	if !grow {
+		d.progress, d.animating = 1, false
+		return nil
+	}
+
+	d.progress = 0
+	if d.animating {
+		return nil
+	}
+	d.animating = true
+	return tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
+		return tickMsg{}
+	})
+}
+
+func (d *diagramSlide) View() string {
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(d.title) + "\n\n")
+
+	width, height := slideWidth-4, slideHeight-2
+	l, ok := d.layout(width, height)
+	if !ok {
+		// Mejor un aviso que cajas cortadas por la mitad
+		sb.WriteString(warningStyle.Width(width).Render(fmt.Sprintf("El diagrama no entra en el slide: necesita %d×%d y hay %d×%d", l.width, l.height, width, height)))
+		return renderFrame(sb.String())
+	}
+	c := newDiagramCanvas(l.width, l.height)
+	for _, b := range l.boxes {
+		c.box(b)
+	}
+	for e, route := range l.routes {
+		if !d.visible(e) {
+			continue
+		}
+		// El último punto es el borde de la caja de destino: la punta
+		// queda justo antes
+		drawn := len(route) - 1
+		if d.animated(e) {
+			t := 1 - d.progress
+			drawn = 1 + int(math.Round(float64(drawn-1)*(1-t*t*t)))
+		}
+		for k := 1; k < drawn; k++ {
+			c.connect(route[k-1], route[k], diagramEdgeStyle)
+		}
+		if drawn < len(route)-1 {
+			continue
+		}
+
+		tip := route[len(route)-2]
+		c.write(tip, arrowHead(tip, route[len(route)-1]), diagramTipStyle)
+		if label := d.edges[e].label; label != "" {
+			c.label(l.labels[e], truncate(label, l.text), l.right)
+		}
+	}
+
+	sb.WriteString(lipgloss.PlaceHorizontal(width, lipgloss.Center, strings.Join(c.rows(), "\n")))
+	return renderFrame(sb.String())
+}
+
+// Separación entre capas y entre las cajas de una misma capa, en celdas.
+// De arriba abajo, entre capas quedan la fila donde doblan las flechas y
+// la de las puntas; de izquierda a derecha, además, el lugar de las
+// etiquetas.
+const (
+	diagramLayerGap  = 2
+	diagramColumnGap = 3
+	diagramRowGap    = 1
+)
+
+type diagramBox struct {
+	x, y, w, h int
+	label      string
+}
+
+type diagramPoint struct{ x, y int }
+
+// diagramLayout dice dónde va cada caja, por qué celdas pasa cada flecha
+// (del borde de la caja de origen al de la de destino) y dónde empieza su
+// etiqueta. text es el ancho al que se cortaron los textos y right si las
+// capas van de izquierda a derecha.
+type diagramLayout struct {
+	width, height int
+	boxes         []diagramBox
+	routes        [][]diagramPoint
+	labels        []diagramPoint
+	text          int
+	right         bool
+}
+
+// layout acomoda el diagrama en width×height. Prueba primero alineando cada
+// caja con las que le llegan y después con las cajas juntas; si no entra,
+// acorta los textos de a un carácter, y si ni así entra, prueba en la otra
+// dirección. Si nada entra devuelve false y el acomodo más chico en la
+// dirección pedida, para decir cuánto lugar hace falta.
+func (d *diagramSlide) layout(width, height int) (diagramLayout, bool) {
+	longest := 3
+	for _, n := range d.nodes {
+		longest = max(longest, lipgloss.Width(n.label))
+	}
+	for _, e := range d.edges {
+		longest = max(longest, lipgloss.Width(e.label))
+	}
+
+	for _, right := range []bool{d.right, !d.right} {
+		for text := longest; text >= 3; text-- {
+			for _, packed := range []bool{false, true} {
+				if l := d.layoutWith(text, packed, right); l.width <= width && l.height <= height {
+					return l, true
+				}
+			}
+		}
+	}
+	return d.layoutWith(3, true, d.right), false
+}
+
+func (d *diagramSlide) layoutWith(text int, packed, right bool) diagramLayout {
+	l := diagramLayout{
+		boxes:  make([]diagramBox, len(d.nodes)),
+		labels: make([]diagramPoint, len(d.edges)),
+		text:   text,
+		right:  right,
+	}
+	for i, n := range d.nodes {
+		label := truncate(n.label, text)
+		l.boxes[i] = diagramBox{w: lipgloss.Width(label) + 4, h: 3, label: label}
+	}
+
+	// Las capas se apilan a lo largo (main) y las cajas de cada capa se
+	// ponen una al lado de la otra a lo ancho (cross)
+	mainSize := func(n int) int { return l.boxes[n].h }
+	crossSize := func(n int) int { return l.boxes[n].w }
+	point := func(main, cross int) diagramPoint { return diagramPoint{cross, main} }
+	layerGap, boxGap, labelRoom := diagramLayerGap, diagramColumnGap, 0
+	if right {
+		mainSize, crossSize = crossSize, mainSize
+		point = func(main, cross int) diagramPoint { return diagramPoint{main, cross} }
+		labelRoom = 1
+		for _, e := range d.edges {
+			if e.label != "" {
+				labelRoom = max(labelRoom, lipgloss.Width(truncate(e.label, text))+2)
+			}
+		}
+		layerGap, boxGap = labelRoom+2, diagramRowGap
+	}
+
+	start := make([]int, len(d.layers))
+	end := make([]int, len(d.layers)) // la primera celda después de la capa
+	for r, layer := range d.layers {
+		if r > 0 {
+			start[r] = end[r-1] + layerGap
+		}
+		end[r] = start[r]
+		for _, n := range layer {
+			end[r] = max(end[r], start[r]+mainSize(n))
+		}
+	}
+
+	cross := make([]int, len(d.nodes))
+	center := func(n int) int { return cross[n] + crossSize(n)/2 }
+	extent := make([]int, len(d.layers))
+	for r, layer := range d.layers {
+		next, first := 0, true
+		for _, n := range layer {
+			at := next
+			if !packed {
+				sum, count := 0, 0
+				for _, e := range d.edges {
+					switch {
+					case e.to == n && d.ranks[e.from] < r:
+						sum += center(e.from)
+						count++
+					case e.from == n && d.ranks[e.to] < r:
+						sum += center(e.to)
+						count++
+					}
+				}
+				if count > 0 && (first || sum/count-crossSize(n)/2 > next) {
+					at = sum/count - crossSize(n)/2
+				}
+			}
+			cross[n], first = at, false
+			next = at + crossSize(n) + boxGap
+		}
+		extent[r] = next - boxGap - cross[layer[0]]
+	}
+	if packed {
+		// Cada capa centrada con la más ancha
+		widest := slices.Max(extent)
+		for r, layer := range d.layers {
+			for _, n := range layer {
+				cross[n] += (widest - extent[r]) / 2
+			}
+		}
+	}
+
+	for r, layer := range d.layers {
+		for _, n := range layer {
+			p := point(start[r], cross[n])
+			l.boxes[n].x, l.boxes[n].y = p.x, p.y
+		}
+	}
+
+	// Cada flecha sale del borde de la caja de la capa más alta, dobla en
+	// el espacio que sigue a esa capa y llega a la otra caja. Si saltea
+	// capas, las cruza por un carril libre. Las que cierran un ciclo, si
+	// las dos cajas están en el mismo extremo de sus capas, vuelven por
+	// afuera para no pisar a las que van en el otro sentido.
+	lanes := map[[2]int]bool{}
+	for i, e := range d.edges {
+		upper, lower := e.from, e.to
+		if d.ranks[upper] > d.ranks[lower] {
+			upper, lower = lower, upper
+		}
+		ru, rl := d.ranks[upper], d.ranks[lower]
+		cu, cl := center(upper), center(lower)
+
+		var ways []diagramPoint
+		side := 0
+		if upper == e.to {
+			side = d.outside(upper, lower)
+		}
+		if side != 0 {
+			edge := func(n int) int {
+				if side > 0 {
+					return cross[n] + crossSize(n) - 1
+				}
+				return cross[n]
+			}
+			mu, ml := start[ru]+mainSize(upper)/2, start[rl]+mainSize(lower)/2
+			lane := d.outerLane(cross, crossSize, lanes, ru, rl, side)
+			ways = []diagramPoint{point(ml, edge(lower)), point(ml, lane), point(mu, lane), point(mu, edge(upper)+side), point(mu, edge(upper))}
+			l.labels[i] = point(mu+1, lane+1)
+			if right {
+				l.labels[i] = point(mu+2, lane)
+			}
+		} else {
+			ways = []diagramPoint{point(start[ru]+mainSize(upper)-1, cu), point(end[ru], cu)}
+			if rl > ru+1 {
+				lane := d.lane(cross, crossSize, lanes, ru+1, rl-1, cl)
+				ways = append(ways, point(end[ru], lane), point(end[rl-1], lane))
+			}
+			ways = append(ways, point(end[rl-1], cl), point(start[rl]-1, cl), point(start[rl], cl))
+			if upper != e.from {
+				slices.Reverse(ways)
+			}
+			// De arriba abajo la etiqueta va al lado de la punta; de
+			// izquierda a derecha, sobre el último tramo antes de la caja
+			// de más abajo
+			l.labels[i] = point(start[rl]-1, cl+1)
+			if right {
+				l.labels[i] = point(start[rl]-1-labelRoom, cl)
+			}
+		}
+
+		var route []diagramPoint
+		for k := 1; k < len(ways); k++ {
+			segment := diagramLine(ways[k-1], ways[k])
+			if len(route) > 0 {
+				segment = segment[1:]
+			}
+			route = append(route, segment...)
+		}
+		l.routes = append(l.routes, route)
+	}
+
+	// Todo a partir de (0, 0)
+	minX, minY, maxX, maxY := math.MaxInt, math.MaxInt, math.MinInt, math.MinInt
+	grow := func(p diagramPoint) {
+		minX, minY = min(minX, p.x), min(minY, p.y)
+		maxX, maxY = max(maxX, p.x), max(maxY, p.y)
+	}
+	for _, b := range l.boxes {
+		grow(diagramPoint{b.x, b.y})
+		grow(diagramPoint{b.x + b.w - 1, b.y + b.h - 1})
+	}
+	for _, route := range l.routes {
+		for _, p := range route {
+			grow(p)
+		}
+	}
+	for i := range l.boxes {
+		l.boxes[i].x -= minX
+		l.boxes[i].y -= minY
+	}
+	for _, route := range l.routes {
+		for k := range route {
+			route[k].x -= minX
+			route[k].y -= minY
+		}
+	}
+	for i := range l.labels {
+		l.labels[i].x -= minX
+		l.labels[i].y -= minY
+	}
+	l.width, l.height = maxX-minX+1, maxY-minY+1
+	return l
+}
+
+// outside devuelve el lado (1 o -1, a lo ancho) por el que una flecha que
+// cierra un ciclo puede volver por afuera de las capas: el de las cajas
+// que son las últimas (o las primeras) de sus capas. 0 si no hay ninguno.
+func (d *diagramSlide) outside(upper, lower int) int {
+	at := func(n, i int) bool {
+		layer := d.layers[d.ranks[n]]
+		return layer[(i+len(layer))%len(layer)] == n
+	}
+	switch {
+	case at(upper, -1) && at(lower, -1):
+		return 1
+	case at(upper, 0) && at(lower, 0):
+		return -1
+	}
+	return 0
+}
+
+// outerLane elige un carril por afuera de todas las cajas de las capas
+// from..to, del lado side, dejando una celda de aire
+func (d *diagramSlide) outerLane(cross []int, crossSize func(int) int, used map[[2]int]bool, from, to, side int) int {
+	lane := 0
+	for r := from; r <= to; r++ {
+		for k, n := range d.layers[r] {
+			c := cross[n] - 2
+			if side > 0 {
+				c = cross[n] + crossSize(n) + 1
+			}
+			if (r == from && k == 0) || c*side > lane*side {
+				lane = c
+			}
+		}
+	}
+	for {
+		free := true
+		for r := from; r <= to; r++ {
+			free = free && !used[[2]int{r, lane}]
+		}
+		if free {
+			break
+		}
+		lane += side
+	}
+	for r := from; r <= to; r++ {
+		used[[2]int{r, lane}] = true
+	}
+	return lane
+}
+
+// lane elige, a lo ancho, un carril que no pise cajas (ni pase pegado a
+// ellas) en las capas from..to, lo más cerca posible de target
+func (d *diagramSlide) lane(cross []int, crossSize func(int) int, used map[[2]int]bool, from, to, target int) int {
+	free := func(c int) bool {
+		for r := from; r <= to; r++ {
+			if used[[2]int{r, c}] {
+				return false
+			}
+			for _, n := range d.layers[r] {
+				if c >= cross[n]-1 && c <= cross[n]+crossSize(n) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	for dist := 0; ; dist++ {
+		for _, c := range []int{target + dist, target - dist} {
+			if free(c) {
+				for r := from; r <= to; r++ {
+					used[[2]int{r, c}] = true
+				}
+				return c
+			}
+		}
+	}
+}
+
+// diagramLine son las celdas de un tramo horizontal o vertical, con sus
+// dos extremos
+func diagramLine(a, b diagramPoint) []diagramPoint {
+	line := []diagramPoint{a}
+	for a != b {
+		a.x += cmp.Compare(b.x, a.x)
+		a.y += cmp.Compare(b.y, a.y)
+		line = append(line, a)
+	}
+	return line
+}
+
+// arrowHead es la punta en tip de una flecha que entra a la caja por el
+// borde vecino
+func arrowHead(tip, border diagramPoint) string {
+	switch {
+	case border.y > tip.y:
+		return "▼"
+	case border.y < tip.y:
+		return "▲"
+	case border.x > tip.x:
+		return "▶"
+	default:
+		return "◀"
+	}
+}
+
+// Estilos de los diagramas
+var (
+	diagramBoxStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+	diagramTextStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FAFAFA"))
+	diagramEdgeStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#10F0FF"))
+	diagramTipStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF10F0"))
+	diagramLabelStyle = lipgloss.NewStyle().Italic(true).Foreground(lipgloss.Color("#A0A0B0"))
+)
+
+// Lados hacia los que sale una línea de una celda
+const (
+	lineUp = 1 << iota
+	lineRight
+	lineDown
+	lineLeft
+)
+
+// lineGlyphs es el carácter de cada combinación de lados
+var lineGlyphs = map[uint8]string{
+	lineUp: "│", lineDown: "│", lineUp | lineDown: "│",
+	lineLeft: "─", lineRight: "─", lineLeft | lineRight: "─",
+	lineRight | lineDown: "╭", lineLeft | lineDown: "╮",
+	lineUp | lineRight: "╰", lineUp | lineLeft: "╯",
+	lineUp | lineDown | lineRight: "├", lineUp | lineDown | lineLeft: "┤",
+	lineLeft | lineRight | lineDown: "┬", lineLeft | lineRight | lineUp: "┴",
+	lineUp | lineRight | lineDown | lineLeft: "┼",
+}
+
+// diagramCanvas junta las líneas de cajas y flechas: cada celda guarda
+// hacia qué lados sale una línea, así donde se cruzan queda el carácter
+// del cruce. El texto se dibuja encima de las líneas.
+type diagramCanvas struct {
+	width, height int
+	lines         [][]uint8
+	text          [][]string
+	styles        [][]lipgloss.Style
+}
+
+// wideTail marca la segunda celda de un carácter ancho
+const wideTail = "\x00"
+
+func newDiagramCanvas(width, height int) *diagramCanvas {
+	c := &diagramCanvas{width: width, height: height}
+	c.lines = make([][]uint8, height)
+	c.text = make([][]string, height)
+	c.styles = make([][]lipgloss.Style, height)
+	for y := range c.lines {
+		c.lines[y] = make([]uint8, width)
+		c.text[y] = make([]string, width)
+		c.styles[y] = make([]lipgloss.Style, width)
+	}
+	return c
+}
+
+func (c *diagramCanvas) inside(p diagramPoint) bool {
+	return p.x >= 0 && p.y >= 0 && p.x < c.width && p.y < c.height
+}
+
+// connect une dos celdas vecinas con una línea
+func (c *diagramCanvas) connect(a, b diagramPoint, style lipgloss.Style) {
+	var toB, toA uint8
+	switch {
+	case b.x > a.x:
+		toB, toA = lineRight, lineLeft
+	case b.x < a.x:
+		toB, toA = lineLeft, lineRight
+	case b.y > a.y:
+		toB, toA = lineDown, lineUp
+	default:
+		toB, toA = lineUp, lineDown
+	}
+	c.mark(a, toB, style)
+	c.mark(b, toA, style)
+}
+
+// mark agrega un lado a la celda; la celda conserva el color de la primera
+// línea, así las salidas de las cajas quedan del color del borde
+func (c *diagramCanvas) mark(p diagramPoint, side uint8, style lipgloss.Style) {
+	if !c.inside(p) {
+		return
+	}
+	if c.lines[p.y][p.x] == 0 {
+		c.styles[p.y][p.x] = style
+	}
+	c.lines[p.y][p.x] |= side
+}
+
+func (c *diagramCanvas) write(p diagramPoint, text string, style lipgloss.Style) {
+	for _, r := range text {
+		if c.inside(p) {
+			c.text[p.y][p.x], c.styles[p.y][p.x] = string(r), style
+		}
+		if runewidth.RuneWidth(r) == 2 && c.inside(diagramPoint{p.x + 1, p.y}) {
+			c.text[p.y][p.x+1] = wideTail
+			p.x++
+		}
+		p.x++
+	}
+}
+
+// free indica si las n celdas desde p están libres de texto y, si lines,
+// también de líneas
+func (c *diagramCanvas) free(p diagramPoint, n int, lines bool) bool {
+	for i := range n {
+		q := diagramPoint{p.x + i, p.y}
+		if !c.inside(q) || c.text[q.y][q.x] != "" || (lines && c.lines[q.y][q.x] != 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *diagramCanvas) box(b diagramBox) {
+	corners := []diagramPoint{{b.x, b.y}, {b.x + b.w - 1, b.y}, {b.x + b.w - 1, b.y + b.h - 1}, {b.x, b.y + b.h - 1}, {b.x, b.y}}
+	for i := 1; i < len(corners); i++ {
+		line := diagramLine(corners[i-1], corners[i])
+		for k := 1; k < len(line); k++ {
+			c.connect(line[k-1], line[k], diagramBoxStyle)
+		}
+	}
+	c.write(diagramPoint{b.x + 2, b.y + 1}, b.label, diagramTextStyle)
+}
+
+// label escribe la etiqueta de una flecha desde p. Si inline, va sobre la
+// línea; si no, necesita celdas vacías y, si a la derecha no hay lugar,
+// prueba a la izquierda de p. Si no hay lugar, no se escribe.
+func (c *diagramCanvas) label(p diagramPoint, text string, inline bool) {
+	text = " " + text + " "
+	n := lipgloss.Width(text)
+	switch {
+	case inline:
+		if c.free(p, n, false) {
+			c.write(p, text, diagramLabelStyle)
+		}
+	case c.free(p, n, true):
+		c.write(p, text, diagramLabelStyle)
+	case c.free(diagramPoint{p.x - 1 - n, p.y}, n, true):
+		c.write(diagramPoint{p.x - 1 - n, p.y}, text, diagramLabelStyle)
+	}
+}
+
+func (c *diagramCanvas) rows() []string {
+	rows := make([]string, c.height)
+	for y := range rows {
+		var sb strings.Builder
+		for x, text := range c.text[y] {
+			switch {
+			case text == wideTail:
+			case text != "":
+				sb.WriteString(c.styles[y][x].Render(text))
+			case c.lines[y][x] != 0:
+				sb.WriteString(c.styles[y][x].Render(lineGlyphs[c.lines[y][x]]))
+			default:
+				sb.WriteString(" ")
+			}
+		}
+		rows[y] = sb.String()
+	}
+	return rows
+}
+
+// commandSlide ejecuta un comando local al entrar al slide y va mostrando
+// su salida, con colores, dentro del marco. Se corta al salir del slide o
+// al vencer el timeout; "r" lo vuelve a ejecutar y ↑ ↓ recorren la salida.
+//
+// El comando corre con tuberías, no con una terminal: para que mantenga los
+// colores se le pasan las variables que los fuerzan en la mayoría de las
+// herramientas (FORCE_COLOR, CLICOLOR_FORCE).
+type commandSlide struct {
+	title   string
+	command string
+	dir     string
+	timeout time.Duration
+
+	lines   [][]cell // salida ya terminada en líneas
+	partial string   // última línea, todavía sin "\n"
+	carry   string   // SGR activo al final de la última línea terminada
+	offset  int      // líneas desplazadas hacia arriba desde el final
+
+	run      int // se incrementa en cada ejecución para descartar mensajes viejos
+	cancel   func()
+	wait     tea.Cmd // espera el próximo mensaje de la ejecución en curso
+	running  bool
+	started  time.Time
+	finished time.Time
+	err      error
+}
+
+// Cantidad de líneas de salida que se guardan
+const commandScrollback = 1000
+
+// commandOutputMsg trae un trozo de la salida de la ejecución run
+type commandOutputMsg struct {
+	slide *commandSlide
+	run   int
+	data  string
+}
+
+// commandDoneMsg avisa que terminó la ejecución run
+type commandDoneMsg struct {
+	slide *commandSlide
+	run   int
+	err   error
+}
+
+// Init lanza el comando si no corrió desde el último reset; congelado, el
+// slide muestra la salida de la vez anterior
+func (c *commandSlide) Init() tea.Cmd {
+	if c.started.IsZero() {
+		return c.start()
+	}
+	return nil
+}
+
+func (c *commandSlide) reset() {
+	c.leave()
+	c.run++
+	c.lines, c.partial, c.carry, c.offset = nil, "", "", 0
+	c.started, c.finished, c.err = time.Time{}, time.Time{}, nil
+}
+
+// start corta la ejecución anterior, si la hay, y lanza el comando de nuevo
+func (c *commandSlide) start() tea.Cmd {
+	c.leave()
+	c.run++
+	c.lines, c.partial, c.carry, c.offset = nil, "", "", 0
+	c.err = nil
+	c.running = true
+	c.started = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.command)
+	cmd.Dir = c.dir
+	cmd.Env = append(os.Environ(), "FORCE_COLOR=1", "CLICOLOR_FORCE=1", "TERM=xterm-256color")
+	// El comando y sus hijos van en su propio grupo, para poder cortarlos
+	// todos juntos
+	killGroup(cmd)
+	cmd.WaitDelay = 2 * time.Second
+
+	pipe, err := cmd.StdoutPipe()
+	if err == nil {
+		cmd.Stderr = cmd.Stdout
+		err = cmd.Start()
+	}
+	run := c.run
+	if err != nil {
+		cancel()
+		return func() tea.Msg { return commandDoneMsg{slide: c, run: run, err: err} }
+	}
+
+	// stop se cierra al salir del slide: desde ahí nadie lee los mensajes,
+	// así que la salida se descarta hasta que el proceso termine
+	stop := make(chan struct{})
+	c.cancel = func() {
+		cancel()
+		close(stop)
+	}
+
+	// La salida se lee en trozos de hasta 4 KB, así un comando muy verboso
+	// no llena la cola de mensajes y el resto de los ticks siguen llegando
+	out := make(chan tea.Msg)
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := pipe.Read(buf)
+			if n > 0 {
+				select {
+				case out <- commandOutputMsg{slide: c, run: run, data: string(buf[:n])}:
+				case <-stop:
+				}
+			}
+			if err != nil {
+				break
+			}
+		}
+
+		err := cmd.Wait()
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("se agotó el tiempo (%s)", c.timeout)
+		}
+		select {
+		case out <- commandDoneMsg{slide: c, run: run, err: err}:
+		case <-stop:
+		}
+	}()
+
+	c.wait = func() tea.Msg {
+		select {
+		case msg := <-out:
+			return msg
+		case <-stop:
+			return nil
+		}
+	}
+	return c.wait
+}
+
+// complete indica que el comando ya terminó
+func (c *commandSlide) complete() (bool, bool) {
+	return !c.started.IsZero() && !c.running, true
+}
+
+// leave corta el comando si sigue corriendo
+func (c *commandSlide) leave() {
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+	}
+}
+
+func (c *commandSlide) Update(msg tea.Msg) (slide, tea.Cmd) {
+	switch msg := msg.(type) {
+	case commandOutputMsg:
+		if msg.slide != c || msg.run != c.run {
+			return c, nil
+		}
+		c.write(msg.data)
+		return c, c.wait
+
+	case commandDoneMsg:
+		if msg.slide != c || msg.run != c.run {
+			return c, nil
+		}
+		c.running = false
+		c.finished = time.Now()
+		c.err = msg.err
+		c.leave()
+		return c, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "r":
+			return c, c.start()
+		case "up", "k":
+			c.offset = min(c.offset+1, max(0, len(c.lines)-c.visibleLines()))
+		case "down", "j":
+			c.offset = max(c.offset-1, 0)
+		case "pgup":
+			c.offset = min(c.offset+c.visibleLines(), max(0, len(c.lines)-c.visibleLines()))
+		case "pgdown":
+			c.offset = max(c.offset-c.visibleLines(), 0)
+		}
+	}
+	return c, nil
+}
+
+// write agrega un trozo de salida. Cada línea se pasa a celdas al
+// completarse; "\r" sin "\n" reescribe la línea, como hacen las barras de
+// progreso.
+func (c *commandSlide) write(data string) {
+	c.partial += data
+	for {
+		i := strings.IndexByte(c.partial, '\n')
+		if i < 0 {
+			break
+		}
+		c.appendLine(c.partial[:i])
+		c.partial = c.partial[i+1:]
+	}
+}
+
+func (c *commandSlide) appendLine(line string) {
+	var cells []cell
+	cells, c.carry = outputCells(c.carry, line)
+	c.lines = append(c.lines, cells)
+
+	if extra := len(c.lines) - commandScrollback; extra > 0 {
+		c.lines = c.lines[extra:]
+	}
+	if c.offset > 0 {
+		// Si se está mirando más arriba, la vista queda quieta
+		c.offset = min(c.offset+1, max(0, len(c.lines)-c.visibleLines()))
+	}
+}
+
+// outputCells pasa una línea de salida a celdas, partiendo del SGR activo
+// que dejó la anterior, y devuelve el que queda activo al final. El color
+// sigue de una línea a otra hasta que el comando lo resetea.
+func outputCells(carry, line string) ([]cell, string) {
+	// Una celda de más al final muestra el estado que queda
+	cells := parseCells(carry + cleanOutput(line) + "$")[0]
+	return cells[:len(cells)-1], cells[len(cells)-1].sgr
+}
+
+// cleanOutput deja solo lo que se puede dibujar de una línea: lo último
+// escrito después de un "\r", tabs como espacios y sin otros caracteres de
+// control
+func cleanOutput(line string) string {
+	line = strings.TrimSuffix(line, "\r")
+	if i := strings.LastIndexByte(line, '\r'); i >= 0 {
+		line = line[i+1:]
+	}
+	line = strings.ReplaceAll(line, "\t", "    ")
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\x1b' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, line)
+}
+
+func (c *commandSlide) visibleLines() int {
+	return max(1, slideHeight-5)
+}
+
+func (c *commandSlide) View() string {
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(c.title) + "\n")
+	command, _, multiline := strings.Cut(c.command, "\n")
+	if multiline {
+		command += " …"
+	}
+	sb.WriteString(promptStyle.Render("$ ") + truncate(command, slideWidth-6) + "\n\n")
+
+	lines := c.lines
+	if c.partial != "" {
+		partial, _ := outputCells(c.carry, c.partial)
+		lines = append(lines[:len(lines):len(lines)], partial)
+	}
+
+	height := c.visibleLines()
+	end := max(0, len(lines)-c.offset)
+	start := max(0, end-height)
+	width := slideWidth - 4
+	window := make([][]cell, 0, height)
+	for _, line := range lines[start:end] {
+		if len(line) > width {
+			// Se copia la fila: line comparte memoria con c.lines
+			line = slices.Clone(line[:width])
+			if line[width-1].width == 2 {
+				line[width-1] = cell{ch: " ", width: 1}
+			}
+		}
+		window = append(window, line)
+	}
+	sb.WriteString(renderCells(padCells(window, width, height)) + "\n")
+	sb.WriteString(c.status())
+	return renderFrame(sb.String())
+}
+
+// status es la línea de estado bajo la salida
+func (c *commandSlide) status() string {
+	var state string
+	switch {
+	case c.running:
+		state = runningStyle.Render(fmt.Sprintf("● ejecutando %.1fs", time.Since(c.started).Seconds()))
+	case c.err != nil:
+		state = failedStyle.Render("✗ " + c.err.Error())
+	case !c.finished.IsZero():
+		state = doneStyle.Render(fmt.Sprintf("✓ terminó en %.1fs", c.finished.Sub(c.started).Seconds()))
+	}
+
+	hint := "r: repetir"
+	if c.offset > 0 {
+		hint = fmt.Sprintf("↓ %d líneas más · %s", c.offset, hint)
+	} else if len(c.lines) > c.visibleLines() {
+		hint = "↑ ↓ desplazar · " + hint
+	}
+	gap := max(1, slideWidth-4-lipgloss.Width(state)-lipgloss.Width(hint))
+	return state + strings.Repeat(" ", gap) + dimCodeStyle.Render(hint)
+}
+
+// pollSlide es una encuesta: muestra la pregunta y los votos que manda el
+// público (ver pollServer) en barras que crecen con cada voto. Con answer
+// es un quiz, y → cierra la votación y marca la respuesta correcta. Cada
+// votante tiene un solo voto: si vuelve a votar, cambia su respuesta.
+//
+// Solo el presentador atiende el servidor y guarda el CSV; las sesiones de
+// la audiencia reciben los votos por el syncLink (ver syncVoteMsg).
+type pollSlide struct {
+	pollQuestion
+	chart   barChartSlide
+	answer  int    // opción correcta; -1 si no es un quiz
+	results string // CSV al que se agrega cada voto
+
+	server   *pollServer
+	url      string  // dónde votar; en la audiencia lo manda el presentador
+	session  int     // encuesta abierta en el servidor; 0 si no hay
+	wait     tea.Cmd // espera el próximo voto
+	ballots  map[string]pollBallot
+	last     string // último que votó, en modo con nombres
+	revealed bool
+	err      error
+}
+
+// pollBallot es el voto vigente de un votante; name queda vacío en las
+// encuestas anónimas
+type pollBallot struct {
+	name   string
+	option int
+}
+
+func (p *pollSlide) Init() tea.Cmd {
+	init := p.chart.Init()
+	if p.server == nil {
+		return init
+	}
+	p.session, p.wait = p.server.open(p.pollQuestion)
+	return tea.Batch(init, p.wait)
+}
+
+// reset repite el crecimiento de las barras; los votos se conservan
+func (p *pollSlide) reset() {
+	p.chart.reset()
+	p.chart.series[0].from = nil
+}
+
+// leave cierra la encuesta en el servidor
+func (p *pollSlide) leave() {
+	if p.session != 0 {
+		p.server.close(p.session)
+		p.session = 0
+	}
+}
+
+func (p *pollSlide) fragments() int {
+	if p.answer < 0 {
+		return 0
+	}
+	return 1
+}
+
+// showFragments con n = 1 cierra la votación y muestra la respuesta
+func (p *pollSlide) showFragments(n int) tea.Cmd {
+	if p.answer < 0 {
+		return nil
+	}
+	p.revealed = n > 0
+	if p.session != 0 {
+		p.server.setClosed(p.session, p.revealed)
+	}
+	p.chart.labels[p.answer] = strconv.Itoa(p.answer+1) + " " + p.options[p.answer]
+	if p.revealed {
+		p.chart.labels[p.answer] = "✓ " + p.options[p.answer]
+	}
+	return nil
+}
+
+func (p *pollSlide) Update(msg tea.Msg) (slide, tea.Cmd) {
+	switch msg := msg.(type) {
+	case pollVoteMsg:
+		if msg.session != p.session {
+			return p, nil
+		}
+		if p.vote(msg) {
+			return p, tea.Batch(p.grow(), p.wait)
+		}
+		return p, p.wait
+
+	case tickMsg:
+		_, cmd := p.chart.Update(msg)
+		return p, cmd
+	}
+	return p, nil
+}
+
+// vote registra un voto y, si el slide atiende el servidor, lo agrega al
+// CSV; devuelve false si el votante repitió la respuesta que ya tenía
+func (p *pollSlide) vote(v pollVoteMsg) bool {
+	if prev, ok := p.ballots[v.voter]; ok && prev.option == v.option {
+		return false
+	}
+	p.ballots[v.voter] = pollBallot{name: v.name, option: v.option}
+	if p.named {
+		p.last = v.name
+	}
+	if p.server != nil {
+		p.err = p.record(v)
+	}
+	return true
+}
+
+// grow anima las barras desde lo que se ve hacia los votos actuales
+func (p *pollSlide) grow() tea.Cmd {
+	series := &p.chart.series[0]
+	from := make([]float64, len(p.options))
+	for i := range from {
+		from[i] = p.chart.value(0, i)
+	}
+	series.from, series.targets = from, p.counts()
+	p.chart.progress = 0
+	if p.chart.animating {
+		return nil
+	}
+	p.chart.animating = true
+	return p.chart.Init()
+}
+
+// counts cuenta los votos vigentes de cada opción
+func (p *pollSlide) counts() []float64 {
+	counts := make([]float64, len(p.options))
+	for _, b := range p.ballots {
+		counts[b.option]++
+	}
+	return counts
+}
+
+// record agrega un voto al CSV de resultados, con el encabezado si el
+// archivo es nuevo
+func (p *pollSlide) record(v pollVoteMsg) error {
+	f, err := os.OpenFile(p.results, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	correct := ""
+	if p.answer >= 0 {
+		correct = "no"
+		if v.option == p.answer {
+			correct = "sí"
+		}
+	}
+	w := csv.NewWriter(f)
+	if info.Size() == 0 {
+		w.Write([]string{"hora", "pregunta", "votante", "respuesta", "correcta"})
+	}
+	w.Write([]string{v.at.Format(time.RFC3339), p.question, v.name, p.options[v.option], correct})
+	w.Flush()
+	return w.Error()
+}
+
+func (p *pollSlide) View() string {
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(p.question) + "\n")
+	sb.WriteString(p.status() + "\n\n")
+	sb.WriteString(p.chart.horizontalView(slideHeight-4) + "\n")
+	sb.WriteString(p.footer())
+	return renderFrame(sb.String())
+}
+
+// status dice dónde votar y cuántos votaron
+func (p *pollSlide) status() string {
+	count := "1 voto"
+	if n := len(p.ballots); n != 1 {
+		count = fmt.Sprintf("%d votos", n)
+	}
+
+	var state string
+	style := runningStyle
+	switch {
+	case p.err != nil:
+		state, style = "✗ "+p.err.Error(), failedStyle
+	case p.revealed:
+		state, style = "✓ votación cerrada", doneStyle
+	case p.url == "":
+		state, style = "sin servidor de votación", dimCodeStyle
+	default:
+		state = "● votá en " + strings.TrimPrefix(p.url, "http://")
+	}
+	state = style.Render(truncate(state, slideWidth-4-len(count)-1))
+	gap := max(1, slideWidth-4-lipgloss.Width(state)-lipgloss.Width(count))
+	return state + strings.Repeat(" ", gap) + count
+}
+
+// footer muestra cuántos acertaron en un quiz resuelto y, con nombres,
+// quiénes; si no, el último que votó
+func (p *pollSlide) footer() string {
+	var text string
+	switch {
+	case p.revealed:
+		var right []string
+		for _, b := range p.ballots {
+			if b.option == p.answer {
+				right = append(right, b.name)
+			}
+		}
+		text = fmt.Sprintf("Respuesta: %s · %d de %d acertaron", p.options[p.answer], len(right), len(p.ballots))
+		if p.named && len(right) > 0 {
+			sort.Strings(right)
+			text += ": " + strings.Join(right, ", ")
+		}
+	case p.last != "":
+		text = "Último voto: " + p.last
+	}
+	return axisStyle.Render(truncate(text, slideWidth-4))
+}
+
+// newPollSlide arma una encuesta:
+//
+//	title: ¿Qué lenguaje usás más?   la pregunta
+//	options: Go, Rust, Python        o una opción por línea "- " en el cuerpo
+//	answer: Go                       la respuesta correcta, por texto o
+//	                                 número; la convierte en un quiz
+//	mode: anonymous | named          named pide el nombre de cada votante
+//	results: votos.csv               relativo a la carpeta del deck
+func newPollSlide(meta map[string]string, body, dir string) (slide, error) {
+	options := splitList(meta["options"])
+	if len(options) == 0 {
+		for _, line := range strings.Split(body, "\n") {
+			line = strings.TrimSpace(line)
+			if item, ok := strings.CutPrefix(line, "- "); ok {
+				options = append(options, strings.TrimSpace(item))
+			} else if item, ok := strings.CutPrefix(line, "* "); ok {
+				options = append(options, strings.TrimSpace(item))
+			}
+		}
+	}
+	if len(options) < 2 {
+		return nil, fmt.Errorf("una encuesta necesita al menos dos opciones")
+	}
+
+	p := &pollSlide{
+		pollQuestion: pollQuestion{question: metaOr(meta, "title", "Encuesta"), options: options},
+		answer:       -1,
+		ballots:      map[string]pollBallot{},
+	}
+	switch mode := metaOr(meta, "mode", "anonymous"); mode {
+	case "anonymous":
+	case "named":
+		p.named = true
+	default:
+		return nil, fmt.Errorf("modo %q desconocido (se espera anonymous o named)", mode)
+	}
+	if answer, ok := meta["answer"]; ok {
+		if p.answer = p.option(answer); p.answer < 0 {
+			return nil, fmt.Errorf("la respuesta %q no es una de las opciones", answer)
+		}
+	}
+
+	p.results = metaOr(meta, "results", "votos.csv")
+	if !filepath.IsAbs(p.results) {
+		p.results = filepath.Join(dir, p.results)
+	}
+
+	labels := make([]string, len(options))
+	for i, option := range options {
+		labels[i] = strconv.Itoa(i+1) + " " + option
+	}
+	// Con max 4 el eje arranca con marcas enteras
+	p.chart = barChartSlide{
+		chartData: chartData{
+			labels:   labels,
+			series:   []chartSeries{{name: "Votos", targets: make([]float64, len(options))}},
+			maxValue: 4,
+		},
+		title:      p.question,
+		horizontal: true,
+		animating:  true,
+	}
+	return p, nil
+}
+
+func (p *particleSlide) Init() tea.Cmd {
+	return tea.Tick(50*time.Millisecond, func(t time.Time) tea.Msg {
+		return tickMsg{}
+	})
+}
+
+func (p *particleSlide) reset() {
+	p.particles = make([]particle, 0)
+}
+
+func (p *particleSlide) Update(msg tea.Msg) (slide, tea.Cmd) {
+	switch msg.(type) {
+	case tickMsg:
+		if rand.Intn(3) == 0 {
+			if len(p.particles) < 100 {
+				chars := []rune{'*', '+', '.', '·', '•', '°', '✧', '✦', '✴', '✹'}
+				width, height := p.bounds()
+				x := float64(width/2) + rand.Float64()*4 - 2
+				y := float64(height / 2)
+				angle := rand.Float64() * 2 * math.Pi
+				speed := 0.2 + rand.Float64()*0.4
+
+				p.particles = append(p.particles, particle{
+					x:           x,
+					y:           y,
+					vx:          math.Cos(angle) * speed,
+					vy:          math.Sin(angle) * speed,
+					char:        chars[rand.Intn(len(chars))],
+					lifespan:    50 + rand.Intn(50),
+					currentLife: 0,
+				})
+			}
+		}
+
+		width, height := p.bounds()
+		for i := 0; i < len(p.particles); i++ {
+			p.particles[i].x += p.particles[i].vx
+			p.particles[i].y += p.particles[i].vy
+			p.particles[i].currentLife++
+
+			if p.particles[i].currentLife >= p.particles[i].lifespan ||
+				 p.particles[i].x < 0 || p.particles[i].x >= float64(width) ||
+				 p.particles[i].y < 0 || p.particles[i].y >= float64(height) {
+				p.particles = append(p.particles[:i], p.particles[i+1:]...)
+				i--
+			}
+		}
+
+		return p, tea.Tick(50*time.Millisecond, func(t time.Time) tea.Msg {
+			return tickMsg{}
+		})
+	}
+	return p, nil
+}
+
+func (p *particleSlide) View() string {
+	width, height := p.bounds()
+	grid := make([][]rune, height)
+	for i := range grid {
+		grid[i] = make([]rune, width)
+		for j := range grid[i] {
+			grid[i][j] = ' '
+		}
+	}
+
+	for _, particle := range p.particles {
+		x, y := int(particle.x), int(particle.y)
+		if x >= 0 && x < width && y >= 0 && y < height {
+			grid[y][x] = particle.char
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(p.title) + "\n\n")
+
+	for _, row := range grid {
+		sb.WriteString(particleStyle.Render(string(row)) + "\n")
+	}
+
+	return renderFrame(sb.String())
+}
+
+// bounds es el área donde viven las partículas: el interior del marco
+// debajo del título. Sigue al tamaño del slide cuando la terminal cambia.
+func (p *particleSlide) bounds() (int, int) {
+	return slideWidth - 4, slideHeight - 2
+}
+
+func (g *gradientSlide) Init() tea.Cmd {
+	// Repartido por caracteres o líneas, el degradé no cambia con el tiempo
+	if g.mode == "chars" || g.mode == "lines" {
+		return nil
+	}
+	return tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
+		return tickMsg{}
+	})
+}
+
+func (g *gradientSlide) reset() {
+	g.progress, g.direction = 0, 1
+}
+
+func (g *gradientSlide) Update(msg tea.Msg) (slide, tea.Cmd) {
+	switch msg.(type) {
+	case tickMsg:
+		if g.mode == "rainbow" {
+			g.progress = math.Mod(g.progress+0.02, 1)
+		} else {
+			g.progress += 0.02 * float64(g.direction)
+		}
+
+		if g.progress >= 1.0 {
+			g.progress = 1.0
+			g.direction = -1
+		} else if g.progress <= 0.0 {
+			g.progress = 0.0
+			g.direction = 1
+		}
+
+		return g, tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
+			return tickMsg{}
+		})
+	}
+	return g, nil
+}
+
+func (g *gradientSlide) View() string {
+	textStyle := lipgloss.NewStyle().
+		Bold(true).
+		Width(slideWidth - 4).
+		Align(lipgloss.Center)
+
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(g.title) + "\n\n")
+	sb.WriteString("\n\n\n")
+
+	if g.mode == "time" {
+		currentColor := strings.ToUpper(g.gradient.At(g.progress).Hex())
+		textStyle = textStyle.Foreground(lipgloss.Color(currentColor))
+		sb.WriteString(textStyle.Render(g.text) + "\n\n")
+		sb.WriteString(textStyle.Render(fmt.Sprintf("Color actual: %s (Progreso: %.0f%%)", currentColor, g.progress*100)))
+		return renderFrame(sb.String())
+	}
+
+	sb.WriteString(g.paint(textStyle.Render(g.text)) + "\n\n")
+	sb.WriteString(axisStyle.Width(slideWidth - 4).Align(lipgloss.Center).Render(g.gradient.Describe()))
+	return renderFrame(sb.String())
+}
+
+// paint colorea carácter por carácter un texto ya acomodado. El degradé
+// va de la primera a la última columna con texto, o de la primera a la
+// última línea.
+func (g *gradientSlide) paint(text string) string {
+	lines := strings.Split(text, "\n")
+	first, last := -1, 0
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		start := runewidth.StringWidth(line) - runewidth.StringWidth(strings.TrimLeft(line, " "))
+		if first < 0 || start < first {
+			first = start
+		}
+		last = max(last, runewidth.StringWidth(strings.TrimRight(line, " "))-1)
+	}
+	span := float64(max(1, last-first))
+
+	grad := g.gradient
+	if g.mode == "rainbow" {
+		grad = grad.Loop()
+	}
+	style := lipgloss.NewStyle().Bold(true)
+	for y, line := range lines {
+		var sb strings.Builder
+		x := 0
+		for _, r := range line {
+			if r == ' ' {
+				sb.WriteRune(r)
+				x++
+				continue
+			}
+			var t float64
+			switch g.mode {
+			case "lines":
+				t = float64(y) / float64(max(1, len(lines)-1))
+			case "rainbow":
+				t = float64(x-first)/(span+1) - g.progress
+				t -= math.Floor(t)
+			default:
+				t = float64(x-first) / span
+			}
+			sb.WriteString(style.Foreground(lipgloss.Color(grad.At(t).Hex())).Render(string(r)))
+			x += runewidth.RuneWidth(r)
+		}
+		lines[y] = sb.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// newGradientSlide lee un slide gradient: colors son las paradas del
+// degradé (ver color.ParseGradient), o si no from y to; space es el espacio en
+// el que se interpola (oklab, oklch, hsl o rgb) y mode por dónde corre
+// (time, chars, lines o rainbow). rainbow sin colores usa todos los tonos.
+func newGradientSlide(meta map[string]string, body string) (*gradientSlide, error) {
+	mode := metaOr(meta, "mode", "time")
+	if !slices.Contains([]string{"time", "chars", "lines", "rainbow"}, mode) {
+		return nil, fmt.Errorf("modo %q desconocido (se espera time, chars, lines o rainbow)", mode)
+	}
+
+	spec := meta["colors"]
+	switch {
+	case spec != "":
+	case mode == "rainbow" && meta["from"] == "" && meta["to"] == "":
+		spec = "red, yellow, lime, cyan, blue, magenta"
+	default:
+		spec = metaOr(meta, "from", "#FF0000") + ", " + metaOr(meta, "to", "#0000FF")
+	}
+	grad, err := color.ParseGradient(spec, metaOr(meta, "space", "oklab"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &gradientSlide{
+		title:     metaOr(meta, "title", "Cambios de Estilo Progresivos"),
+		text:      metaOr(meta, "text", strings.TrimSpace(body)),
+		gradient:  grad,
+		mode:      mode,
+		direction: 1,
+	}, nil
+}
+
+func (s *markdownSlide) Init() tea.Cmd {
+	return nil
+}
+
+func (s *markdownSlide) Update(msg tea.Msg) (slide, tea.Cmd) {
+	return s, nil
+}
+
+func (s *markdownSlide) fragments() int {
+	return max(0, len(s.parts)-1)
+}
+
+func (s *markdownSlide) showFragments(n int) tea.Cmd {
+	s.shown = n
+	return nil
+}
+
+func (s *markdownSlide) View() string {
+	title, lines := renderMarkdown(s.source, slideWidth-4)
+	if len(s.parts) > 0 {
+		// Lo oculto sigue ocupando su lugar, así el resto no se mueve
+		var all []string
+		title, all = renderMarkdown(strings.Join(s.parts, ""), slideWidth-4)
+		_, lines = renderMarkdown(strings.Join(s.parts[:s.shown+1], ""), slideWidth-4)
+		for len(lines) < len(all) {
+			lines = append(lines, "")
+		}
+	}
+
+	var sb strings.Builder
+	if title != "" {
+		sb.WriteString(titleStyle.Render(title) + "\n\n")
+	}
+	sb.WriteString(strings.Join(lines, "\n"))
+	return renderFrame(sb.String())
+}
+
+// splitFragments divide el Markdown de un slide en las partes que aparecen
+// de a una. Una línea ". . ." marca una pausa; además, con reveal "items"
+// cada ítem de lista es una parte y con "paragraphs" cada bloque que no sea
+// un encabezado. Devuelve nil si el slide no tiene fragmentos.
+func splitFragments(source, reveal string) ([]string, error) {
+	if reveal != "" && reveal != "items" && reveal != "paragraphs" {
+		return nil, fmt.Errorf("reveal desconocido %q (se espera items o paragraphs)", reveal)
+	}
+
+	var parts []string
+	var current strings.Builder
+	inFence, blank := false, true
+	cut := func() {
+		if strings.TrimSpace(current.String()) != "" {
+			parts = append(parts, current.String())
+			current.Reset()
+		}
+	}
+	for _, line := range strings.SplitAfter(source, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			inFence = !inFence
+		case inFence:
+		case trimmed == ". . .":
+			cut()
+			blank = true
+			continue
+		case reveal == "items" && isListItem(trimmed):
+			cut()
+		case reveal == "paragraphs" && blank && trimmed != "" && !strings.HasPrefix(trimmed, "#"):
+			cut()
+		}
+		current.WriteString(line)
+		blank = trimmed == ""
+	}
+	cut()
+
+	if len(parts) < 2 {
+		return nil, nil
+	}
+	return parts, nil
+}
+
+// codeSlide muestra código resaltado con números de línea. Con → destaca
+// por pasos rangos de líneas y atenúa el resto; si el código no entra en el
+// slide, se desplaza para mostrar el rango destacado (o con ↑ ↓).
+type codeSlide struct {
+	title      string
+	lang       string
+	lines      []string
+	steps      [][2]int
+	step       int
+	offset     int
+	typewriter bool
+	revealed   int
+	total      int
+}
+
+const codeGutter = 6
+
+func newCodeSlide(meta map[string]string, body string) (*codeSlide, error) {
+	lang := meta["lang"]
+	lines := strings.Split(strings.Trim(body, "\n"), "\n")
+	if len(lines) > 0 && strings.HasPrefix(strings.TrimSpace(lines[0]), "```") {
+		if lang == "" {
+			lang = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[0]), "```"))
+		}
+		lines = lines[1:]
+		if n := len(lines); n > 0 && strings.TrimSpace(lines[n-1]) == "```" {
+			lines = lines[:n-1]
+		}
+	}
+	if _, ok := lexers[lang]; !ok && lang != "" {
+		return nil, fmt.Errorf("lenguaje no soportado %q", lang)
+	}
+
+	c := &codeSlide{
+		title:      metaOr(meta, "title", "Código"),
+		lang:       lang,
+		typewriter: meta["typewriter"] == "true",
+	}
+	for _, line := range lines {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		c.lines = append(c.lines, line)
+		c.total += len([]rune(line)) + 1
+	}
+
+	for _, part := range splitList(meta["steps"]) {
+		from, to, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(from))
+		end := start
+		if err == nil && isRange {
+			end, err = strconv.Atoi(strings.TrimSpace(to))
+		}
+		if err != nil || start < 1 || end < start {
+			return nil, fmt.Errorf("paso inválido %q", part)
+		}
+		if end > len(c.lines) {
+			return nil, fmt.Errorf("el paso %q pasa la última línea (el código tiene %d)", part, len(c.lines))
+		}
+		c.steps = append(c.steps, [2]int{start, end})
+	}
+
+	if !c.typewriter {
+		c.revealed = c.total
+	}
+	return c, nil
+}
+
+func (c *codeSlide) Init() tea.Cmd {
+	if c.revealed < c.total {
+		return tea.Tick(30*time.Millisecond, func(t time.Time) tea.Msg {
+			return tickMsg{}
+		})
+	}
+	return nil
+}
+
+func (c *codeSlide) reset() {
+	if c.typewriter {
+		c.revealed = 0
+	}
+}
+
+// complete solo tiene sentido con typewriter: sin él, el código está
+// completo desde el principio y el slide no tiene un final propio
+func (c *codeSlide) complete() (bool, bool) {
+	return c.revealed >= c.total, c.typewriter
+}
+
+func (c *codeSlide) Update(msg tea.Msg) (slide, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tickMsg:
+		if c.revealed >= c.total {
+			return c, nil
+		}
+		c.revealed += 2
+		return c, tea.Tick(30*time.Millisecond, func(t time.Time) tea.Msg {
+			return tickMsg{}
+		})
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if c.offset > 0 {
+				c.offset--
+			}
+		case "down", "j":
+			if c.offset < len(c.lines)-c.visibleLines() {
+				c.offset++
+			}
+		}
+	}
+	return c, nil
+}
+
+func (c *codeSlide) next() bool {
+	if c.step >= len(c.steps) {
+		return false
+	}
+	c.step++
+	c.scrollToStep()
+	return true
+}
+
+func (c *codeSlide) prev() bool {
+	if c.step == 0 {
+		return false
+	}
+	c.step--
+	c.scrollToStep()
+	return true
+}
+
+// visibleLines es la cantidad de líneas de código que entran bajo el título
+func (c *codeSlide) visibleLines() int {
+	return slideHeight - 2
+}
+
+// scrollToStep desplaza el código para que el rango destacado quede a la vista
+func (c *codeSlide) scrollToStep() {
+	if c.step == 0 {
+		return
+	}
+	r := c.steps[c.step-1]
+	visible := c.visibleLines()
+	if r[0]-1 < c.offset || r[1] > c.offset+visible {
+		c.offset = r[0] - 2
+	}
+	c.offset = max(0, min(c.offset, len(c.lines)-visible))
+}
+
+func (c *codeSlide) View() string {
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(c.title) + "\n\n")
+
+	// La memoria del lexer (comentarios de bloque) recorre todas las líneas
+	// anteriores aunque no se muestren
+	state := lexState{}
+	remaining := c.revealed
+	width := slideWidth - 4 - codeGutter
+	var lines []string
+	for i, line := range c.lines {
+		spans := highlightLine(c.lang, line, &state)
+		shown := min(remaining, len([]rune(line)))
+		remaining -= len([]rune(line)) + 1
+		if i < c.offset || i >= c.offset+c.visibleLines() {
+			continue
+		}
+		if shown < 0 {
+			lines = append(lines, "")
+			continue
+		}
+
+		dim := c.step > 0 && (i+1 < c.steps[c.step-1][0] || i+1 > c.steps[c.step-1][1])
+		gutter := gutterStyle.Render(fmt.Sprintf("%3d │ ", i+1))
+		if !dim && c.step > 0 {
+			gutter = subheadingStyle.Render(fmt.Sprintf("%3d ▶ ", i+1))
+		}
+
+		spans = cutSpans(spans, shown)
+		if lipgloss.Width(line) > width && shown >= len([]rune(line)) {
+			spans = append(cutSpans(spans, width-1), span{"…", gutterStyle})
+		} else {
+			spans = cutSpans(spans, width)
+		}
+
+		var text strings.Builder
+		for _, sp := range spans {
+			if dim {
+				text.WriteString(dimCodeStyle.Render(sp.text))
+			} else {
+				text.WriteString(sp.style.Render(sp.text))
+			}
+		}
+		if remaining < 0 {
+			// Línea que se está escribiendo
+			text.WriteString(cursorStyle.Render("▌"))
+		}
+		lines = append(lines, gutter+text.String())
+	}
+
+	sb.WriteString(strings.Join(lines, "\n"))
+	return renderFrame(sb.String())
+}
+
+// cutSpans recorta los trozos a n caracteres
+func cutSpans(spans []span, n int) []span {
+	var out []span
+	for _, sp := range spans {
+		if n <= 0 {
+			break
+		}
+		runes := []rune(sp.text)
+		if len(runes) > n {
+			runes = runes[:n]
+		}
+		out = append(out, span{string(runes), sp.style})
+		n -= len(runes)
+	}
+	return out
+}
+
+// Estilos del resaltado de código
+var (
+	gutterStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#6C6C80"))
+	dimCodeStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#4A4A5A"))
+	cursorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#FAFAFA"))
+	plainStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#FAFAFA"))
+	keywordStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF10F0")).Bold(true)
+	stringStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#10FF50"))
+	commentStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#6C6C80")).Italic(true)
+	numberStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFF10"))
+	typeStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#10F0FF"))
+	variableStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF8C10"))
+)
+
+// lexer describe lo que el resaltador reconoce de cada lenguaje
+type lexer struct {
+	keywords     map[string]bool
+	types        map[string]bool
+	lineComment  string
+	blockComment [2]string
+	quotes       string
+	variables    bool // $VAR y ${VAR} del shell
+	keys         bool // claves "clave:" de YAML y JSON
+}
+
+func wordSet(words string) map[string]bool {
+	set := map[string]bool{}
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+var lexers = map[string]lexer{
+	"go": {
+		keywords: wordSet("break case chan const continue default defer else fallthrough for func go goto if " +
+			"import interface map package range return select struct switch type var nil true false iota"),
+		types: wordSet("bool byte complex64 complex128 error float32 float64 int int8 int16 int32 int64 rune " +
+			"string uint uint8 uint16 uint32 uint64 uintptr any append cap close copy delete len make new panic print println recover"),
+		lineComment:  "//",
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'`",
+	},
+	"sh": {
+		keywords:    wordSet("if then else elif fi for while until do done case esac in function return export local readonly set unset shift exit"),
+		types:       wordSet("echo cd ls cat grep sed awk printf read source test go git make curl"),
+		lineComment: "#",
+		quotes:      "\"'",
+		variables:   true,
+	},
+	"yaml": {
+		keywords:    wordSet("true false null yes no on off ~"),
+		lineComment: "#",
+		quotes:      "\"'",
+		keys:        true,
+	},
+	"json": {
+		keywords: wordSet("true false null"),
+		quotes:   "\"",
+		keys:     true,
+	},
+	"": {},
+}
+
+func init() {
+	lexers["bash"] = lexers["sh"]
+	lexers["shell"] = lexers["sh"]
+	lexers["yml"] = lexers["yaml"]
+}
+
+// lexState guarda lo que una línea le deja a la siguiente
+type lexState struct {
+	inComment bool
+}
+
+// highlightLine divide una línea en trozos con el estilo de cada token
+func highlightLine(lang, line string, state *lexState) []span {
+	lx := lexers[lang]
+	runes := []rune(line)
+	var spans []span
+	emit := func(text string, style lipgloss.Style) {
+		if text != "" {
+			spans = append(spans, span{text, style})
+		}
+	}
+
+	i := 0
+	if state.inComment {
+		end := strings.Index(line, lx.blockComment[1])
+		if end < 0 {
+			emit(line, commentStyle)
+			return spans
+		}
+		end = len([]rune(line[:end])) + len(lx.blockComment[1])
+		emit(string(runes[:end]), commentStyle)
+		state.inComment = false
+		i = end
+	}
+
+	// En YAML, "clave:" al inicio de la línea (también tras "- ")
+	if lx.keys && lang != "json" {
+		trimmed := strings.TrimLeft(string(runes[i:]), " -")
+		if key, _, ok := strings.Cut(trimmed, ":"); ok && key != "" && !strings.ContainsAny(key, "\"'#") {
+			prefix := len(runes[i:]) - len([]rune(trimmed))
+			emit(string(runes[i:i+prefix]), plainStyle)
+			emit(key, typeStyle)
+			i += prefix + len([]rune(key))
+		}
+	}
+
+	for i < len(runes) {
+		rest := string(runes[i:])
+		r := runes[i]
+		switch {
+		case lx.lineComment != "" && strings.HasPrefix(rest, lx.lineComment) &&
+			(lx.lineComment != "#" || i == 0 || runes[i-1] == ' ' || runes[i-1] == '\t'):
+			emit(rest, commentStyle)
+			return spans
+
+		case lx.blockComment[0] != "" && strings.HasPrefix(rest, lx.blockComment[0]):
+			end := strings.Index(rest[len(lx.blockComment[0]):], lx.blockComment[1])
+			if end < 0 {
+				emit(rest, commentStyle)
+				state.inComment = true
+				return spans
+			}
+			n := len([]rune(rest[:len(lx.blockComment[0])+end])) + len(lx.blockComment[1])
+			emit(string(runes[i:i+n]), commentStyle)
+			i += n
+
+		case strings.ContainsRune(lx.quotes, r):
+			j := i + 1
+			for j < len(runes) && runes[j] != r {
+				if runes[j] == '\\' && r != '`' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(runes))
+			style := stringStyle
+			// En JSON, un string seguido de ":" es una clave
+			if lx.keys && lang == "json" && strings.HasPrefix(strings.TrimLeft(string(runes[j:]), " "), ":") {
+				style = typeStyle
+			}
+			emit(string(runes[i:j]), style)
+			i = j
+
+		case lx.variables && r == '$':
+			j := i + 1
+			if j < len(runes) && runes[j] == '{' {
+				for j < len(runes) && runes[j] != '}' {
+					j++
+				}
+				j = min(j+1, len(runes))
+			} else {
+				for j < len(runes) && (isWordRune(runes[j]) || (j == i+1 && strings.ContainsRune("?#@*$!0123456789", runes[j]))) {
+					j++
+				}
+			}
+			emit(string(runes[i:j]), variableStyle)
+			i = j
+
+		case r >= '0' && r <= '9' || (r == '-' && i+1 < len(runes) && runes[i+1] >= '0' && runes[i+1] <= '9' && (i == 0 || !isWordRune(runes[i-1]))):
+			j := i + 1
+			for j < len(runes) && (isWordRune(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			emit(string(runes[i:j]), numberStyle)
+			i = j
+
+		case isWordRune(r):
+			j := i
+			for j < len(runes) && (isWordRune(runes[j]) || (lx.variables && runes[j] == '-')) {
+				j++
+			}
+			word := string(runes[i:j])
+			switch {
+			case lx.keywords[word]:
+				emit(word, keywordStyle)
+			case lx.types[word]:
+				emit(word, typeStyle)
+			default:
+				emit(word, plainStyle)
+			}
+			i = j
+
+		default:
+			emit(string(r), plainStyle)
+			i++
+		}
+	}
+	return spans
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r > 127
+}
+
+// Estilos del Markdown de los decks
+var (
+	headingStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#FF10F0"))
+
+	subheadingStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#10F0FF"))
+
+	codeStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#10FF50")).
+			Background(lipgloss.Color("#2A2A3A"))
+
+	quoteStyle = lipgloss.NewStyle().
+			Italic(true).
+			Foreground(lipgloss.Color("#A0A0B0"))
+
+	tableBorderStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#7D56F4"))
+)
+
+// loadDeck lee un deck en Markdown. Los slides se separan con una línea
+// "---" y cada uno puede empezar con un bloque de metadatos entre líneas
+// "+++" con pares "clave: valor". La clave "kind" elige el tipo de slide:
+//
+//	markdown   (por defecto) el cuerpo se muestra como Markdown
+//	credits    créditos que suben por la pantalla (ver newCreditsSlide)
+//	chart      barras (ver newBarChartSlide y parseChartData)
+//	line, area, sparkline
+//	           series en el tiempo, con los mismos datos que chart
+//	pie, donut proporciones de una serie (ver newPieSlide)
+//	particles  simulación de partículas; clave title
+//	gradient   texto pintado con un degradé (ver newGradientSlide)
+//	code       código resaltado; claves title, lang (go, sh, yaml, json),
+//	           steps ("1-3, 5": rangos de líneas a destacar con →) y
+//	           typewriter (true para escribirlo carácter por carácter)
+//	command    salida en vivo de un comando (ver newCommandSlide)
+//	poll       encuesta o quiz que vota el público (ver newPollSlide)
+//	diagram    cajas y flechas descritas en el cuerpo (ver newDiagramSlide)
+//
+// Las claves transition, duration y easing eligen la transición con la que
+// se entra al slide, y animation si al volver al slide su animación se
+// repite (replay, por defecto) o sigue donde quedó (freeze). advance
+// configura el avance automático (ver parseAdvance). Si el primer
+// bloque del archivo tiene metadatos sin "kind" ni cuerpo, son los valores
+// por defecto de todo el deck.
+//
+// Lo que sigue a una línea "???" son las notas del presentador del slide.
+func loadDeck(path string) (deck, error) {
+	var d deck
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return d, err
+	}
+
+	defaults := map[string]string{}
+	for i, chunk := range splitSlides(string(data)) {
+		meta, body, err := splitFrontMatter(chunk)
+		if err == nil && i == 0 && len(meta) > 0 && meta["kind"] == "" && strings.TrimSpace(body) == "" {
+			defaults = meta
+			continue
+		}
+
+		body, notes := splitNotes(body)
+		var spec transitionSpec
+		if err == nil {
+			spec, err = parseTransition(meta, defaults)
+		}
+		var freeze bool
+		if err == nil {
+			switch animation := metaOr(meta, "animation", metaOr(defaults, "animation", "replay")); animation {
+			case "replay":
+			case "freeze":
+				freeze = true
+			default:
+				err = fmt.Errorf("animación desconocida %q (se espera replay o freeze)", animation)
+			}
+		}
+		var advance advanceSpec
+		if err == nil {
+			advance, err = parseAdvance(meta, defaults)
+		}
+		if err == nil {
+			var s slide
+			s, err = buildSlide(meta, body, filepath.Dir(path))
+			d.slides = append(d.slides, s)
+			d.transitions = append(d.transitions, spec)
+			d.notes = append(d.notes, notes)
+			d.freeze = append(d.freeze, freeze)
+			d.advance = append(d.advance, advance)
+		}
+		if err != nil {
+			return d, fmt.Errorf("%s: slide %d: %w", path, i+1, err)
+		}
+	}
+
+	if len(d.slides) == 0 {
+		return d, fmt.Errorf("%s: el deck no tiene slides", path)
+	}
+	return d, nil
+}
+
+// parseAdvance lee la clave advance de un slide o, si no la tiene, la del
+// deck: una duración ("8s"), "end" para pasar apenas el slide termina u
+// "off" para no pasar solo. Con "end", los slides sin un final propio
+// (ver completer) pasan con el tiempo por defecto del deck.
+func parseAdvance(meta, defaults map[string]string) (advanceSpec, error) {
+	def, err := parseAdvanceValue(defaults["advance"])
+	if err != nil {
+		return def, err
+	}
+	value, ok := meta["advance"]
+	if !ok {
+		return def, nil
+	}
+	spec, err := parseAdvanceValue(value)
+	if spec.onEnd && spec.after == 0 {
+		spec.after = def.after
+	}
+	return spec, err
+}
+
+func parseAdvanceValue(value string) (advanceSpec, error) {
+	switch value {
+	case "":
+		return advanceSpec{}, nil
+	case "end":
+		return advanceSpec{onEnd: true}, nil
+	case "off":
+		return advanceSpec{off: true}, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return advanceSpec{}, fmt.Errorf("avance %q inválido (se espera una duración, end u off)", value)
+	}
+	return advanceSpec{after: d}, nil
+}
+
+// splitNotes separa las notas del presentador, que van después de "???"
+func splitNotes(body string) (string, string) {
+	lines := strings.Split(body, "\n")
+	inCode := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+		}
+		if !inCode && strings.TrimSpace(line) == "???" {
+			return strings.Join(lines[:i], "\n"), strings.TrimSpace(strings.Join(lines[i+1:], "\n"))
+		}
+	}
+	return body, ""
+}
+
+// splitSlides corta el documento en cada línea "---" que no esté dentro de
+// un bloque de código
+func splitSlides(doc string) []string {
+	var chunks []string
+	var current []string
+	inCode := false
+
+	for _, line := range strings.Split(strings.ReplaceAll(doc, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+		}
+		if !inCode && strings.TrimSpace(line) == "---" {
+			chunks = append(chunks, strings.Join(current, "\n"))
+			current = nil
+			continue
+		}
+		current = append(current, line)
+	}
+	chunks = append(chunks, strings.Join(current, "\n"))
+
+	var slides []string
+	for _, chunk := range chunks {
+		if strings.TrimSpace(chunk) != "" {
+			slides = append(slides, chunk)
+		}
+	}
+	return slides
+}
+
+// splitFrontMatter separa el bloque "+++" inicial del cuerpo del slide
+func splitFrontMatter(chunk string) (map[string]string, string, error) {
+	meta := map[string]string{}
+	lines := strings.Split(strings.TrimLeft(chunk, "\n"), "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "+++" {
+		return meta, chunk, nil
+	}
+
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "+++" {
+			return meta, strings.Join(lines[i+1:], "\n"), nil
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, "", fmt.Errorf("metadato inválido %q", line)
+		}
+		meta[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+	}
+	return nil, "", fmt.Errorf("falta el cierre \"+++\" de los metadatos")
+}
+
+// buildSlide arma un slide según su "kind"; dir es la carpeta del deck, para
+// resolver los archivos que mencionan los metadatos
+func buildSlide(meta map[string]string, body, dir string) (slide, error) {
+	switch kind := meta["kind"]; kind {
+	case "", "markdown":
+		parts, err := splitFragments(body, meta["reveal"])
+		if err != nil {
+			return nil, err
+		}
+		return &markdownSlide{source: body, parts: parts}, nil
+
+	case "credits":
+		return newCreditsSlide(meta, body, dir)
+
+	case "chart":
+		return newBarChartSlide(meta, dir)
+
+	case "line", "area", "sparkline":
+		data, err := parseChartData(meta, dir)
+		if err != nil {
+			return nil, err
+		}
+		return &lineChartSlide{
+			chartData:    data,
+			seriesReveal: seriesReveal{enabled: meta["reveal"] == "series"},
+			title:        metaOr(meta, "title", "Gráfico"),
+			kind:         kind,
+			animating:    true,
+		}, nil
+
+	case "command":
+		return newCommandSlide(meta, body, dir)
+
+	case "poll":
+		return newPollSlide(meta, body, dir)
+
+	case "pie", "donut":
+		return newPieSlide(meta, dir, kind == "donut")
+
+	case "particles":
+		return &particleSlide{title: metaOr(meta, "title", "Simulación de Partículas")}, nil
+
+	case "gradient":
+		return newGradientSlide(meta, body)
+
+	case "code":
+		return newCodeSlide(meta, body)
+
+	case "diagram":
+		return newDiagramSlide(meta, body)
+
+	default:
+		return nil, fmt.Errorf("tipo de slide desconocido %q", kind)
+	}
+}
+
+func metaOr(meta map[string]string, key, fallback string) string {
+	if v, ok := meta[key]; ok && v != "" {
+		return v
+	}
+	return fallback
+}
+
+// splitList separa una lista "a, b, c" de los metadatos
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseFloats(value string) ([]float64, error) {
+	var nums []float64
+	for _, item := range splitList(value) {
+		n, err := strconv.ParseFloat(item, 64)
+		if err != nil {
+			return nil, fmt.Errorf("valor inválido %q", item)
+		}
+		nums = append(nums, n)
+	}
+	return nums, nil
+}
+
+// parseChartData lee los datos de un gráfico desde los metadatos:
+//
+//	values: 1, 2, 3                    una sola serie
+//	series: Ventas: 1, 2; Costos: 3, 4 varias series separadas por ";"
+//	csv: datos.csv                     etiquetas en la primera columna y una
+//	                                   serie por columna; la primera fila
+//	                                   tiene los nombres
+//	json: datos.json                   {"labels": [...], "series":
+//	                                   [{"name": "...", "values": [...]}]}
+//	labels, min, max
+//	reveal: series                     las series aparecen de a una con →
+//
+// Las rutas son relativas a dir, la carpeta del deck.
+func parseChartData(meta map[string]string, dir string) (chartData, error) {
+	d := chartData{labels: splitList(meta["labels"])}
+	resolve := func(path string) string {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		return path
+	}
+
+	switch {
+	case meta["csv"] != "":
+		labels, series, err := readChartCSV(resolve(meta["csv"]))
+		if err != nil {
+			return d, err
+		}
+		d.series = series
+		if len(d.labels) == 0 {
+			d.labels = labels
+		}
+
+	case meta["json"] != "":
+		labels, series, err := readChartJSON(resolve(meta["json"]))
+		if err != nil {
+			return d, err
+		}
+		d.series = series
+		if len(d.labels) == 0 {
+			d.labels = labels
+		}
+
+	case meta["series"] != "":
+		for _, part := range strings.Split(meta["series"], ";") {
+			name, values, ok := strings.Cut(part, ":")
+			if !ok {
+				return d, fmt.Errorf("serie sin nombre %q (se espera \"nombre: 1, 2, 3\")", strings.TrimSpace(part))
+			}
+			targets, err := parseFloats(values)
+			if err != nil {
+				return d, err
+			}
+			d.series = append(d.series, chartSeries{name: strings.TrimSpace(name), targets: targets})
+		}
+
+	default:
+		targets, err := parseFloats(meta["values"])
+		if err != nil {
+			return d, err
+		}
+		d.series = []chartSeries{{name: metaOr(meta, "title", "Serie"), targets: targets}}
+	}
+
+	if len(d.series) == 0 || len(d.series[0].targets) == 0 {
+		return d, fmt.Errorf("el gráfico no tiene valores")
+	}
+	n := len(d.series[0].targets)
+	for _, s := range d.series {
+		if len(s.targets) != n {
+			return d, fmt.Errorf("la serie %q tiene %d valores y se esperaban %d", s.name, len(s.targets), n)
+		}
+	}
+	for len(d.labels) < n {
+		d.labels = append(d.labels, strconv.Itoa(len(d.labels)+1))
+	}
+	d.labels = d.labels[:n]
+
+	for key, dst := range map[string]*float64{"min": &d.minValue, "max": &d.maxValue} {
+		if v, ok := meta[key]; ok {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return d, fmt.Errorf("%s inválido %q", key, v)
+			}
+			*dst = n
+		}
+	}
+	return d, nil
+}
+
+// newBarChartSlide arma un gráfico de barras; además de los datos (ver
+// parseChartData) acepta:
+//
+//	orientation: vertical | horizontal
+//	mode: grouped | stacked
+func newBarChartSlide(meta map[string]string, dir string) (slide, error) {
+	data, err := parseChartData(meta, dir)
+	if err != nil {
+		return nil, err
+	}
+	b := &barChartSlide{
+		chartData:    data,
+		seriesReveal: seriesReveal{enabled: meta["reveal"] == "series"},
+		title:        metaOr(meta, "title", "Gráfico"),
+		animating:    true,
+	}
+
+	switch o := metaOr(meta, "orientation", "vertical"); o {
+	case "vertical":
+	case "horizontal":
+		b.horizontal = true
+	default:
+		return nil, fmt.Errorf("orientación desconocida %q", o)
+	}
+	switch mode := metaOr(meta, "mode", "grouped"); mode {
+	case "grouped":
+	case "stacked":
+		b.stacked = true
+	default:
+		return nil, fmt.Errorf("modo de gráfico desconocido %q", mode)
+	}
+	return b, nil
+}
+
+// newPieSlide arma una torta con la primera serie de los datos (ver
+// parseChartData); además acepta:
+//
+//	hole: 0.5              radio del agujero de la dona, entre 0 y 0.9
+//	aspect: 2              alto de una celda sobre su ancho
+//	backend: blocks | braille
+func newPieSlide(meta map[string]string, dir string, donut bool) (slide, error) {
+	data, err := parseChartData(meta, dir)
+	if err != nil {
+		return nil, err
+	}
+	p := &pieSlide{
+		title:     metaOr(meta, "title", "Gráfico"),
+		labels:    data.labels,
+		values:    data.series[0].targets,
+		aspect:    2,
+		animating: true,
+	}
+	if donut {
+		p.hole = 0.5
+	}
+
+	total := 0.0
+	for i, v := range p.values {
+		if v < 0 {
+			return nil, fmt.Errorf("la porción %q es negativa", p.labels[i])
+		}
+		total += v
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("la torta no tiene valores")
+	}
+
+	for key, dst := range map[string]*float64{"hole": &p.hole, "aspect": &p.aspect} {
+		if v, ok := meta[key]; ok {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s inválido %q", key, v)
+			}
+			*dst = n
+		}
+	}
+	if p.hole < 0 || p.hole > 0.9 {
+		return nil, fmt.Errorf("hole debe estar entre 0 y 0.9")
+	}
+	if p.aspect <= 0 {
+		return nil, fmt.Errorf("aspect debe ser positivo")
+	}
+
+	switch backend := metaOr(meta, "backend", "blocks"); backend {
+	case "blocks":
+	case "braille":
+		p.braille = true
+	default:
+		return nil, fmt.Errorf("backend desconocido %q", backend)
+	}
+	return p, nil
+}
+
+// newCommandSlide arma un slide que ejecuta un comando:
+//
+//	command: go test ./...   o el cuerpo del slide, sin las cercas ```
+//	timeout: 30s
+//	cwd: ejemplos            relativo a la carpeta del deck
+func newCommandSlide(meta map[string]string, body, dir string) (slide, error) {
+	command := meta["command"]
+	if command == "" {
+		var lines []string
+		for _, line := range strings.Split(strings.TrimSpace(body), "\n") {
+			if !strings.HasPrefix(strings.TrimSpace(line), "```") {
+				lines = append(lines, line)
+			}
+		}
+		command = strings.TrimSpace(strings.Join(lines, "\n"))
+	}
+	if command == "" {
+		return nil, fmt.Errorf("falta el comando a ejecutar")
+	}
+
+	timeout, err := time.ParseDuration(metaOr(meta, "timeout", "30s"))
+	if err != nil || timeout <= 0 {
+		return nil, fmt.Errorf("timeout inválido %q", meta["timeout"])
+	}
+
+	cwd := metaOr(meta, "cwd", ".")
+	if !filepath.IsAbs(cwd) {
+		cwd = filepath.Join(dir, cwd)
+	}
+	return &commandSlide{
+		title:   metaOr(meta, "title", "Demo en Vivo"),
+		command: command,
+		dir:     cwd,
+		timeout: timeout,
+	}, nil
+}
+
+// readChartCSV lee las categorías y las series de un CSV
+func readChartCSV(path string) ([]string, []chartSeries, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(rows) < 2 || len(rows[0]) < 2 {
+		return nil, nil, fmt.Errorf("%s: se necesita un encabezado y al menos una fila con valores", path)
+	}
+
+	series := make([]chartSeries, len(rows[0])-1)
+	for i := range series {
+		series[i].name = strings.TrimSpace(rows[0][i+1])
+	}
+	var labels []string
+	for _, row := range rows[1:] {
+		labels = append(labels, strings.TrimSpace(row[0]))
+		for i := range series {
+			v, err := strconv.ParseFloat(strings.TrimSpace(row[i+1]), 64)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: valor inválido %q", path, row[i+1])
+			}
+			series[i].targets = append(series[i].targets, v)
+		}
+	}
+	return labels, series, nil
+}
+
+// readChartJSON lee las categorías y las series de un archivo JSON
+func readChartJSON(path string) ([]string, []chartSeries, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var file struct {
+		Labels []string `json:"labels"`
+		Series []struct {
+			Name   string    `json:"name"`
+			Values []float64 `json:"values"`
+		} `json:"series"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var series []chartSeries
+	for _, s := range file.Series {
+		series = append(series, chartSeries{name: s.Name, targets: s.Values})
+	}
+	return file.Labels, series, nil
+}
+
+// span es un trozo de texto con un estilo uniforme
+type span struct {
+	text  string
+	style lipgloss.Style
+}
+
+// renderMarkdown convierte el Markdown de un slide en líneas ya estilizadas
+// de a lo sumo width celdas. El primer encabezado "#" se devuelve aparte
+// como título del slide.
+func renderMarkdown(src string, width int) (string, []string) {
+	var title string
+	var out []string
+	var paragraph []string
+	var code []string
+	var table [][]string
+	inCode := false
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			out = append(out, wrapSpans(parseInline(strings.Join(paragraph, " "), lipgloss.NewStyle()), width)...)
+			paragraph = nil
+		}
+	}
+	flushTable := func() {
+		if len(table) > 0 {
+			out = append(out, renderTable(table, width)...)
+			table = nil
+		}
+	}
+	flushCode := func() {
+		for _, c := range code {
+			out = append(out, codeStyle.Render(padRight(truncate(c, width), width)))
+		}
+		code = nil
+	}
+
+	for _, line := range strings.Split(src, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") {
+			if inCode {
+				flushCode()
+			} else {
+				flushParagraph()
+				flushTable()
+			}
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			code = append(code, strings.ReplaceAll(line, "\t", "    "))
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "|") {
+			flushParagraph()
+			if !isTableSeparator(trimmed) {
+				table = append(table, splitTableRow(trimmed))
+			}
+			continue
+		}
+		flushTable()
+
+		indent := (len(line) - len(strings.TrimLeft(line, " \t"))) / 2
+		switch {
+		case trimmed == "":
+			flushParagraph()
+			if len(out) > 0 && out[len(out)-1] != "" {
+				out = append(out, "")
+			}
+
+		case strings.HasPrefix(trimmed, "#"):
+			flushParagraph()
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			text := strings.TrimSpace(trimmed[level:])
+			switch {
+			case level == 1 && title == "":
+				title = text
+			case level <= 2:
+				out = append(out, wrapSpans(parseInline(text, headingStyle), width)...)
+			default:
+				out = append(out, wrapSpans(parseInline(text, subheadingStyle), width)...)
+			}
+
+		case strings.HasPrefix(trimmed, ">"):
+			flushParagraph()
+			text := strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+			for _, l := range wrapSpans(parseInline(text, quoteStyle), width-2) {
+				out = append(out, tableBorderStyle.Render("│ ")+l)
+			}
+
+		case isListItem(trimmed):
+			flushParagraph()
+			bullet, text := listMarker(trimmed)
+			pad := strings.Repeat("  ", indent)
+			for i, l := range wrapSpans(parseInline(text, lipgloss.NewStyle()), width-len(pad)-lipgloss.Width(bullet)) {
+				if i == 0 {
+					out = append(out, pad+subheadingStyle.Render(bullet)+l)
+				} else {
+					out = append(out, pad+strings.Repeat(" ", lipgloss.Width(bullet))+l)
+				}
+			}
+
+		default:
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	flushParagraph()
+	flushTable()
+	// Un bloque sin cerrar llega hasta el final del slide
+	flushCode()
+
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	return title, out
+}
+
+func isListItem(line string) bool {
+	if strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") || strings.HasPrefix(line, "+ ") {
+		return true
+	}
+	num, _, ok := strings.Cut(line, ". ")
+	_, err := strconv.Atoi(num)
+	return ok && err == nil
+}
+
+// listMarker devuelve la viñeta a mostrar y el texto del ítem
+func listMarker(line string) (string, string) {
+	if num, text, ok := strings.Cut(line, ". "); ok {
+		if _, err := strconv.Atoi(num); err == nil {
+			return num + ". ", text
+		}
+	}
+	return "• ", line[2:]
+}
+
+func isTableSeparator(line string) bool {
+	return strings.Trim(line, "|-: ") == "" && strings.Contains(line, "-")
+}
+
+func splitTableRow(line string) []string {
+	cells := strings.Split(strings.Trim(line, "|"), "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+// renderTable alinea las columnas de una tabla; la primera fila es el
+// encabezado. Si no entra en width, las celdas se recortan con "…".
+func renderTable(rows [][]string, width int) []string {
+	cols := 0
+	for _, row := range rows {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+
+	widths := make([]int, cols)
+	for _, row := range rows {
+		for i, cell := range row {
+			if w := lipgloss.Width(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	// Recorta la columna más ancha hasta que la tabla entre
+	sep := tableBorderStyle.Render(" │ ")
+	for {
+		total := 3 * (cols - 1)
+		widest := 0
+		for i, w := range widths {
+			total += w
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if total <= width || widths[widest] <= 1 {
+			break
+		}
+		widths[widest]--
+	}
+
+	var out []string
+	for r, row := range rows {
+		cells := make([]string, cols)
+		for i := range cells {
+			text := ""
+			if i < len(row) {
+				text = row[i]
+			}
+			cell := padRight(truncate(text, widths[i]), widths[i])
+			if r == 0 {
+				cell = lipgloss.NewStyle().Bold(true).Render(cell)
+			}
+			cells[i] = cell
+		}
+		out = append(out, strings.Join(cells, sep))
+
+		if r == 0 {
+			lines := make([]string, cols)
+			for i, w := range widths {
+				lines[i] = strings.Repeat("─", w)
+			}
+			out = append(out, tableBorderStyle.Render(strings.Join(lines, "─┼─")))
+		}
+	}
+	return out
+}
+
+// parseInline reconoce **negrita**, *cursiva* o _cursiva_ y `código`
+func parseInline(text string, base lipgloss.Style) []span {
+	var spans []span
+	var current strings.Builder
+	bold, italic, code := false, false, false
+
+	style := func() lipgloss.Style {
+		if code {
+			return codeStyle
+		}
+		return base.Bold(bold || base.GetBold()).Italic(italic || base.GetItalic())
+	}
+	flush := func() {
+		if current.Len() > 0 {
+			spans = append(spans, span{current.String(), style()})
+			current.Reset()
+		}
+	}
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '`':
+			flush()
+			code = !code
+		case code:
+			current.WriteRune(r)
+		case r == '*' && i+1 < len(runes) && runes[i+1] == '*':
+			flush()
+			bold = !bold
+			i++
+		case r == '*' || (r == '_' && (i == 0 || runes[i-1] == ' ' || i == len(runes)-1 || runes[i+1] == ' ')):
+			flush()
+			italic = !italic
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return spans
+}
+
+// wrapSpans reparte los trozos en líneas de a lo sumo width celdas,
+// cortando en los espacios y conservando el estilo de cada palabra
+func wrapSpans(spans []span, width int) []string {
+	type word struct {
+		pieces []span
+		width  int
+	}
+
+	var words []word
+	var current word
+	for _, sp := range spans {
+		parts := strings.Split(sp.text, " ")
+		for i, part := range parts {
+			if i > 0 && current.width > 0 {
+				words = append(words, current)
+				current = word{}
+			}
+			if part != "" {
+				current.pieces = append(current.pieces, span{part, sp.style})
+				current.width += lipgloss.Width(part)
+			}
+		}
+	}
+	if current.width > 0 {
+		words = append(words, current)
+	}
+
+	var lines []string
+	var line strings.Builder
+	lineWidth := 0
+	for _, w := range words {
+		if lineWidth > 0 && lineWidth+1+w.width > width {
+			lines = append(lines, line.String())
+			line.Reset()
+			lineWidth = 0
+		}
+		if lineWidth > 0 {
+			line.WriteString(" ")
+			lineWidth++
+		}
+		for _, piece := range w.pieces {
+			line.WriteString(piece.style.Render(piece.text))
+		}
+		lineWidth += w.width
+	}
+	if lineWidth > 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// truncate recorta texto sin estilos a width celdas
+func truncate(text string, width int) string {
+	if lipgloss.Width(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+1 > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+func padRight(text string, width int) string {
+	if w := lipgloss.Width(text); w < width {
+		return text + strings.Repeat(" ", width-w)
+	}
+	return text
+}
+
+// transitionSpec describe cómo se entra a un slide
+type transitionSpec struct {
+	kind     string
+	duration time.Duration
+	easing   string
+}
+
+const defaultTransitionDuration = 600 * time.Millisecond
+
+var transitionKinds = map[string]bool{
+	"none": true, "push": true, "wipe": true, "dissolve": true, "fade": true, "matrix": true,
+}
+
+// parseTransition lee transition, duration y easing de los metadatos del
+// slide, usando los del deck cuando el slide no los define
+func parseTransition(meta, deck map[string]string) (transitionSpec, error) {
+	get := func(key, fallback string) string {
+		return metaOr(meta, key, metaOr(deck, key, fallback))
+	}
+
+	spec := transitionSpec{kind: get("transition", ""), easing: get("easing", "spring")}
+	if spec.kind != "" && !transitionKinds[spec.kind] {
+		return spec, fmt.Errorf("transición desconocida %q", spec.kind)
+	}
+	switch spec.easing {
+	case "spring", "bounce", "linear":
+	default:
+		return spec, fmt.Errorf("easing desconocido %q", spec.easing)
+	}
+
+	duration, err := time.ParseDuration(get("duration", defaultTransitionDuration.String()))
+	if err != nil {
+		return spec, fmt.Errorf("duration: %w", err)
+	}
+	spec.duration = duration
+	return spec, nil
+}
+
+// transitionTickMsg es un cuadro de la transición número gen
+type transitionTickMsg struct{ gen int }
+
+const transitionFPS = 30
+
+func transitionTick(gen int) tea.Cmd {
+	return tea.Tick(time.Second/transitionFPS, func(t time.Time) tea.Msg {
+		return transitionTickMsg{gen}
+	})
+}
+
+// transition mezcla el slide anterior (congelado) con el nuevo. El avance
+// lo lleva un resorte de harmonica que se asienta en la duración pedida.
+type transition struct {
+	kind          string
+	from          [][]cell
+	spring        harmonica.Spring
+	linear        bool
+	pos, vel      float64
+	frame, frames int
+}
+
+func newTransition(spec transitionSpec, from string) *transition {
+	frames := int(spec.duration.Seconds() * transitionFPS)
+	if frames < 1 {
+		frames = 1
+	}
+
+	// Un resorte con frecuencia angular ω se asienta en unos 7/ω segundos
+	frequency := 7 / spec.duration.Seconds()
+	damping := 1.0
+	if spec.easing == "bounce" {
+		damping = 0.4
+	}
+
+	return &transition{
+		kind:   spec.kind,
+		from:   parseCells(from),
+		spring: harmonica.NewSpring(harmonica.FPS(transitionFPS), frequency, damping),
+		linear: spec.easing == "linear",
+		frames: frames,
+	}
+}
+
+// step avanza un cuadro y devuelve true cuando la transición terminó
+func (t *transition) step() bool {
+	t.frame++
+	if t.linear {
+		t.pos = float64(t.frame) / float64(t.frames)
+	} else {
+		t.pos, t.vel = t.spring.Update(t.pos, t.vel, 1)
+	}
+	return t.frame >= t.frames
+}
+
+func (t *transition) progress() float64 {
+	return math.Max(0, math.Min(1, t.pos))
+}
+
+// compose arma el cuadro actual celda por celda a partir del slide anterior
+// y del nuevo
+func (t *transition) compose(to string) string {
+	from, next := t.from, parseCells(to)
+	height := max(len(from), len(next))
+	width := 0
+	for _, row := range append(append([][]cell{}, from...), next...) {
+		width = max(width, len(row))
+	}
+	from = padCells(from, width, height)
+	next = padCells(next, width, height)
+
+	p := t.progress()
+	out := make([][]cell, height)
+	for y := range out {
+		out[y] = make([]cell, width)
+		for x := range out[y] {
+			switch t.kind {
+			case "push":
+				offset := int(math.Round(p * float64(width)))
+				if x+offset < width {
+					out[y][x] = from[y][x+offset]
+				} else {
+					out[y][x] = next[y][x+offset-width]
+				}
+
+			case "wipe":
+				if float64(x) < p*float64(width) {
+					out[y][x] = next[y][x]
+				} else {
+					out[y][x] = from[y][x]
+				}
+
+			case "dissolve":
+				if cellNoise(x, y) < p {
+					out[y][x] = next[y][x]
+				} else {
+					out[y][x] = from[y][x]
+				}
+
+			case "fade":
+				// Se oscurece el anterior hasta negro y se aclara el nuevo
+				if p < 0.5 {
+					c := from[y][x]
+					c.sgr = fadeSGR(c.sgr, 1-2*p)
+					out[y][x] = c
+				} else {
+					c := next[y][x]
+					c.sgr = fadeSGR(c.sgr, 2*p-1)
+					out[y][x] = c
+				}
+
+			case "matrix":
+				out[y][x] = matrixCell(from[y][x], next[y][x], x, y, height, p)
+
+			default:
+				out[y][x] = next[y][x]
+			}
+		}
+		fixWideCells(out[y])
+	}
+
+	return renderCells(out)
+}
+
+// cell es una celda de la terminal con el estilo SGR activo en ella. La
+// segunda mitad de un carácter ancho es una celda con width 0.
+type cell struct {
+	ch    string
+	sgr   string
+	width int
+}
+
+// parseCells convierte un string con secuencias ANSI en una grilla de
+// celdas. Solo se conservan las secuencias SGR (colores y atributos); las
+// demás se descartan para no dejar escapes cortados a la mitad.
+func parseCells(s string) [][]cell {
+	var grid [][]cell
+	for _, line := range strings.Split(s, "\n") {
+		var row []cell
+		sgr := ""
+		for i := 0; i < len(line); {
+			if line[i] == '\x1b' {
+				seq, n := readEscape(line[i:])
+				i += n
+				if strings.HasPrefix(seq, "\x1b[") && strings.HasSuffix(seq, "m") {
+					if params := seq[2 : len(seq)-1]; params == "" || params == "0" {
+						sgr = ""
+					} else {
+						sgr += seq
+					}
+				}
+				continue
+			}
+
+			r, n := utf8.DecodeRuneInString(line[i:])
+			i += n
+			w := runewidth.RuneWidth(r)
+			if w == 0 {
+				// Marca combinante: se suma a la celda anterior
+				if len(row) > 0 {
+					row[len(row)-1].ch += string(r)
+				}
+				continue
+			}
+			row = append(row, cell{ch: string(r), sgr: sgr, width: w})
+			if w == 2 {
+				row = append(row, cell{sgr: sgr})
+			}
+		}
+		grid = append(grid, row)
+	}
+	return grid
+}
+
+// readEscape devuelve la secuencia de escape al inicio de s y su largo
+func readEscape(s string) (string, int) {
+	if len(s) < 2 {
+		return s, len(s)
+	}
+	switch s[1] {
+	case '[':
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7E {
+				return s[:i+1], i + 1
+			}
+		}
+	case ']':
+		for i := 2; i < len(s); i++ {
+			if s[i] == '\a' {
+				return s[:i+1], i + 1
+			}
+			if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\' {
+				return s[:i+2], i + 2
+			}
+		}
+	default:
+		return s[:2], 2
+	}
+	return s, len(s)
+}
+
+// renderCells vuelve a armar el string, emitiendo un SGR solo cuando el
+// estilo cambia y reiniciando al final de cada línea
+func renderCells(grid [][]cell) string {
+	var sb strings.Builder
+	for y, row := range grid {
+		current := ""
+		for _, c := range row {
+			if c.width == 0 {
+				continue
+			}
+			if c.sgr != current {
+				sb.WriteString("\x1b[0m" + c.sgr)
+				current = c.sgr
+			}
+			sb.WriteString(c.ch)
+		}
+		if current != "" {
+			sb.WriteString("\x1b[0m")
+		}
+		if y < len(grid)-1 {
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+func padCells(grid [][]cell, width, height int) [][]cell {
+	out := make([][]cell, height)
+	for y := range out {
+		if y < len(grid) {
+			out[y] = append(out[y], grid[y]...)
+		}
+		for len(out[y]) < width {
+			out[y] = append(out[y], cell{ch: " ", width: 1})
+		}
+	}
+	return out
+}
+
+// fixWideCells reemplaza por espacios las mitades sueltas de caracteres
+// anchos que quedaron partidos al mezclar dos slides
+func fixWideCells(row []cell) {
+	for x := range row {
+		if row[x].width == 2 && (x+1 >= len(row) || row[x+1].width != 0) {
+			row[x] = cell{ch: " ", sgr: row[x].sgr, width: 1}
+		}
+		if row[x].width == 0 && (x == 0 || row[x-1].width != 2) {
+			row[x] = cell{ch: " ", sgr: row[x].sgr, width: 1}
+		}
+	}
+}
+
+// cellNoise devuelve un valor pseudoaleatorio estable en [0, 1) por celda
+func cellNoise(x, y int) float64 {
+	h := uint32(x)*2654435761 ^ uint32(y)*2246822519
+	h ^= h >> 15
+	h *= 2654435761
+	h ^= h >> 13
+	return float64(h%10000) / 10000
+}
+
+var (
+	matrixGlyphs    = []rune("ｱｲｳｴｵｶｷｸｹｺｻｼｽｾｿﾀﾁﾂﾃﾄ01")
+	matrixTailCells = 6
+)
+
+// foregroundSGR devuelve la secuencia SGR del color en el perfil de la
+// terminal, o "" si la terminal no tiene colores
+func foregroundSGR(hex string) string {
+	if seq := lipgloss.ColorProfile().Color(hex).Sequence(false); seq != "" {
+		return "\x1b[" + seq + "m"
+	}
+	return ""
+}
+
+// matrixCell hace caer una gota por columna: debajo de ella sigue el slide
+// anterior, sobre ella ya aparece el nuevo
+func matrixCell(from, next cell, x, y, height int, p float64) cell {
+	delay := cellNoise(x, 0) * 0.5
+	head := (p*1.5 - delay) * float64(height+matrixTailCells)
+
+	switch {
+	case float64(y) < head-float64(matrixTailCells):
+		return next
+	case float64(y) <= head:
+		glyph := matrixGlyphs[int(cellNoise(x, y+int(p*20))*float64(len(matrixGlyphs)))]
+		sgr := foregroundSGR("#10FF50")
+		if float64(y) > head-1 {
+			sgr = foregroundSGR("#E0FFE0")
+		}
+		return cell{ch: string(glyph), sgr: sgr, width: 1}
+	default:
+		return from
+	}
+}
+
+// fadeSGR reescribe los colores de una secuencia SGR multiplicando su
+// brillo por k, y los vuelve a expresar en el perfil de color de la
+// terminal. El texto sin color explícito se trata como gris claro.
+func fadeSGR(sgr string, k float64) string {
+	if k >= 1 {
+		return sgr
+	}
+
+	var attrs []string
+	fg, bg := [3]int{0xD0, 0xD0, 0xD0}, [3]int{}
+	hasBg := false
+
+	var params []string
+	for _, seq := range strings.Split(sgr, "\x1b[") {
+		if seq = strings.TrimSuffix(seq, "m"); seq != "" {
+			params = append(params, strings.Split(seq, ";")...)
+		}
+	}
+
+	num := func(i int) int {
+		if i < len(params) {
+			n, _ := strconv.Atoi(params[i])
+			return n
+		}
+		return 0
+	}
+	for i := 0; i < len(params); i++ {
+		n := num(i)
+		switch {
+		case n == 0:
+			attrs, hasBg = nil, false
+			fg = [3]int{0xD0, 0xD0, 0xD0}
+		case (n == 38 || n == 48) && num(i+1) == 2:
+			c := [3]int{num(i + 2), num(i + 3), num(i + 4)}
+			i += 4
+			if n == 38 {
+				fg = c
+			} else {
+				bg, hasBg = c, true
+			}
+		case (n == 38 || n == 48) && num(i+1) == 5:
+			c := color.Xterm256(num(i + 2))
+			i += 2
+			if n == 38 {
+				fg = c
+			} else {
+				bg, hasBg = c, true
+			}
+		case n >= 30 && n <= 37:
+			fg = color.Xterm256(n - 30)
+		case n >= 90 && n <= 97:
+			fg = color.Xterm256(n - 90 + 8)
+		case n >= 40 && n <= 47:
+			bg, hasBg = color.Xterm256(n-40), true
+		case n >= 100 && n <= 107:
+			bg, hasBg = color.Xterm256(n-100+8), true
+		case n == 39:
+			fg = [3]int{0xD0, 0xD0, 0xD0}
+		case n == 49:
+			hasBg = false
+		default:
+			attrs = append(attrs, params[i])
+		}
+	}
+
+	profile := lipgloss.ColorProfile()
+	scale := func(c [3]int) string {
+		return fmt.Sprintf("#%02X%02X%02X", int(float64(c[0])*k), int(float64(c[1])*k), int(float64(c[2])*k))
+	}
+	if seq := profile.Color(scale(fg)).Sequence(false); seq != "" {
+		attrs = append(attrs, seq)
+	}
+	if hasBg {
+		if seq := profile.Color(scale(bg)).Sequence(true); seq != "" {
+			attrs = append(attrs, seq)
+		}
+	}
+	if len(attrs) == 0 {
+		return ""
+	}
+	return "\x1b[" + strings.Join(attrs, ";") + "m"
+}
+
+func (m model) Init() tea.Cmd {
+	cmds := []tea.Cmd{m.wrap(m.currentIdx, m.slides[m.currentIdx].Init())}
+	if m.presenter {
+		cmds = append(cmds, clockTick())
+	}
+	if m.auto {
+		cmds = append(cmds, advanceTick())
+	}
+	return tea.Batch(cmds...)
+}
+
+// Update atiende un mensaje y le cuenta al control remoto si cambió algo
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	if next.remote != nil {
+		next.remote.publish(next.remoteState())
+	}
+	return next, cmd
+}
+
+func (m model) update(msg tea.Msg) (model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// Cualquier tecla pausa el avance automático; "a" lo retoma
+		if m.auto && m.prompt == "" && !m.overview && msg.String() == "a" {
+			m.autoPaused = !m.autoPaused
+			return m, nil
+		}
+		m.autoPaused = m.auto
+
+		if m.prompt != "" {
+			return m.updatePrompt(msg)
+		}
+		if m.overview {
+			return m.updateOverview(msg)
+		}
+		if m.query != "" {
+			switch msg.String() {
+			case "n":
+				return m.nextMatch(1)
+			case "N":
+				return m.nextMatch(-1)
+			case "esc":
+				m.query, m.matches = "", nil
+				return m, nil
+			}
+		}
+
 		switch msg.String() {
+		case "o":
+			m.overview, m.selected = true, m.currentIdx
+			return m, nil
+
+		case ".":
+			if m.paused {
+				m.paused = false
+				return m.resume()
+			}
+			m.paused = true
+			return m, nil
+
+		case "R":
+			return m.reset()
+
+		case "b":
+			return m.setBlank(!m.blank), nil
+
+		case "g", "/":
+			m.prompt, m.input = msg.String(), ""
+			return m, nil
+
 		case "ctrl+c", "q", "esc":
+			m.leave(m.currentIdx)
 			return m, tea.Quit
 
-		case "right", "l", "n", " ":
-			if m.currentIdx < len(m.slides)-1 {
-				m.currentIdx++
-				return m, m.slides[m.currentIdx].Init()
+		case "right", "l", "n", " ":
+			if next, cmd, ok := m.forward(); ok {
+				return next, cmd
+			}
+
+		case "left", "h", "p":
+			if prev, cmd, ok := m.backward(); ok {
+				return prev, cmd
+			}
+		}
+
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.layout()
+		return m, nil
+
+	case syncMsg:
+		if msg.idx < 0 || msg.idx >= len(m.slides) {
+			return m, nil
+		}
+		m.fragment[msg.idx] = msg.fragment
+		if msg.idx != m.currentIdx {
+			return m.goTo(msg.idx)
+		}
+		return m.showFragments()
+
+	case syncBlankMsg:
+		m.blank = bool(msg)
+		return m, nil
+
+	case syncJoinMsg:
+		// Una audiencia nueva arranca en el slide actual, con los votos
+		// que ya llegaron
+		m.link.send(m.currentIdx, m.fragment[m.currentIdx])
+		m.link.sendBlank(m.blank)
+		url := ""
+		for i, s := range m.slides {
+			p, ok := s.(*pollSlide)
+			if !ok || p.server == nil {
+				continue
+			}
+			if url == "" {
+				url = p.url
+				m.link.sendPolls(url)
+			}
+			for voter, b := range p.ballots {
+				m.link.sendVote(i, pollVoteMsg{voter: voter, name: b.name, option: b.option})
+			}
+		}
+		return m, nil
+
+	case syncPollsMsg:
+		for _, s := range m.slides {
+			if p, ok := s.(*pollSlide); ok {
+				p.url = string(msg)
+			}
+		}
+		return m, nil
+
+	case syncVoteMsg:
+		return m.mirrorVote(msg)
+
+	case remoteMsg:
+		m.paired = true
+		next, cmd := m.remoteCommand(msg)
+		msg.reply <- next.remoteState()
+		return next, cmd
+
+	case clockTickMsg:
+		m.now = time.Time(msg)
+		return m, clockTick()
+
+	case advanceTickMsg:
+		now := time.Time(msg)
+		if !m.lastTick.IsZero() && !m.autoPaused && !m.paused && !m.overview && m.prompt == "" {
+			m.shownFor += now.Sub(m.lastTick)
+		}
+		m.lastTick = now
+		if m.due() {
+			next, cmd := m.autoForward()
+			return next, tea.Batch(cmd, advanceTick())
+		}
+		return m, advanceTick()
+
+	case slideMsg:
+		if msg.idx != m.currentIdx || msg.gen != m.gen[msg.idx] {
+			return m, nil
+		}
+		if _, ok := msg.msg.(tickMsg); ok && (m.paused || m.overview) {
+			m.held = true
+			return m, nil
+		}
+		if v, ok := msg.msg.(pollVoteMsg); ok {
+			m.link.sendVote(msg.idx, v)
+		}
+		return m.deliver(msg.msg)
+
+	case transitionTickMsg:
+		if m.trans == nil || msg.gen != m.transGen {
+			return m, nil
+		}
+		if m.trans.step() {
+			m.trans = nil
+			return m, nil
+		}
+		return m, transitionTick(m.transGen)
+	}
+
+	return m.deliver(msg)
+}
+
+// mirrorVote aplica en la audiencia un voto que recibió el presentador. Las
+// barras crecen si la encuesta está a la vista; si no, se ponen al día.
+func (m model) mirrorVote(msg syncVoteMsg) (model, tea.Cmd) {
+	if msg.idx < 0 || msg.idx >= len(m.slides) {
+		return m, nil
+	}
+	p, ok := m.slides[msg.idx].(*pollSlide)
+	if !ok || msg.option < 0 || msg.option >= len(p.options) {
+		return m, nil
+	}
+	if !p.vote(pollVoteMsg{voter: msg.voter, name: msg.name, option: msg.option, at: time.Now()}) {
+		return m, nil
+	}
+	if msg.idx != m.currentIdx {
+		p.chart.series[0].targets = p.counts()
+		return m, nil
+	}
+	return m, m.wrap(msg.idx, p.grow())
+}
+
+// deliver entrega un mensaje al slide actual
+func (m model) deliver(msg tea.Msg) (model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.slides[m.currentIdx], cmd = m.slides[m.currentIdx].Update(msg)
+	return m, m.wrap(m.currentIdx, cmd)
+}
+
+// wrap etiqueta los mensajes de un comando del slide idx con su generación
+// actual; los de tea.Batch se etiquetan uno por uno
+func (m model) wrap(idx int, cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	gen := m.gen[idx]
+	return func() tea.Msg {
+		switch msg := cmd().(type) {
+		case nil:
+			return nil
+		case tea.BatchMsg:
+			batch := make(tea.BatchMsg, len(msg))
+			for i, c := range msg {
+				batch[i] = m.wrap(idx, c)
+			}
+			return batch
+		default:
+			return slideMsg{idx: idx, gen: gen, msg: msg}
+		}
+	}
+}
+
+// enter hace de idx el slide actual: invalida los mensajes que quedaron de
+// la visita anterior y arranca el slide, desde cero si repite su animación
+func (m model) enter(idx int) (model, tea.Cmd) {
+	m.currentIdx = idx
+	m.gen[idx]++
+	m.held = false
+	m.shownFor = 0
+	if r, ok := m.slides[idx].(resetter); ok && !m.freeze[idx] {
+		r.reset()
+	}
+	init := m.wrap(idx, m.slides[idx].Init())
+	m, fragments := m.showFragments()
+	return m, tea.Batch(init, fragments)
+}
+
+// leave deja el slide idx: sus mensajes pendientes se descartan y se corta
+// lo que tenga en marcha
+func (m model) leave(idx int) {
+	m.gen[idx]++
+	if l, ok := m.slides[idx].(leaver); ok {
+		l.leave()
+	}
+}
+
+// resume entrega el tick que se retuvo durante la pausa
+func (m model) resume() (model, tea.Cmd) {
+	if !m.held || m.paused || m.overview {
+		return m, nil
+	}
+	m.held = false
+	return m.deliver(tickMsg{})
+}
+
+// reset vuelve a empezar la animación del slide actual
+func (m model) reset() (model, tea.Cmd) {
+	r, ok := m.slides[m.currentIdx].(resetter)
+	if !ok {
+		return m, nil
+	}
+	m.leave(m.currentIdx)
+	m.held = false
+	r.reset()
+	return m, m.wrap(m.currentIdx, m.slides[m.currentIdx].Init())
+}
+
+// updatePrompt edita el número de slide de "g" o el texto de "/"; con
+// enter salta al slide o busca
+func (m model) updatePrompt(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+
+	case tea.KeyEsc:
+		m.prompt = ""
+
+	case tea.KeyBackspace:
+		if runes := []rune(m.input); len(runes) > 0 {
+			m.input = string(runes[:len(runes)-1])
+		}
+
+	case tea.KeyRunes, tea.KeySpace:
+		text := string(msg.Runes)
+		if msg.Type == tea.KeySpace {
+			text = " "
+		}
+		if m.prompt == "g" && strings.Trim(text, "0123456789") != "" {
+			return m, nil
+		}
+		m.input += text
+
+	case tea.KeyEnter:
+		prompt := m.prompt
+		m.prompt = ""
+		if prompt == "g" {
+			n, err := strconv.Atoi(m.input)
+			if err != nil || n < 1 || n > len(m.slides) || n-1 == m.currentIdx {
+				return m, nil
+			}
+			return m.navigate(n - 1)
+		}
+
+		m.query = strings.TrimSpace(m.input)
+		m.matches = nil
+		if m.query == "" {
+			return m, nil
+		}
+		m.matches = m.search(m.query)
+		if !slices.Contains(m.matches, m.currentIdx) {
+			return m.nextMatch(1)
+		}
+	}
+	return m, nil
+}
+
+// statusLine es la línea bajo el slide: la navegación, lo que se está
+// escribiendo en "g" o "/", o el resultado de la búsqueda
+func (m model) statusLine() string {
+	switch {
+	case m.prompt == "g":
+		return fmt.Sprintf("Ir al slide: %s▌ (1-%d, enter para ir, esc para cancelar)", m.input, len(m.slides))
+	case m.prompt == "/":
+		return "/" + m.input + "▌"
+	case m.overview:
+		return fmt.Sprintf("Vista general [%d/%d] ←↑↓→ elegir, enter para abrir, esc para volver", m.selected+1, len(m.slides))
+	case m.query != "" && len(m.matches) == 0:
+		return fmt.Sprintf("%s Sin resultados para %q (esc para limpiar)", m.counter(), m.query)
+	case m.query != "":
+		return fmt.Sprintf("%s %q en %d slides: n/N siguiente/anterior, esc para limpiar", m.counter(), m.query, len(m.matches))
+	}
+	if m.blank {
+		return fmt.Sprintf("%s ■ Pantalla en negro: 'b' para volver", m.counter())
+	}
+	if m.paused {
+		return fmt.Sprintf("%s ⏸ En pausa: '.' para seguir, 'R' para empezar de nuevo", m.counter())
+	}
+	if m.remote != nil && !m.paired {
+		return fmt.Sprintf("%s Control remoto en %s, código %s", m.counter(), m.remote.addr, m.remote.token)
+	}
+	if m.auto && m.autoPaused {
+		return fmt.Sprintf("%s ⏸ Avance automático en pausa: 'a' para seguir", m.counter())
+	}
+	if m.auto {
+		return fmt.Sprintf("%s Avance automático: cualquier tecla lo pausa, 'q' para salir", m.counter())
+	}
+	return fmt.Sprintf("%s Use ← → para navegar, 'q' para salir", m.counter())
+}
+
+// progressBar muestra cuánto falta para que el avance automático pase al
+// paso siguiente. Los slides que solo esperan a terminar no tienen barra.
+func (m model) progressBar() string {
+	spec := m.advance[m.currentIdx]
+	if spec.off || spec.after == 0 || m.waitsForEnd() {
+		return ""
+	}
+	width := slideWidth + 2
+	eighths := int(min(1, float64(m.shownFor)/float64(spec.after)) * float64(width*8))
+	bar := strings.Repeat("█", eighths/8)
+	if eighths < width*8 {
+		bar += leftBlocks[eighths%8]
+	}
+	bar += strings.Repeat(" ", width-runewidth.StringWidth(bar))
+
+	if m.autoPaused {
+		return progressPausedStyle.Render(bar)
+	}
+	return progressStyle.Render(bar)
+}
+
+// navigate cambia de slide por una tecla local y avisa a la otra sesión
+func (m model) navigate(idx int) (model, tea.Cmd) {
+	m, cmd := m.goTo(idx)
+	m.link.send(idx, m.fragment[idx])
+	return m, cmd
+}
+
+// forward avanza un paso: el siguiente paso interno del slide, el siguiente
+// fragmento o el siguiente slide. ok es false si ya no hay a dónde avanzar.
+func (m model) forward() (next model, cmd tea.Cmd, ok bool) {
+	if st, ok := m.slides[m.currentIdx].(stepper); ok && st.next() {
+		m.shownFor = 0
+		return m, nil, true
+	}
+	if n := m.fragment[m.currentIdx]; n < m.fragmentCount() {
+		next, cmd = m.step(n + 1)
+		return next, cmd, true
+	}
+	if m.currentIdx < len(m.slides)-1 {
+		next, cmd = m.navigate(m.currentIdx + 1)
+		return next, cmd, true
+	}
+	return m, nil, false
+}
+
+// remoteState es el estado actual para el control remoto
+func (m model) remoteState() remoteState {
+	return remoteState{
+		Slide:     m.currentIdx + 1,
+		Total:     len(m.slides),
+		Fragment:  m.fragment[m.currentIdx],
+		Fragments: m.fragmentCount(),
+		Title:     m.title(m.currentIdx),
+		Notes:     m.notes[m.currentIdx],
+		Blank:     m.blank,
+	}
+}
+
+// title es el título del slide i para el control remoto. Se calcula recién
+// al pedirlo, con los slides ya acomodados a la terminal, y se guarda hasta
+// el próximo layout.
+func (m model) title(i int) string {
+	if i < len(m.titles) && m.titles[i] != "" {
+		return m.titles[i]
+	}
+	title := slideTitle(m.slides[i])
+	if i < len(m.titles) {
+		m.titles[i] = title
+	}
+	return title
+}
+
+// remoteCommand aplica una orden del control remoto como si fuera una
+// tecla: también pausa el avance automático y cierra la vista general
+func (m model) remoteCommand(msg remoteMsg) (model, tea.Cmd) {
+	if msg.command == "state" {
+		return m, nil
+	}
+	m.autoPaused = m.auto
+	m.overview, m.prompt = false, ""
+
+	switch msg.command {
+	case "next":
+		next, cmd, _ := m.forward()
+		return next, cmd
+	case "prev":
+		prev, cmd, _ := m.backward()
+		return prev, cmd
+	case "goto":
+		if msg.slide != m.currentIdx {
+			return m.navigate(msg.slide)
+		}
+	case "blank":
+		return m.setBlank(!m.blank), nil
+	}
+	return m, nil
+}
+
+// backward retrocede un paso: el paso interno anterior del slide, el
+// fragmento anterior o el slide anterior. ok es false si ya se está al
+// principio.
+func (m model) backward() (prev model, cmd tea.Cmd, ok bool) {
+	if st, ok := m.slides[m.currentIdx].(stepper); ok && st.prev() {
+		return m, nil, true
+	}
+	if n := m.fragment[m.currentIdx]; n > 0 {
+		prev, cmd = m.step(n - 1)
+		return prev, cmd, true
+	}
+	if m.currentIdx > 0 {
+		prev, cmd = m.navigate(m.currentIdx - 1)
+		return prev, cmd, true
+	}
+	return m, nil, false
+}
+
+// setBlank deja la pantalla en negro (o la vuelve a mostrar) y avisa a la
+// otra sesión
+func (m model) setBlank(on bool) model {
+	m.blank = on
+	m.link.sendBlank(on)
+	return m
+}
+
+// due indica si el avance automático tiene que pasar al paso siguiente
+func (m model) due() bool {
+	if m.autoPaused || m.paused || m.overview || m.prompt != "" || m.trans != nil {
+		return false
+	}
+	spec := m.advance[m.currentIdx]
+	if spec.off {
+		return false
+	}
+	if m.waitsForEnd() {
+		done, _ := m.slides[m.currentIdx].(completer).complete()
+		return done
+	}
+	return spec.after > 0 && m.shownFor >= spec.after
+}
+
+// waitsForEnd indica si el slide actual pasa cuando termina y no por tiempo
+func (m model) waitsForEnd() bool {
+	c, ok := m.slides[m.currentIdx].(completer)
+	if !ok || !m.advance[m.currentIdx].onEnd {
+		return false
+	}
+	_, ends := c.complete()
+	return ends
+}
+
+// autoForward es el paso del avance automático. En modo loop, después del
+// último slide vuelve al primero con los fragmentos y los pasos de código
+// desde el principio.
+func (m model) autoForward() (model, tea.Cmd) {
+	next, cmd, ok := m.forward()
+	if ok || !m.loop {
+		return next, cmd
+	}
+	m.fragment = map[int]int{}
+	for _, s := range m.slides {
+		if st, ok := s.(stepper); ok {
+			for st.prev() {
+			}
+		}
+	}
+	return m.navigate(0)
+}
+
+// step muestra n fragmentos del slide actual y avisa a la otra sesión
+func (m model) step(n int) (model, tea.Cmd) {
+	m.fragment[m.currentIdx] = n
+	m.shownFor = 0
+	m.link.send(m.currentIdx, n)
+	return m.showFragments()
+}
+
+// showFragments aplica al slide actual los fragmentos que tiene guardados
+func (m model) showFragments() (model, tea.Cmd) {
+	if f, ok := m.slides[m.currentIdx].(fragmenter); ok {
+		return m, m.wrap(m.currentIdx, f.showFragments(m.fragment[m.currentIdx]))
+	}
+	return m, nil
+}
+
+// fragmentCount es la cantidad de fragmentos del slide actual
+func (m model) fragmentCount() int {
+	if f, ok := m.slides[m.currentIdx].(fragmenter); ok {
+		return f.fragments()
+	}
+	return 0
+}
+
+// counter es la posición en el deck y, si el slide tiene fragmentos, en
+// sus fragmentos
+func (m model) counter() string {
+	if n := m.fragmentCount(); n > 0 {
+		return fmt.Sprintf("[%d/%d · %d/%d]", m.currentIdx+1, len(m.slides), m.fragment[m.currentIdx], n)
+	}
+	return fmt.Sprintf("[%d/%d]", m.currentIdx+1, len(m.slides))
+}
+
+// goTo cambia al slide idx, animando la transición que tenga configurada
+func (m model) goTo(idx int) (model, tea.Cmd) {
+	from := m.slideView()
+	m.leave(m.currentIdx)
+	m, init := m.enter(idx)
+
+	spec := m.transitions[idx]
+	if spec.kind == "" || spec.kind == "none" {
+		m.trans = nil
+		return m, init
+	}
+
+	m.trans = newTransition(spec, from)
+	m.transGen++
+	return m, tea.Batch(init, transitionTick(m.transGen))
+}
+
+// slideView devuelve el slide actual, mezclado con el anterior si hay una
+// transición en curso
+func (m model) slideView() string {
+	view := m.slides[m.currentIdx].View()
+	if m.trans != nil {
+		view = m.trans.compose(view)
+	}
+	return view
+}
+
+// layout ajusta los slides al tamaño de la terminal. La vista del
+// presentador reserva lugar para la miniatura y las notas.
+func (m *model) layout() {
+	// Con otro tamaño los títulos pueden cambiar
+	m.titles = make([]string, len(m.slides))
+	if m.projector {
+		return
+	}
+	width, height := m.width-4, m.height-5
+	if m.presenter {
+		// El marco y la miniatura, que mide la mitad, comparten el ancho
+		width = (m.width-len(presenterGap))*2/3 - 2
+		height = min(m.height*3/5, m.height-presenterChrome-minNotesRows)
+	}
+	setSlideSize(max(width, minSlideWidth), max(height, minSlideHeight))
+}
+
+// Lo que la vista del presentador agrega al slide: el espacio hasta la
+// miniatura y, a lo alto, el marco, las etiquetas, el reloj y las líneas
+// en blanco, más al menos unas líneas de notas
+const (
+	presenterGap    = "  "
+	presenterChrome = 6
+	minNotesRows    = 2
+)
+
+// presenterWidth es el ancho del slide con la miniatura del siguiente
+func presenterWidth() int {
+	frame := slideWidth + 2
+	return frame + len(presenterGap) + (frame+1)/2
+}
+
+// tooSmall indica si el slide (con marco y navegación, o con lo que agrega
+// la vista del presentador) no entra en la terminal
+func (m model) tooSmall() bool {
+	width, height := m.minSize()
+	return m.width > 0 && (m.width < width || m.height < height)
+}
+
+// minSize es el tamaño de terminal que necesita la vista actual
+func (m model) minSize() (int, int) {
+	if m.presenter {
+		return presenterWidth(), slideHeight + presenterChrome + minNotesRows
+	}
+	return slideWidth + 2, slideHeight + 4
+}
+
+func (m model) View() string {
+	if m.tooSmall() {
+		width, height := m.minSize()
+		warning := warningStyle.Render("La terminal es muy chica") + "\n" +
+			fmt.Sprintf("%dx%d, se necesitan al menos %dx%d", m.width, m.height, width, height)
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, warning)
+	}
+
+	var view string
+	switch {
+	case m.blank && !m.presenter:
+		// La audiencia no ve nada; el presentador sigue viendo su vista
+		if m.width == 0 {
+			return ""
+		}
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, "")
+	case m.overview:
+		view = highlight(m.overviewView(), m.query) + "\n" + m.statusLine()
+	case m.presenter:
+		view = highlight(m.presenterView(), m.query)
+	default:
+		gap := "\n\n"
+		if m.auto {
+			gap = "\n" + m.progressBar() + "\n"
+		}
+		view = highlight(m.slideView(), m.query) + gap + m.statusLine()
+	}
+
+	if m.width == 0 {
+		return view
+	}
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, view)
+}
+
+// Estilos de la vista del presentador
+var (
+	presenterLabelStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("#7D56F4"))
+
+	notesStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FAFAFA"))
+
+	aheadStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#10F0FF"))
+	onPaceStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#10FF50"))
+	behindStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF1050"))
+)
+
+type clockTickMsg time.Time
+
+type advanceTickMsg time.Time
+
+// advanceTick mide el tiempo del avance automático; va más seguido que
+// clockTick para que la barra de progreso avance suave
+func advanceTick() tea.Cmd {
+	return tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
+		return advanceTickMsg(t)
+	})
+}
+
+func clockTick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return clockTickMsg(t)
+	})
+}
+
+// presenterView muestra el slide actual, una miniatura del siguiente, las
+// notas y el reloj de la charla
+func (m model) presenterView() string {
+	next := presenterLabelStyle.Render("Fin del deck")
+	if m.currentIdx+1 < len(m.slides) {
+		next = presenterLabelStyle.Render("Siguiente") + "\n" +
+			thumbnail(stillView(m.slides[m.currentIdx+1]), 2)
+	}
+	top := lipgloss.JoinHorizontal(lipgloss.Top, m.slideView(), presenterGap, next)
+
+	// Las notas usan el ancho de la terminal y las filas que quedan libres
+	width, rows := presenterWidth(), minNotesRows
+	if m.width > 0 {
+		width = m.width
+		rows = max(minNotesRows, m.height-slideHeight-presenterChrome)
+	}
+	notes := m.notes[m.currentIdx]
+	if notes == "" {
+		notes = "(sin notas)"
+	}
+	_, lines := renderMarkdown(notes, width)
+	if len(lines) > rows {
+		lines = append(lines[:rows-1], "…")
+	}
+
+	elapsed := m.now.Sub(m.started).Truncate(time.Second)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	clock := fmt.Sprintf("⏱ %s transcurrido", formatClock(elapsed))
+	if m.talk > 0 {
+		clock += fmt.Sprintf("  %s restante  %s", formatClock(m.talk-elapsed), m.pace(elapsed))
+	}
+	if m.remote != nil {
+		clock += fmt.Sprintf("  📱 %s código %s", m.remote.addr, m.remote.token)
+	}
+	status := fmt.Sprintf("%s  %s", m.counter(), clock)
+	if m.prompt != "" || m.query != "" || m.paused || m.blank {
+		status = m.statusLine()
+	}
+
+	return top + "\n\n" +
+		presenterLabelStyle.Render("Notas") + "\n" +
+		notesStyle.Width(width).Render(strings.Join(lines, "\n")) + "\n\n" +
+		lipgloss.NewStyle().MaxWidth(width).Render(status)
+}
+
+// pace compara el slide actual con el que correspondería según el tiempo
+// transcurrido de la charla
+func (m model) pace(elapsed time.Duration) string {
+	expected := float64(elapsed) / float64(m.talk) * float64(len(m.slides))
+	switch diff := float64(m.currentIdx+1) - expected; {
+	case diff > 1:
+		return aheadStyle.Render("▲ adelantado")
+	case diff < -1:
+		return behindStyle.Render("▼ atrasado")
+	default:
+		return onPaceStyle.Render("● en ritmo")
+	}
+}
+
+func formatClock(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	return fmt.Sprintf("%s%02d:%02d", sign, int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// thumbnail reduce un slide ya renderizado a una celda por cada bloque de
+// scale×scale, quedándose con la primera celda visible del bloque para que
+// el texto no desaparezca del todo
+func thumbnail(view string, scale int) string {
+	grid := parseCells(view)
+	var out [][]cell
+	for y := 0; y < len(grid); y += scale {
+		var row []cell
+		for x := 0; x < len(grid[y]); x += scale {
+			c := grid[y][x]
+			for dy := 0; dy < scale && y+dy < len(grid); dy++ {
+				for dx := 0; dx < scale && x+dx < len(grid[y+dy]); dx++ {
+					if candidate := grid[y+dy][x+dx]; candidate.width == 1 && c.ch == " " && candidate.ch != " " {
+						c = candidate
+					}
+				}
+			}
+			if c.width != 1 {
+				c = cell{ch: " ", sgr: c.sgr, width: 1}
+			}
+			row = append(row, c)
+		}
+		out = append(out, row)
+	}
+	return renderCells(out)
+}
+
+// stillViewer lo implementan los slides animados: stillView es cómo se ven
+// con la animación terminada y todo el contenido a la vista. Se usa en la
+// vista general y en la búsqueda.
+type stillViewer interface {
+	stillView() string
+}
+
+// stillView muestra el principio de la lista, quieta
+func (c *creditsSlide) stillView() string {
+	var sb strings.Builder
+	for _, line := range c.lines {
+		sb.WriteString(line.render() + "\n")
+	}
+	return renderFrame(sb.String())
+}
+
+func (b *barChartSlide) stillView() string {
+	still := *b
+	still.progress, still.shown = 1, len(b.series)
+	return still.View()
+}
+
+func (p *pollSlide) stillView() string {
+	still := *p
+	still.chart.progress = 1
+	return still.View()
+}
+
+func (l *lineChartSlide) stillView() string {
+	still := *l
+	still.progress, still.shown = 1, len(l.series)
+	return still.View()
+}
+
+func (p *pieSlide) stillView() string {
+	still := *p
+	still.progress = 1
+	return still.View()
+}
+
+func (c *codeSlide) stillView() string {
+	still := *c
+	still.revealed = c.total
+	return still.View()
+}
+
+func (d *diagramSlide) stillView() string {
+	still := *d
+	still.progress, still.shown = 1, len(d.edges)
+	return still.View()
+}
+
+func (s *markdownSlide) stillView() string {
+	still := *s
+	still.shown = s.fragments()
+	return still.View()
+}
+
+// stillView devuelve la versión estática de un slide si la tiene
+func stillView(s slide) string {
+	if sv, ok := s.(stillViewer); ok {
+		return sv.stillView()
+	}
+	return s.View()
+}
+
+// plainText devuelve el texto de un slide ya dibujado, sin estilos ni marco,
+// con los espacios colapsados
+func plainText(view string) string {
+	var sb strings.Builder
+	for _, row := range parseCells(view) {
+		for _, c := range row {
+			if c.width > 0 && !strings.ContainsAny(c.ch, "╭╮╰╯│─") {
+				sb.WriteString(c.ch)
+			}
+		}
+		sb.WriteString(" ")
+	}
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// slideTitle es la primera línea con texto del slide
+func slideTitle(s slide) string {
+	for _, row := range strings.Split(stillView(s), "\n") {
+		if title := plainText(row); title != "" {
+			return title
+		}
+	}
+	return ""
+}
+
+// search devuelve los slides cuyo texto o notas contienen query, sin
+// distinguir mayúsculas
+func (m model) search(query string) []int {
+	query = strings.ToLower(strings.Join(strings.Fields(query), " "))
+	var found []int
+	for i, s := range m.slides {
+		text := plainText(stillView(s)) + " " + strings.Join(strings.Fields(m.notes[i]), " ")
+		if strings.Contains(strings.ToLower(text), query) {
+			found = append(found, i)
+		}
+	}
+	return found
+}
+
+// nextMatch va al siguiente (o anterior) slide con resultados de la búsqueda
+func (m model) nextMatch(dir int) (model, tea.Cmd) {
+	if len(m.matches) == 0 {
+		return m, nil
+	}
+	for i := 1; i <= len(m.slides); i++ {
+		idx := (m.currentIdx + dir*i + len(m.slides)) % len(m.slides)
+		if slices.Contains(m.matches, idx) {
+			if idx == m.currentIdx {
+				return m, nil
+			}
+			return m.navigate(idx)
+		}
+	}
+	return m, nil
+}
+
+// highlight resalta en un texto ya dibujado cada aparición de query, sin
+// distinguir mayúsculas. No encuentra lo que quedó cortado entre dos líneas.
+func highlight(view, query string) string {
+	needle := []rune(strings.ToLower(query))
+	if len(needle) == 0 {
+		return view
+	}
+	sgr := lipgloss.ColorProfile().Color("#FFFF10").Sequence(true)
+	if sgr == "" {
+		sgr = "\x1b[7m"
+	} else {
+		sgr = "\x1b[30;" + sgr + "m"
+	}
+
+	grid := parseCells(view)
+	for _, row := range grid {
+		// Posición de cada rune visible en la fila
+		var text []rune
+		var at []int
+		for i, c := range row {
+			if c.width == 0 {
+				continue
+			}
+			for _, r := range strings.ToLower(c.ch) {
+				text = append(text, r)
+				at = append(at, i)
+			}
+		}
+		for start := 0; start+len(needle) <= len(text); start++ {
+			if string(text[start:start+len(needle)]) != string(needle) {
+				continue
+			}
+			for k := start; k < start+len(needle); k++ {
+				row[at[k]].sgr = sgr
+				if row[at[k]].width == 2 {
+					row[at[k]+1].sgr = sgr
+				}
+			}
+		}
+	}
+	return renderCells(grid)
+}
+
+// Estilos de la vista general
+var (
+	thumbStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#4A4A5A"))
+
+	selectedThumbStyle = thumbStyle.BorderForeground(lipgloss.Color("#7D56F4"))
+	matchThumbStyle    = thumbStyle.BorderForeground(lipgloss.Color("#FFFF10"))
+	thumbLabelStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#FAFAFA"))
+)
+
+// overviewScale es cuánto se reducen los slides en la vista general: al
+// menos a un tercio, y más si hace falta para que entren tres por fila
+func (m model) overviewScale() int {
+	scale := 3
+	for scale < 8 && m.width > 0 && 3*(m.thumbWidth(scale)+1) > m.width-2 {
+		scale++
+	}
+	return scale
+}
+
+// thumbWidth es el ancho de una miniatura con su borde
+func (m model) thumbWidth(scale int) int {
+	return (slideWidth+2+scale-1)/scale + 2
+}
+
+// overviewColumns es cuántas miniaturas entran a lo ancho
+func (m model) overviewColumns() int {
+	return max(1, (m.width-2)/(m.thumbWidth(m.overviewScale())+1))
+}
+
+// updateOverview mueve la selección de la vista general; enter abre el
+// slide elegido
+func (m model) updateOverview(msg tea.KeyMsg) (model, tea.Cmd) {
+	cols := m.overviewColumns()
+	switch msg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+	case "esc", "o":
+		m.overview = false
+		return m.resume()
+	case "left", "h":
+		m.selected = max(0, m.selected-1)
+	case "right", "l":
+		m.selected = min(len(m.slides)-1, m.selected+1)
+	case "up", "k":
+		if m.selected-cols >= 0 {
+			m.selected -= cols
+		}
+	case "down", "j":
+		if m.selected+cols < len(m.slides) {
+			m.selected += cols
+		}
+	case "home":
+		m.selected = 0
+	case "end":
+		m.selected = len(m.slides) - 1
+	case "enter", " ":
+		m.overview = false
+		if m.selected != m.currentIdx {
+			return m.navigate(m.selected)
+		}
+		return m.resume()
+	}
+	return m, nil
+}
+
+// overviewView muestra las miniaturas de todos los slides en una grilla;
+// se desplaza por filas para que la selección quede a la vista
+func (m model) overviewView() string {
+	cols := m.overviewColumns()
+	scale := m.overviewScale()
+	var rows []string
+	var row []string
+	for i, s := range m.slides {
+		style := thumbStyle
+		switch {
+		case i == m.selected:
+			style = selectedThumbStyle
+		case slices.Contains(m.matches, i):
+			style = matchThumbStyle
+		}
+
+		thumb := thumbnail(stillView(s), scale)
+		label := truncate(fmt.Sprintf("%d %s", i+1, slideTitle(s)), lipgloss.Width(thumb))
+		if len(row) > 0 {
+			row = append(row, " ")
+		}
+		row = append(row, style.Render(thumb+"\n"+thumbLabelStyle.Render(label)))
+		if len(row) == 2*cols-1 || i == len(m.slides)-1 {
+			rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, row...))
+			row = nil
+		}
+	}
+
+	visible := len(rows)
+	if m.height > 0 {
+		visible = max(1, (m.height-2)/lipgloss.Height(rows[0]))
+	}
+	first := max(0, min(m.selected/cols-visible+1, len(rows)-visible))
+	return strings.Join(rows[first:min(len(rows), first+visible)], "\n")
+}
+
+// Mensajes de la sincronización entre presentador y audiencia
+type syncMsg struct{ idx, fragment int }
+type syncBlankMsg bool
+type syncJoinMsg struct{}
+type syncPollsMsg string
+
+// syncVoteMsg es un voto de la encuesta del slide idx, reenviado por el
+// presentador
+type syncVoteMsg struct {
+	idx, option int
+	voter, name string
+}
+
+// syncLink une la sesión del presentador con las de la audiencia por un
+// socket Unix. Cada lado envía "goto N F" (slide y fragmentos visibles) al
+// moverse con una tecla, y "blank true|false" al dejar la pantalla en
+// negro, y aplica lo que recibe sin reenviarlo. El presentador además
+// manda "polls URL" con la dirección del servidor de votación y
+// "vote N OPCIÓN VOTANTE NOMBRE" por cada voto que recibe.
+type syncLink struct {
+	mu    sync.Mutex
+	conns []net.Conn
+	ln    net.Listener
+}
+
+// listenSync abre el socket del presentador
+func listenSync(path string) (*syncLink, error) {
+	// Un socket que quedó de una sesión anterior impide escuchar
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return &syncLink{ln: ln}, nil
+}
+
+// dialSync conecta una audiencia con el presentador
+func dialSync(path string) (*syncLink, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return &syncLink{conns: []net.Conn{conn}}, nil
+}
+
+// serve entrega al programa los cambios de slide de la otra sesión
+func (l *syncLink) serve(deliver func(tea.Msg)) {
+	if l.ln == nil {
+		l.read(l.conns[0], deliver)
+		return
+	}
+	for {
+		conn, err := l.ln.Accept()
+		if err != nil {
+			return
+		}
+		l.mu.Lock()
+		l.conns = append(l.conns, conn)
+		l.mu.Unlock()
+		deliver(syncJoinMsg{})
+		go l.read(conn, deliver)
+	}
+}
+
+func (l *syncLink) read(conn net.Conn, deliver func(tea.Msg)) {
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var idx, fragment int
+		var blank bool
+		var url string
+		var vote syncVoteMsg
+		if _, err := fmt.Sscanf(scanner.Text(), "goto %d %d", &idx, &fragment); err == nil {
+			deliver(syncMsg{idx, fragment})
+			// Con varias audiencias, el presentador reenvía a las demás
+			l.sendExcept(conn, scanner.Text())
+		} else if _, err := fmt.Sscanf(scanner.Text(), "blank %t", &blank); err == nil {
+			deliver(syncBlankMsg(blank))
+			l.sendExcept(conn, scanner.Text())
+		} else if _, err := fmt.Sscanf(scanner.Text(), "polls %q", &url); err == nil {
+			deliver(syncPollsMsg(url))
+		} else if _, err := fmt.Sscanf(scanner.Text(), "vote %d %d %q %q", &vote.idx, &vote.option, &vote.voter, &vote.name); err == nil {
+			deliver(vote)
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, c := range l.conns {
+		if c == conn {
+			l.conns = append(l.conns[:i], l.conns[i+1:]...)
+			break
+		}
+	}
+	conn.Close()
+}
+
+func (l *syncLink) send(idx, fragment int) {
+	l.sendExcept(nil, fmt.Sprintf("goto %d %d", idx, fragment))
+}
+
+func (l *syncLink) sendBlank(on bool) {
+	l.sendExcept(nil, fmt.Sprintf("blank %t", on))
+}
+
+func (l *syncLink) sendPolls(url string) {
+	l.sendExcept(nil, fmt.Sprintf("polls %q", url))
+}
+
+func (l *syncLink) sendVote(idx int, v pollVoteMsg) {
+	l.sendExcept(nil, fmt.Sprintf("vote %d %d %q %q", idx, v.option, v.voter, v.name))
+}
+
+func (l *syncLink) sendExcept(skip net.Conn, line string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, c := range l.conns {
+		if c != skip {
+			fmt.Fprintln(c, line)
+		}
+	}
+}
+
+func (l *syncLink) close() {
+	if l.ln != nil {
+		l.ln.Close()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, c := range l.conns {
+		c.Close()
+	}
+}
+
+// pollQuestion es lo que el servidor de votación necesita de una encuesta
+type pollQuestion struct {
+	question string
+	options  []string
+	named    bool
+}
+
+// option busca una opción por número (desde 1) o por texto; -1 si no existe
+func (q pollQuestion) option(value string) int {
+	value = strings.TrimSpace(value)
+	if n, err := strconv.Atoi(value); err == nil {
+		if n >= 1 && n <= len(q.options) {
+			return n - 1
+		}
+		return -1
+	}
+	for i, option := range q.options {
+		if strings.EqualFold(option, value) {
+			return i
+		}
+	}
+	return -1
+}
+
+// pollVoteMsg es un voto para la encuesta session. voter identifica al
+// votante: su nombre en las encuestas con nombre, y si no la cookie del
+// navegador o la dirección desde la que vota.
+type pollVoteMsg struct {
+	session int
+	voter   string
+	name    string
+	option  int
+	at      time.Time
+}
+
+// pollServer recibe por HTTP los votos del público. Lo arranca el
+// presentador si el deck tiene encuestas; cada encuesta se abre al entrar a
+// su slide y se cierra al salir, y los votos llegan al slide por un canal.
+//
+//	GET  /       la pregunta abierta: una página para el navegador o texto
+//	             para la terminal
+//	GET  /poll   la pregunta abierta en JSON
+//	POST /vote   opcion (número o texto), nombre y encuesta (opcionales)
+type pollServer struct {
+	url string
+	ln  net.Listener
+
+	mu       sync.Mutex
+	session  int // se incrementa con cada encuesta que se abre
+	question *pollQuestion
+	closed   bool
+	votes    chan pollVoteMsg
+	stop     chan struct{}
+}
+
+// listenPolls arranca el servidor de votación en addr
+func listenPolls(addr string) (*pollServer, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &pollServer{ln: ln, url: "http://" + publicAddr(ln.Addr().(*net.TCPAddr))}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.page)
+	mux.HandleFunc("GET /poll", s.state)
+	mux.HandleFunc("POST /vote", s.vote)
+	go http.Serve(ln, mux)
+	return s, nil
+}
+
+// publicAddr es la dirección con la que el público llega al servidor: si
+// escucha en todas las interfaces, la primera IPv4 que no es de loopback
+func publicAddr(addr *net.TCPAddr) string {
+	host := addr.IP.String()
+	if addr.IP.IsUnspecified() {
+		host = "localhost"
+		if addrs, err := net.InterfaceAddrs(); err == nil {
+			for _, a := range addrs {
+				if ip, ok := a.(*net.IPNet); ok && !ip.IP.IsLoopback() && ip.IP.To4() != nil {
+					host = ip.IP.String()
+					break
+				}
+			}
+		}
+	}
+	return net.JoinHostPort(host, strconv.Itoa(addr.Port))
+}
+
+// open abre una encuesta y devuelve su número y el comando que espera el
+// próximo voto
+func (s *pollServer) open(q pollQuestion) (int, tea.Cmd) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+	}
+	s.session++
+	s.question, s.closed = &q, false
+	votes, stop := make(chan pollVoteMsg, 64), make(chan struct{})
+	s.votes, s.stop = votes, stop
+
+	return s.session, func() tea.Msg {
+		select {
+		case v := <-votes:
+			return v
+		case <-stop:
+			return nil
+		}
+	}
+}
+
+// close cierra la encuesta session si sigue abierta
+func (s *pollServer) close(session int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if session != s.session || s.stop == nil {
+		return
+	}
+	close(s.stop)
+	s.question, s.votes, s.stop = nil, nil, nil
+}
+
+// setClosed deja de aceptar votos (o los vuelve a aceptar) sin sacar la
+// pregunta
+func (s *pollServer) setClosed(session int, closed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if session == s.session {
+		s.closed = closed
+	}
+}
+
+func (s *pollServer) shutdown() {
+	s.ln.Close()
+}
+
+// current devuelve la encuesta abierta, o nil
+func (s *pollServer) current() (session int, q *pollQuestion, closed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.session, s.question, s.closed
+}
+
+// wantsHTML distingue un navegador de curl y compañía
+func wantsHTML(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+func (s *pollServer) state(w http.ResponseWriter, r *http.Request) {
+	session, q, closed := s.current()
+	state := struct {
+		Session  int      `json:"session"`
+		Question string   `json:"question,omitempty"`
+		Options  []string `json:"options,omitempty"`
+		Named    bool     `json:"named,omitempty"`
+		Open     bool     `json:"open"`
+	}{Session: session}
+	if q != nil {
+		state.Question, state.Options, state.Named, state.Open = q.question, q.options, q.named, !closed
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state)
+}
+
+func (s *pollServer) page(w http.ResponseWriter, r *http.Request) {
+	session, q, closed := s.current()
+	if !wantsHTML(r) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if q == nil {
+			fmt.Fprintln(w, "No hay ninguna pregunta abierta.")
+			return
+		}
+		fmt.Fprintln(w, q.question)
+		for i, option := range q.options {
+			fmt.Fprintf(w, "  %d. %s\n", i+1, option)
+		}
+		if closed {
+			fmt.Fprintln(w, "La votación está cerrada.")
+			return
+		}
+		name := ""
+		if q.named {
+			name = " -d nombre=TuNombre"
+		}
+		fmt.Fprintf(w, "\nPara votar: curl -d opcion=N%s %s/vote\n", name, s.url)
+		return
+	}
+
+	if _, err := r.Cookie("votante"); err != nil {
+		http.SetCookie(w, &http.Cookie{Name: "votante", Value: fmt.Sprintf("%016x", rand.Uint64()), Path: "/"})
+	}
+	var body strings.Builder
+	switch {
+	case q == nil:
+		body.WriteString("<p class=\"wait\">Esperando la próxima pregunta…</p>")
+	default:
+		fmt.Fprintf(&body, "<h1>%s</h1>\n", html.EscapeString(q.question))
+		if closed {
+			body.WriteString("<p class=\"wait\">La votación está cerrada.</p>")
+			break
+		}
+		fmt.Fprintf(&body, "<form method=\"post\" action=\"/vote\">\n<input type=\"hidden\" name=\"encuesta\" value=\"%d\">\n", session)
+		if q.named {
+			body.WriteString("<input name=\"nombre\" placeholder=\"Tu nombre\" maxlength=\"40\" required autocomplete=\"name\">\n")
+		}
+		for i, option := range q.options {
+			fmt.Fprintf(&body, "<button name=\"opcion\" value=\"%d\">%s</button>\n", i+1, html.EscapeString(option))
+		}
+		body.WriteString("</form>")
+	}
+	writePollPage(w, body.String(), session, q != nil && !closed)
+}
+
+func (s *pollServer) vote(w http.ResponseWriter, r *http.Request) {
+	reply := func(status int, text string) {
+		if !wantsHTML(r) {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.WriteHeader(status)
+			fmt.Fprintln(w, text)
+			return
+		}
+		w.WriteHeader(status)
+		session, q, closed := s.current()
+		writePollPage(w, "<p class=\"wait\">"+html.EscapeString(text)+"</p>\n<p><a href=\"/\">Volver</a></p>", session, q != nil && !closed)
+	}
+
+	s.mu.Lock()
+	session, q, closed, votes := s.session, s.question, s.closed, s.votes
+	s.mu.Unlock()
+	switch {
+	case q == nil:
+		reply(http.StatusConflict, "No hay ninguna pregunta abierta.")
+		return
+	case closed:
+		reply(http.StatusConflict, "La votación está cerrada.")
+		return
+	case r.FormValue("encuesta") != "" && r.FormValue("encuesta") != strconv.Itoa(session):
+		reply(http.StatusConflict, "Esa pregunta ya cerró; recargá la página para ver la nueva.")
+		return
+	}
+
+	option := q.option(r.FormValue("opcion"))
+	if option < 0 {
+		reply(http.StatusBadRequest, fmt.Sprintf("Opción inválida %q: se espera un número del 1 al %d o el texto de la opción.", r.FormValue("opcion"), len(q.options)))
+		return
+	}
+	v := pollVoteMsg{session: session, option: option, at: time.Now()}
+	if q.named {
+		v.name = truncate(strings.TrimSpace(r.FormValue("nombre")), 40)
+		if v.name == "" {
+			reply(http.StatusBadRequest, "Falta tu nombre: esta pregunta no es anónima.")
+			return
+		}
+		v.voter = "nombre:" + strings.ToLower(v.name)
+	} else if c, err := r.Cookie("votante"); err == nil {
+		v.voter = "cookie:" + c.Value
+	} else {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		v.voter = "ip:" + host
+	}
+
+	select {
+	case votes <- v:
+		reply(http.StatusOK, fmt.Sprintf("¡Gracias! Votaste: %s.", q.options[option]))
+	default:
+		reply(http.StatusServiceUnavailable, "Llegan demasiados votos juntos; probá de nuevo en un momento.")
+	}
+}
+
+// writePollPage escribe la página de votación. Mientras la pregunta
+// esté abierta, la página consulta /poll y se recarga cuando cambia.
+func writePollPage(w http.ResponseWriter, body string, session int, open bool) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, pollPage, body, session, open)
+}
+
+const pollPage = `<!DOCTYPE html>
+<html lang="es">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Votación</title>
+<style>
+body { margin: 0; padding: 2em 1em; background: #1a1a2e; color: #fafafa; font: 18px/1.4 system-ui, sans-serif; }
+main { max-width: 30em; margin: 0 auto; }
+h1 { color: #ff10f0; font-size: 1.4em; }
+input, button { display: block; width: 100%%; box-sizing: border-box; margin: .5em 0; padding: .8em; font: inherit; border-radius: .4em; border: 1px solid #7d56f4; }
+input { background: #2a2a3a; color: inherit; }
+button { background: #7d56f4; color: #fafafa; cursor: pointer; text-align: left; }
+button:hover, button:focus { background: #ff10f0; }
+.wait { color: #a0a0b0; }
+a { color: #10f0ff; }
+</style>
+</head>
+<body>
+<main>
+%s
+</main>
+<script>
+const session = %d, open = %t;
+setInterval(async () => {
+  try {
+    const state = await (await fetch("/poll")).json();
+    if (state.session !== session || state.open !== open) location.replace("/");
+  } catch (e) {}
+}, 2000);
+</script>
+</body>
+</html>
+`
+
+// remoteMsg es una orden del control remoto; el modelo responde por reply
+// con el estado que queda
+type remoteMsg struct {
+	command string // next, prev, goto, blank o state
+	slide   int
+	reply   chan remoteState
+}
+
+// remoteState es lo que el control remoto sabe de la presentación
+type remoteState struct {
+	Slide     int    `json:"slide"` // desde 1
+	Total     int    `json:"total"`
+	Fragment  int    `json:"fragment"`
+	Fragments int    `json:"fragments"`
+	Title     string `json:"title"`
+	Notes     string `json:"notes,omitempty"`
+	Blank     bool   `json:"blank"`
+}
+
+// remoteServer deja manejar la presentación desde un teléfono en la misma
+// red o desde un script que traduzca las teclas de un puntero. Todas las
+// rutas de /api piden el código de emparejamiento, en "Authorization:
+// Bearer CÓDIGO" o en ?token=CÓDIGO:
+//
+//	GET  /                 la página del control remoto
+//	GET  /api/state        el estado en JSON (ver remoteState)
+//	POST /api/next         avanza, como →
+//	POST /api/prev         retrocede, como ←
+//	POST /api/goto/N       va al slide N
+//	POST /api/blank        pone o saca la pantalla en negro
+//	GET  /api/ws           WebSocket: recibe las mismas órdenes en texto
+//	                       ("next", "goto 3") y manda el estado cada vez
+//	                       que cambia
+//
+// Por ejemplo: curl -X POST -H "Authorization: Bearer 123456" host:8078/api/next
+type remoteServer struct {
+	addr    string
+	token   string
+	total   int // cantidad de slides, para validar goto
+	deliver func(tea.Msg)
+	ln      net.Listener
+
+	// Los intentos con un código equivocado esperan de a uno
+	failures sync.Mutex
+
+	mu      sync.Mutex
+	clients map[*remoteClient]bool
+	last    remoteState
+}
+
+// listenRemote abre el control remoto en addr para un deck de total
+// slides. Los títulos no se piden acá: los calcula el modelo en cada
+// estado (ver model.title), así nunca se dibuja un slide desde otra
+// goroutine ni antes de acomodarlo a la terminal.
+func listenRemote(addr, token string, total int) (*remoteServer, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		token = pairingToken()
+	}
+	return &remoteServer{
+		addr:    publicAddr(ln.Addr().(*net.TCPAddr)),
+		token:   token,
+		total:   total,
+		ln:      ln,
+		clients: map[*remoteClient]bool{},
+	}, nil
+}
+
+// pairingToken es un código de seis cifras al azar
+func pairingToken() string {
+	var b [4]byte
+	crand.Read(b[:])
+	return fmt.Sprintf("%06d", binary.BigEndian.Uint32(b[:])%1000000)
+}
+
+// serve atiende el control remoto y le entrega las órdenes al programa
+func (s *remoteServer) serve(deliver func(tea.Msg)) {
+	s.deliver = deliver
+	http.Serve(s.ln, s.handler())
+}
+
+func (s *remoteServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.page)
+	mux.HandleFunc("GET /api/state", s.handle)
+	mux.HandleFunc("POST /api/{command}", s.handle)
+	mux.HandleFunc("POST /api/goto/{slide}", s.handle)
+	mux.HandleFunc("GET /api/ws", s.socket)
+	return mux
+}
+
+func (s *remoteServer) close() {
+	s.ln.Close()
+}
+
+// authorized controla el código de emparejamiento. Los intentos fallidos
+// tardan un segundo y van de a uno, así no se pueden probar todos los
+// códigos.
+func (s *remoteServer) authorized(r *http.Request) bool {
+	token := r.URL.Query().Get("token")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = bearer
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1 {
+		return true
+	}
+	s.failures.Lock()
+	time.Sleep(time.Second)
+	s.failures.Unlock()
+	return false
+}
+
+// parse lee una orden en texto: next, prev, blank, state o "goto N"
+func (s *remoteServer) parse(text string) (remoteMsg, error) {
+	command, arg, _ := strings.Cut(strings.TrimSpace(text), " ")
+	switch command {
+	case "next", "prev", "blank", "state":
+		return remoteMsg{command: command}, nil
+	case "goto":
+		n, err := strconv.Atoi(strings.TrimSpace(arg))
+		if err != nil || n < 1 || n > s.total {
+			return remoteMsg{}, fmt.Errorf("slide inválido %q (se espera un número del 1 al %d)", arg, s.total)
+		}
+		return remoteMsg{command: command, slide: n - 1}, nil
+	}
+	return remoteMsg{}, fmt.Errorf("orden desconocida %q (se espera next, prev, goto N, blank o state)", command)
+}
+
+// run le pasa una orden al programa y espera el estado que queda
+func (s *remoteServer) run(msg remoteMsg) (remoteState, error) {
+	msg.reply = make(chan remoteState, 1)
+	s.deliver(msg)
+	select {
+	case state := <-msg.reply:
+		return state, nil
+	case <-time.After(2 * time.Second):
+		return remoteState{}, fmt.Errorf("la presentación no responde")
+	}
+}
+
+// publish manda el estado a los WebSockets conectados si cambió
+func (s *remoteServer) publish(state remoteState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if state == s.last {
+		return
+	}
+	s.last = state
+	for c := range s.clients {
+		c.push(state)
+	}
+}
+
+func (s *remoteServer) handle(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		remoteError(w, http.StatusUnauthorized, "código incorrecto")
+		return
+	}
+	text := "state"
+	if slide := r.PathValue("slide"); slide != "" {
+		text = "goto " + slide
+	} else if command := r.PathValue("command"); command != "" {
+		text = command
+	}
+	msg, err := s.parse(text)
+	if err == nil && msg.command == "state" && r.Method != http.MethodGet {
+		err = fmt.Errorf("el estado se pide con GET")
+	}
+	if err != nil {
+		remoteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	state, err := s.run(msg)
+	if err != nil {
+		remoteError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state)
+}
+
+func remoteError(w http.ResponseWriter, status int, text string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": text})
+}
+
+// remoteClient es un WebSocket conectado. El estado se le manda desde otra
+// goroutine; si todavía no se mandó el anterior, se reemplaza por el nuevo.
+type remoteClient struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+	pending chan remoteState
+	sent    remoteState
+}
+
+func (c *remoteClient) push(state remoteState) {
+	for {
+		select {
+		case c.pending <- state:
+			return
+		default:
+		}
+		select {
+		case <-c.pending:
+		default:
+		}
+	}
+}
+
+func (c *remoteClient) write(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return writeFrame(c.conn, opcode, payload)
+}
+
+func (s *remoteServer) socket(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		remoteError(w, http.StatusUnauthorized, "código incorrecto")
+		return
+	}
+	conn, buf, err := acceptWebSocket(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	c := &remoteClient{conn: conn, pending: make(chan remoteState, 1)}
+	s.mu.Lock()
+	s.clients[c] = true
+	s.mu.Unlock()
+	done := make(chan struct{})
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, c)
+		s.mu.Unlock()
+		close(done)
+	}()
+
+	go func() {
+		for {
+			select {
+			case state := <-c.pending:
+				if state == c.sent {
+					continue
+				}
+				c.sent = state
+				data, _ := json.Marshal(state)
+				if c.write(wsText, data) != nil {
+					conn.Close()
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	if state, err := s.run(remoteMsg{command: "state"}); err == nil {
+		c.push(state)
+	}
+	for {
+		opcode, payload, err := readFrame(buf.Reader)
+		if err != nil {
+			return
+		}
+		switch opcode {
+		case wsClose:
+			c.write(wsClose, nil)
+			return
+		case wsPing:
+			c.write(wsPong, payload)
+		case wsText:
+			msg, err := s.parse(string(payload))
+			if err == nil {
+				var state remoteState
+				if state, err = s.run(msg); err == nil {
+					c.push(state)
+				}
+			}
+			if err != nil {
+				data, _ := json.Marshal(map[string]string{"error": err.Error()})
+				c.write(wsText, data)
+			}
+		}
+	}
+}
+
+// Lo mínimo de WebSocket (RFC 6455) para el control remoto: mensajes de
+// texto cortos, en un solo frame
+const (
+	wsText  = 0x1
+	wsClose = 0x8
+	wsPing  = 0x9
+	wsPong  = 0xA
+
+	wsMaxPayload = 4096
+)
+
+// acceptWebSocket responde el handshake y se queda con la conexión
+func acceptWebSocket(w http.ResponseWriter, r *http.Request) (net.Conn, *bufio.ReadWriter, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(w, "se espera un WebSocket", http.StatusBadRequest)
+		return nil, nil, fmt.Errorf("no es un WebSocket")
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "no se puede abrir un WebSocket", http.StatusInternalServerError)
+		return nil, nil, fmt.Errorf("la conexión no se puede tomar")
+	}
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sum := sha1.Sum([]byte(key + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+	fmt.Fprintf(buf, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+		base64.StdEncoding.EncodeToString(sum[:]))
+	if err := buf.Flush(); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, buf, nil
+}
+
+// readFrame lee un frame del cliente, que siempre viene enmascarado
+func readFrame(r io.Reader) (opcode byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode = head[0] & 0x0F
+	if head[1]&0x80 == 0 {
+		return 0, nil, fmt.Errorf("frame sin máscara")
+	}
+
+	size := uint64(head[1] & 0x7F)
+	switch size {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		size = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		size = binary.BigEndian.Uint64(ext[:])
+	}
+	if size > wsMaxPayload {
+		return 0, nil, fmt.Errorf("frame demasiado grande (%d bytes)", size)
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(r, mask[:]); err != nil {
+		return 0, nil, err
+	}
+	payload = make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return opcode, payload, nil
+}
+
+// writeFrame escribe un frame completo del servidor, sin máscara
+func writeFrame(w io.Writer, opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	_, err := w.Write(append(frame, payload...))
+	return err
+}
+
+func (s *remoteServer) page(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	io.WriteString(w, remotePage)
+}
+
+// remotePage es el control remoto para el teléfono. Pide el código (o lo
+// toma de ?token=), lo recuerda y se conecta por WebSocket; también sirven
+// ← → y b del teclado.
+const remotePage = `<!DOCTYPE html>
+<html lang="es">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Control remoto</title>
+<style>
+body { margin: 0; padding: 1em; background: #1a1a2e; color: #fafafa; font: 18px/1.4 system-ui, sans-serif; }
+main { max-width: 30em; margin: 0 auto; }
+h1 { color: #ff10f0; font-size: 1.3em; margin: .2em 0 .6em; }
+input, button { font: inherit; border-radius: .4em; border: 1px solid #7d56f4; padding: .7em; }
+input { background: #2a2a3a; color: inherit; min-width: 0; }
+button { background: #7d56f4; color: #fafafa; cursor: pointer; }
+button:active { background: #ff10f0; }
+.counter, .hint { color: #a0a0b0; margin: 0; }
+.error { color: #ff1050; }
+.nav { display: grid; grid-template-columns: 1fr 2fr; gap: .6em; margin: 1em 0; }
+.nav button { font-size: 2.5em; padding: .6em 0; }
+.row { display: flex; gap: .6em; }
+.row form { display: flex; gap: .6em; flex: 1; }
+.row input { flex: 1; }
+#blank.on { background: #fafafa; color: #1a1a2e; }
+#pair input { display: block; width: 100%; box-sizing: border-box; margin: .6em 0; font-size: 1.6em; letter-spacing: .3em; text-align: center; }
+#pair button { width: 100%; }
+pre { white-space: pre-wrap; font: inherit; color: #d0d0e0; border-top: 1px solid #4a4a5a; padding-top: .6em; }
+</style>
+</head>
+<body>
+<main>
+<form id="pair" hidden>
+<h1>Control remoto</h1>
+<p class="hint">Escribí el código que muestra la presentación.</p>
+<input id="token" inputmode="numeric" autocomplete="one-time-code" placeholder="000000">
+<button>Conectar</button>
+<p id="error" class="error"></p>
+</form>
+<section id="remote" hidden>
+<p id="counter" class="counter"></p>
+<h1 id="title"></h1>
+<div class="nav"><button id="prev" aria-label="Anterior">◀</button><button id="next" aria-label="Siguiente">▶</button></div>
+<div class="row">
+<button id="blank">Negro</button>
+<form id="goto"><input id="slide" type="number" min="1" placeholder="Slide"><button>Ir</button></form>
+</div>
+<p id="status" class="error"></p>
+<pre id="notes"></pre>
+</section>
+</main>
+<script>
+const $ = id => document.getElementById(id);
+let token = new URLSearchParams(location.search).get("token") || localStorage.getItem("slides-token") || "";
+let socket = null;
+
+function showPairing(error) {
+  socket = null;
+  $("remote").hidden = true;
+  $("pair").hidden = false;
+  $("error").textContent = error || "";
+  $("token").value = token;
+  $("token").focus();
+}
+
+async function connect() {
+  let response;
+  try {
+    response = await fetch("/api/state", { headers: { Authorization: "Bearer " + token } });
+  } catch (e) {
+    $("status").textContent = "Sin conexión con la presentación; reintentando…";
+    setTimeout(connect, 2000);
+    return;
+  }
+  if (response.status === 401) {
+    localStorage.removeItem("slides-token");
+    showPairing(token ? "Código incorrecto" : "");
+    return;
+  }
+  localStorage.setItem("slides-token", token);
+  show(await response.json());
+
+  const scheme = location.protocol === "https:" ? "wss://" : "ws://";
+  socket = new WebSocket(scheme + location.host + "/api/ws?token=" + encodeURIComponent(token));
+  socket.onopen = () => { $("status").textContent = ""; };
+  socket.onmessage = event => {
+    const msg = JSON.parse(event.data);
+    if (msg.error) $("status").textContent = msg.error;
+    else show(msg);
+  };
+  socket.onclose = () => {
+    socket = null;
+    $("status").textContent = "Se cortó la conexión; reintentando…";
+    setTimeout(connect, 2000);
+  };
+}
+
+function show(state) {
+  $("pair").hidden = true;
+  $("remote").hidden = false;
+  $("counter").textContent = "Slide " + state.slide + " de " + state.total +
+    (state.fragments ? " · " + state.fragment + "/" + state.fragments : "");
+  $("title").textContent = state.title || "Slide " + state.slide;
+  $("notes").textContent = state.notes || "";
+  $("blank").classList.toggle("on", state.blank);
+  $("slide").max = state.total;
+}
+
+function send(command) {
+  if (socket && socket.readyState === WebSocket.OPEN) socket.send(command);
+}
+
+$("pair").onsubmit = event => {
+  event.preventDefault();
+  token = $("token").value.trim();
+  connect();
+};
+$("next").onclick = () => send("next");
+$("prev").onclick = () => send("prev");
+$("blank").onclick = () => send("blank");
+$("goto").onsubmit = event => {
+  event.preventDefault();
+  if ($("slide").value) send("goto " + $("slide").value);
+  $("slide").value = "";
+  $("slide").blur();
+};
+document.addEventListener("keydown", event => {
+  if (event.target.tagName === "INPUT") return;
+  const command = { ArrowRight: "next", PageDown: "next", " ": "next", ArrowLeft: "prev", PageUp: "prev", b: "blank" }[event.key];
+  if (command) {
+    event.preventDefault();
+    send(command);
+  }
+});
+
+if (token) connect();
+else showPairing();
+</script>
+</body>
+</html>
+`
+
+// Exportación de decks. Los slides se dibujan igual que en la terminal, en
+// colores de 24 bits y con un tamaño fijo, y cada paso (fragmento o paso de
+// código) se guarda con los cuadros de su animación ya calculados.
+
+// exportedSlide es un slide listo para exportar
+type exportedSlide struct {
+	title string
+	notes string
+	steps []exportedStep
+}
+
+// exportedStep son los cuadros de un paso; loop indica que la animación no
+// termina (partículas, degradé) y se repite
+type exportedStep struct {
+	frames []exportedFrame
+	loop   bool
+}
+
+type exportedFrame struct {
+	view string
+	ms   int
+}
+
+// Tope de cuadros por paso: las animaciones que no terminan se cortan
+// enseguida y se repiten; las que terminan (ver completer) se graban
+// enteras mientras no pasen el segundo tope
+const (
+	maxExportFrames       = 120
+	maxFiniteExportFrames = 1200
+)
+
+// animationInterval es cada cuánto avanza la animación de un slide
+func animationInterval(s slide) time.Duration {
+	switch s.(type) {
+	case *creditsSlide:
+		return creditsFrame
+	case *particleSlide:
+		return 50 * time.Millisecond
+	case *codeSlide:
+		return 30 * time.Millisecond
+	default:
+		return 100 * time.Millisecond
+	}
+}
+
+// renderDeck dibuja todos los slides de un deck con sus pasos. Los slides de
+// comando solo se ejecutan si runCommands lo pide; si no, muestran el
+// comando sin su salida.
+func renderDeck(d deck, runCommands bool) []exportedSlide {
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	lipgloss.SetHasDarkBackground(true)
+
+	var out []exportedSlide
+	for i, s := range d.slides {
+		if r, ok := s.(resetter); ok {
+			r.reset()
+		}
+		if c, ok := s.(*commandSlide); ok {
+			if runCommands {
+				c.runToEnd()
+			} else {
+				c.skipRun()
+			}
+		}
+
+		exported := exportedSlide{title: slideTitle(s), notes: d.notes[i]}
+		init := s.Init()
+		if f, ok := s.(fragmenter); ok {
+			for n := 0; n <= f.fragments(); n++ {
+				if cmd := f.showFragments(n); cmd != nil {
+					init = cmd
+				}
+				exported.steps = append(exported.steps, recordFrames(s, init))
+				init = nil
 			}
+		} else {
+			exported.steps = append(exported.steps, recordFrames(s, init))
+		}
 
-		case "left", "h", "p":
-			if m.currentIdx > 0 {
-				m.currentIdx--
-				return m, m.slides[m.currentIdx].Init()
+		if st, ok := s.(stepper); ok {
+			for st.next() {
+				exported.steps = append(exported.steps, recordFrames(s, nil))
 			}
 		}
+		out = append(out, exported)
 	}
+	return out
+}
 
-	var cmd tea.Cmd
-	m.slides[m.currentIdx], cmd = m.slides[m.currentIdx].Update(msg)
-	return m, cmd
+// recordFrames guarda la vista del slide y, si start arranca una animación,
+// un cuadro por tick hasta que termina. Los cuadros iguales seguidos se
+// juntan en uno más largo.
+func recordFrames(s slide, start tea.Cmd) exportedStep {
+	interval := int(animationInterval(s) / time.Millisecond)
+	limit := maxExportFrames
+	if c, ok := s.(completer); ok {
+		if _, ends := c.complete(); ends {
+			limit = maxFiniteExportFrames
+		}
+	}
+	step := exportedStep{frames: []exportedFrame{{view: s.View(), ms: interval}}}
+	for cmd := start; cmd != nil; {
+		if len(step.frames) >= limit {
+			step.loop = true
+			break
+		}
+		s, cmd = s.Update(tickMsg{})
+		view := s.View()
+		if last := &step.frames[len(step.frames)-1]; last.view == view {
+			last.ms += interval
+		} else {
+			step.frames = append(step.frames, exportedFrame{view: view, ms: interval})
+		}
+	}
+	return step
 }
 
-func (m model) View() string {
-	slideView := m.slides[m.currentIdx].View()
+// runToEnd ejecuta el comando y espera su salida completa, para exportarla
+func (c *commandSlide) runToEnd() {
+	for cmd := c.start(); cmd != nil; {
+		msg := cmd()
+		if msg == nil {
+			break
+		}
+		_, cmd = c.Update(msg)
+	}
+}
+
+// skipRun deja el slide como si ya hubiera corrido, con un aviso en lugar
+// de la salida, para que Init no lance el comando
+func (c *commandSlide) skipRun() {
+	c.reset()
+	c.started = time.Now()
+	c.appendLine(dimCodeStyle.Render("(sin ejecutar, ver --run-commands)"))
+}
+
+// Colores de la página; se usan también para el video inverso
+var (
+	pageBackground = [3]int{0x10, 0x10, 0x18}
+	pageForeground = [3]int{0xE0, 0xE0, 0xE0}
+)
+
+// cellStyle es el estilo de una celda ya resuelto: el video inverso se
+// aplica intercambiando los colores, con los de la página por defecto
+type cellStyle struct {
+	fg, bg                                 [3]int
+	hasFg, hasBg                           bool
+	bold, faint, italic, underline, strike bool
+}
+
+// parseSGR interpreta las secuencias SGR acumuladas de una celda
+func parseSGR(sgr string) cellStyle {
+	var st cellStyle
+	reverse := false
+	for _, seq := range strings.Split(sgr, "\x1b[") {
+		seq = strings.TrimSuffix(seq, "m")
+		if seq == "" {
+			continue
+		}
+		params := strings.Split(seq, ";")
+		for i := 0; i < len(params); i++ {
+			n, _ := strconv.Atoi(params[i])
+			switch {
+			case n == 0:
+				st, reverse = cellStyle{}, false
+			case n == 1:
+				st.bold = true
+			case n == 2:
+				st.faint = true
+			case n == 3:
+				st.italic = true
+			case n == 4:
+				st.underline = true
+			case n == 7:
+				reverse = true
+			case n == 9:
+				st.strike = true
+			case n == 22:
+				st.bold, st.faint = false, false
+			case n == 23:
+				st.italic = false
+			case n == 24:
+				st.underline = false
+			case n == 27:
+				reverse = false
+			case n == 29:
+				st.strike = false
+			case n >= 30 && n <= 37:
+				st.fg, st.hasFg = color.Xterm256(n-30), true
+			case n >= 90 && n <= 97:
+				st.fg, st.hasFg = color.Xterm256(n-90+8), true
+			case n >= 40 && n <= 47:
+				st.bg, st.hasBg = color.Xterm256(n-40), true
+			case n >= 100 && n <= 107:
+				st.bg, st.hasBg = color.Xterm256(n-100+8), true
+			case n == 39:
+				st.hasFg = false
+			case n == 49:
+				st.hasBg = false
+			case n == 38 || n == 48:
+				var rgb [3]int
+				if i+2 < len(params) && params[i+1] == "5" {
+					idx, _ := strconv.Atoi(params[i+2])
+					rgb = color.Xterm256(idx)
+					i += 2
+				} else if i+4 < len(params) && params[i+1] == "2" {
+					r, _ := strconv.Atoi(params[i+2])
+					g, _ := strconv.Atoi(params[i+3])
+					b, _ := strconv.Atoi(params[i+4])
+					rgb = [3]int{r, g, b}
+					i += 4
+				} else {
+					continue
+				}
+				if n == 38 {
+					st.fg, st.hasFg = rgb, true
+				} else {
+					st.bg, st.hasBg = rgb, true
+				}
+			}
+		}
+	}
+
+	if reverse {
+		if !st.hasFg {
+			st.fg = pageForeground
+		}
+		if !st.hasBg {
+			st.bg = pageBackground
+		}
+		st.fg, st.bg = st.bg, st.fg
+		st.hasFg, st.hasBg = true, true
+	}
+	return st
+}
+
+// cellsToHTML convierte una vista con secuencias SGR en HTML: una línea por
+// fila, con un span por tramo de mismo estilo
+func cellsToHTML(view string) string {
+	var sb strings.Builder
+	for y, row := range parseCells(view) {
+		if y > 0 {
+			sb.WriteString("\n")
+		}
+		current, open := "", false
+		for _, c := range row {
+			if c.width == 0 {
+				continue
+			}
+			if c.sgr != current || !open {
+				if open {
+					sb.WriteString("</span>")
+				}
+				current, open = c.sgr, true
+				if css := sgrToCSS(c.sgr); css != "" {
+					sb.WriteString(`<span style="` + css + `">`)
+				} else {
+					sb.WriteString("<span>")
+				}
+			}
+			sb.WriteString(html.EscapeString(c.ch))
+		}
+		if open {
+			sb.WriteString("</span>")
+		}
+	}
+	return sb.String()
+}
+
+// sgrToCSS traduce las secuencias SGR acumuladas de una celda a CSS
+func sgrToCSS(sgr string) string {
+	st := parseSGR(sgr)
+	var css []string
+	if st.hasFg {
+		css = append(css, "color:"+rgbCSS(st.fg))
+	}
+	if st.hasBg {
+		css = append(css, "background:"+rgbCSS(st.bg))
+	}
+	if st.bold {
+		css = append(css, "font-weight:bold")
+	}
+	if st.faint {
+		css = append(css, "opacity:.6")
+	}
+	if st.italic {
+		css = append(css, "font-style:italic")
+	}
+	switch {
+	case st.underline && st.strike:
+		css = append(css, "text-decoration:underline line-through")
+	case st.underline:
+		css = append(css, "text-decoration:underline")
+	case st.strike:
+		css = append(css, "text-decoration:line-through")
+	}
+	return strings.Join(css, ";")
+}
+
+func rgbCSS(c [3]int) string {
+	return fmt.Sprintf("#%02x%02x%02x", c[0], c[1], c[2])
+}
+
+// writeHTML escribe el deck como una página autocontenida: los cuadros van
+// en elementos <pre> y un script los recorre con las mismas teclas que la
+// terminal. No se descarga nada de la red.
+func writeHTML(w io.Writer, title string, d deck, slides []exportedSlide) error {
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html lang=\"es\">\n<head>\n<meta charset=\"utf-8\">\n")
+	sb.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+	sb.WriteString("<style>\n" + fmt.Sprintf(exportCSS, rgbCSS(pageBackground), rgbCSS(pageForeground)) + "</style>\n</head>\n<body>\n")
+	sb.WriteString(fmt.Sprintf("<main id=\"deck\" data-cols=\"%d\" data-rows=\"%d\">\n", slideWidth+2, slideHeight+2))
+	for i, s := range slides {
+		sb.WriteString(fmt.Sprintf("<section class=\"slide\" data-title=\"%s\" data-notes=\"%s\" data-freeze=\"%t\">\n",
+			html.EscapeString(s.title), html.EscapeString(s.notes), d.freeze[i]))
+		for _, step := range s.steps {
+			sb.WriteString(fmt.Sprintf("<div class=\"step\" data-loop=\"%t\">\n", step.loop))
+			for _, frame := range step.frames {
+				sb.WriteString(fmt.Sprintf("<pre class=\"frame\" data-ms=\"%d\">", frame.ms))
+				sb.WriteString(cellsToHTML(frame.view))
+				sb.WriteString("</pre>\n")
+			}
+			sb.WriteString("</div>\n")
+		}
+		sb.WriteString("</section>\n")
+	}
+	sb.WriteString("</main>\n<div id=\"status\"></div>\n<script>\n" + exportJS + "</script>\n</body>\n</html>\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+const exportCSS = `html, body { margin: 0; height: 100%%; background: %s; color: %s; }
+body { display: flex; flex-direction: column; align-items: center; justify-content: center;
+  font-family: ui-monospace, "DejaVu Sans Mono", Menlo, Consolas, monospace; }
+pre { margin: 0; font: inherit; line-height: 1.2; white-space: pre; }
+.slide, .step, .frame { display: none; }
+.slide.current, .step.current, .frame.current { display: block; }
+#status { margin-top: 1em; opacity: .8; white-space: pre; }
+main.overview { display: grid; grid-template-columns: repeat(auto-fill, minmax(28ch, 1fr)); gap: 1ch;
+  width: 100%%; height: 100%%; overflow: auto; align-content: start; }
+main.overview .slide { display: block; zoom: .3; outline: 3px solid #4a4a5a; cursor: pointer; }
+main.overview .slide.selected { outline-color: #7d56f4; }
+main.overview .slide.match { outline-color: #ffff10; }
+main.overview .step.last, main.overview .step.last .frame.final { display: block; }
+main.overview .step:not(.last), main.overview .frame:not(.final) { display: none; }
+::highlight(search) { background: #ffff10; color: #000; }
+`
+
+const exportJS = `const deck = document.getElementById("deck");
+const statusBar = document.getElementById("status");
+const slides = [...deck.querySelectorAll(".slide")].map(s => ({
+  el: s, steps: [...s.querySelectorAll(".step")], freeze: s.dataset.freeze === "true",
+  text: (s.textContent + " " + s.dataset.notes).replace(/\s+/g, " ").toLowerCase(),
+}));
+for (const s of slides) {
+  s.steps[s.steps.length - 1].classList.add("last");
+  for (const st of s.steps) st.lastElementChild.classList.add("final");
+}
+let cur = 0, overview = false, selected = 0, paused = false, prompt = "", input = "", query = "", matches = [];
+const stepOf = slides.map(() => 0);
+let timer = null, frame = 0, played = new Set();
+
+function fit() {
+  const cols = +deck.dataset.cols, rows = +deck.dataset.rows;
+  const size = Math.min(innerWidth / (cols * 0.62), innerHeight / ((rows + 3) * 1.2));
+  document.body.style.fontSize = Math.max(6, Math.floor(size)) + "px";
+}
+
+function frames() { return [...slides[cur].steps[stepOf[cur]].children]; }
+
+// play muestra los cuadros del paso actual uno tras otro; al terminar
+// queda el último, salvo en las animaciones que se repiten
+function play(from) {
+  clearTimeout(timer);
+  const fs = frames();
+  fs.forEach(f => f.classList.remove("current"));
+  frame = from;
+  fs[frame].classList.add("current");
+  if (paused || fs.length < 2) return;
+  const loop = slides[cur].steps[stepOf[cur]].dataset.loop === "true";
+  timer = setTimeout(function tick() {
+    if (frame === fs.length - 1 && !loop) return;
+    fs[frame].classList.remove("current");
+    frame = (frame + 1) % fs.length;
+    fs[frame].classList.add("current");
+    timer = setTimeout(tick, +fs[frame].dataset.ms);
+  }, +fs[frame].dataset.ms);
+}
+
+function show(animate) {
+  for (const s of slides) {
+    s.el.classList.remove("current");
+    s.steps.forEach(st => st.classList.remove("current"));
+  }
+  const s = slides[cur];
+  s.el.classList.add("current");
+  s.steps[stepOf[cur]].classList.add("current");
+  // Congelado, un slide ya visto muestra su último cuadro
+  const still = !animate || (s.freeze && played.has(cur + ":" + stepOf[cur]));
+  play(still ? frames().length - 1 : 0);
+  played.add(cur + ":" + stepOf[cur]);
+  highlight();
+  render();
+}
+
+function counter() {
+  let c = "[" + (cur + 1) + "/" + slides.length;
+  const n = slides[cur].steps.length - 1;
+  if (n > 0) c += " · " + stepOf[cur] + "/" + n;
+  return c + "]";
+}
+
+function render() {
+  if (prompt === "g") statusBar.textContent = "Ir al slide: " + input + "▌ (1-" + slides.length + ", enter para ir, esc para cancelar)";
+  else if (prompt === "/") statusBar.textContent = "/" + input + "▌";
+  else if (overview) statusBar.textContent = "Vista general [" + (selected + 1) + "/" + slides.length + "] ←↑↓→ elegir, enter para abrir, esc para volver";
+  else if (query && !matches.length) statusBar.textContent = counter() + " Sin resultados para \"" + query + "\" (esc para limpiar)";
+  else if (query) statusBar.textContent = counter() + " \"" + query + "\" en " + matches.length + " slides: n/N siguiente/anterior, esc para limpiar";
+  else if (paused) statusBar.textContent = counter() + " ⏸ En pausa: '.' para seguir, 'R' para empezar de nuevo";
+  else statusBar.textContent = counter() + " Use ← → para navegar";
+  slides.forEach((s, i) => {
+    s.el.classList.toggle("selected", overview && i === selected);
+    s.el.classList.toggle("match", matches.includes(i));
+  });
+}
+
+function go(idx) {
+  if (idx < 0 || idx >= slides.length) return;
+  cur = idx;
+  show(true);
+}
+
+function next() {
+  if (stepOf[cur] < slides[cur].steps.length - 1) { stepOf[cur]++; show(true); }
+  else go(cur + 1);
+}
+
+function prev() {
+  if (stepOf[cur] > 0) { stepOf[cur]--; show(false); }
+  else go(cur - 1);
+}
+
+function nextMatch(dir) {
+  for (let i = 1; i <= slides.length; i++) {
+    const idx = (cur + dir * i + slides.length) % slides.length;
+    if (matches.includes(idx)) { if (idx !== cur) go(idx); return; }
+  }
+}
+
+// highlight marca las apariciones de la búsqueda en el slide actual con la
+// API de resaltado de CSS, donde el navegador la tenga
+function highlight() {
+  if (!window.CSS || !CSS.highlights) return;
+  CSS.highlights.delete("search");
+  if (!query) return;
+  const ranges = [];
+  const walker = document.createTreeWalker(slides[cur].el, NodeFilter.SHOW_TEXT);
+  for (let node; (node = walker.nextNode());) {
+    const text = node.textContent.toLowerCase();
+    for (let i = text.indexOf(query); i >= 0; i = text.indexOf(query, i + query.length)) {
+      const r = new Range();
+      r.setStart(node, i);
+      r.setEnd(node, i + query.length);
+      ranges.push(r);
+    }
+  }
+  CSS.highlights.set("search", new Highlight(...ranges));
+}
+
+function setOverview(on) {
+  overview = on;
+  selected = cur;
+  deck.classList.toggle("overview", on);
+  if (on) slides[selected].el.scrollIntoView({ block: "nearest" });
+  render();
+}
+
+function columns() {
+  const first = slides[0].el.getBoundingClientRect().top;
+  return Math.max(1, slides.filter(s => s.el.getBoundingClientRect().top === first).length);
+}
+
+document.addEventListener("keydown", e => {
+  const key = e.key;
+  if (prompt) {
+    if (key === "Escape") prompt = "";
+    else if (key === "Backspace") input = input.slice(0, -1);
+    else if (key === "Enter") {
+      if (prompt === "g") { const n = parseInt(input, 10); prompt = ""; if (n >= 1 && n <= slides.length && n - 1 !== cur) go(n - 1); }
+      else {
+        prompt = "";
+        query = input.trim().replace(/\s+/g, " ").toLowerCase();
+        matches = query ? slides.map((s, i) => s.text.includes(query) ? i : -1).filter(i => i >= 0) : [];
+        if (query && !matches.includes(cur)) nextMatch(1);
+        highlight();
+      }
+    } else if (key.length === 1 && (prompt === "/" || /[0-9]/.test(key))) input += key;
+    e.preventDefault();
+    render();
+    return;
+  }
+  if (overview) {
+    const cols = columns();
+    if (key === "Escape" || key === "o") setOverview(false);
+    else if (key === "ArrowLeft" || key === "h") selected = Math.max(0, selected - 1);
+    else if (key === "ArrowRight" || key === "l") selected = Math.min(slides.length - 1, selected + 1);
+    else if (key === "ArrowUp" || key === "k") { if (selected - cols >= 0) selected -= cols; }
+    else if (key === "ArrowDown" || key === "j") { if (selected + cols < slides.length) selected += cols; }
+    else if (key === "Home") selected = 0;
+    else if (key === "End") selected = slides.length - 1;
+    else if (key === "Enter" || key === " ") { const s = selected; setOverview(false); if (s !== cur) go(s); }
+    else return;
+    e.preventDefault();
+    slides[selected].el.scrollIntoView({ block: "nearest" });
+    render();
+    return;
+  }
+  if (query && (key === "n" || key === "N" || key === "Escape")) {
+    if (key === "Escape") { query = ""; matches = []; highlight(); render(); }
+    else nextMatch(key === "n" ? 1 : -1);
+    e.preventDefault();
+    return;
+  }
+  switch (key) {
+    case "ArrowRight": case "l": case "n": case " ": next(); break;
+    case "ArrowLeft": case "h": case "p": prev(); break;
+    case "o": setOverview(true); break;
+    case "g": case "/": prompt = key; input = ""; render(); break;
+    case ".": paused = !paused; if (paused) clearTimeout(timer); else play(frame); render(); break;
+    case "R": play(0); break;
+    default: return;
+  }
+  e.preventDefault();
+});
+
+deck.addEventListener("click", e => {
+  if (!overview) return;
+  const i = slides.findIndex(s => s.el.contains(e.target));
+  if (i >= 0) { setOverview(false); if (i !== cur) go(i); }
+});
+
+addEventListener("resize", fit);
+fit();
+show(true);
+`
+
+// monoFont es la fuente que se incrusta en los PDF, así se ven igual en
+// cualquier máquina. Es DejaVu Sans Mono, que tiene los dibujos de cajas,
+// los bloques y el braille que usan los slides (licencia en fonts/LICENSE).
+//
+//go:embed fonts/DejaVuSansMono.ttf
+var monoFont []byte
+
+// trueType tiene lo que el PDF necesita de una fuente TrueType: las
+// métricas, en unidades de la fuente, y la tabla para pasar de runas a
+// glifos
+type trueType struct {
+	data            []byte
+	unitsPerEm      int
+	advance         int
+	ascent, descent int
+	bbox            [4]int
+	cmap            []byte
+	cmapFormat      int
+}
+
+func be16(b []byte) int { return int(binary.BigEndian.Uint16(b)) }
+func be32(b []byte) int { return int(binary.BigEndian.Uint32(b)) }
+
+// parseTrueType lee las tablas head, hhea, hmtx y cmap de una fuente
+func parseTrueType(data []byte) (*trueType, error) {
+	invalid := fmt.Errorf("la fuente no es TrueType válida")
+	if len(data) < 12 {
+		return nil, invalid
+	}
+	n := be16(data[4:])
+	if len(data) < 12+16*n {
+		return nil, invalid
+	}
+	tables := map[string][]byte{}
+	for i := 0; i < n; i++ {
+		rec := data[12+16*i:]
+		offset, length := be32(rec[8:]), be32(rec[12:])
+		if offset+length > len(data) {
+			return nil, invalid
+		}
+		tables[string(rec[:4])] = data[offset : offset+length]
+	}
+	head, hhea, hmtx, cmap := tables["head"], tables["hhea"], tables["hmtx"], tables["cmap"]
+	if len(head) < 54 || len(hhea) < 36 || len(hmtx) < 4 || len(cmap) < 4 {
+		return nil, invalid
+	}
+
+	t := &trueType{
+		data:       data,
+		unitsPerEm: be16(head[18:]),
+		advance:    be16(hmtx),
+		ascent:     int(int16(be16(hhea[4:]))),
+		descent:    int(int16(be16(hhea[6:]))),
+		bbox: [4]int{int(int16(be16(head[36:]))), int(int16(be16(head[38:]))),
+			int(int16(be16(head[40:]))), int(int16(be16(head[42:])))},
+	}
+
+	// Se prefiere la subtabla Unicode completa (formato 12) a la que solo
+	// cubre el plano básico (formato 4)
+	for i := 0; i < be16(cmap[2:]) && len(cmap) >= 4+8*(i+1); i++ {
+		rec := cmap[4+8*i:]
+		platform, encoding, offset := be16(rec), be16(rec[2:]), be32(rec[4:])
+		if offset+4 > len(cmap) || (platform != 0 && (platform != 3 || (encoding != 1 && encoding != 10))) {
+			continue
+		}
+		sub := cmap[offset:]
+		switch format := be16(sub); {
+		case format == 12 && len(sub) >= 16:
+			t.cmap, t.cmapFormat = sub, 12
+		case format == 4 && len(sub) >= 14 && t.cmapFormat != 12:
+			t.cmap, t.cmapFormat = sub, 4
+		}
+	}
+	if t.cmap == nil || t.unitsPerEm == 0 {
+		return nil, invalid
+	}
+	return t, nil
+}
+
+// glyph devuelve el glifo de una runa, o 0 (el glifo "no definido") si la
+// fuente no la tiene
+func (t *trueType) glyph(r rune) int {
+	sub := t.cmap
+	if t.cmapFormat == 12 {
+		for i := 0; i < be32(sub[12:]) && len(sub) >= 16+12*(i+1); i++ {
+			g := sub[16+12*i:]
+			if start, end := be32(g), be32(g[4:]); int(r) >= start && int(r) <= end {
+				return be32(g[8:]) + int(r) - start
+			}
+		}
+		return 0
+	}
+
+	if r > 0xFFFF {
+		return 0
+	}
+	segments := be16(sub[6:]) / 2
+	ends, starts, deltas, ranges := 14, 16+2*segments, 16+4*segments, 16+6*segments
+	if len(sub) < ranges+2*segments {
+		return 0
+	}
+	for i := 0; i < segments; i++ {
+		if int(r) > be16(sub[ends+2*i:]) {
+			continue
+		}
+		start := be16(sub[starts+2*i:])
+		if int(r) < start {
+			return 0
+		}
+		delta, rangeOffset := be16(sub[deltas+2*i:]), be16(sub[ranges+2*i:])
+		if rangeOffset == 0 {
+			return (int(r) + delta) & 0xFFFF
+		}
+		at := ranges + 2*i + rangeOffset + 2*(int(r)-start)
+		if at+2 > len(sub) {
+			return 0
+		}
+		if g := be16(sub[at:]); g != 0 {
+			return (g + delta) & 0xFFFF
+		}
+		return 0
+	}
+	return 0
+}
+
+// pdfWriter arma un PDF objeto por objeto. Los números de objeto se
+// reservan antes de escribirlos, así se pueden referenciar de antemano.
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+func newPDFWriter() *pdfWriter {
+	p := &pdfWriter{offsets: []int{0}}
+	p.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	return p
+}
+
+func (p *pdfWriter) reserve() int {
+	p.offsets = append(p.offsets, 0)
+	return len(p.offsets) - 1
+}
+
+func (p *pdfWriter) object(id int, body string) {
+	p.offsets[id] = p.buf.Len()
+	fmt.Fprintf(&p.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+// stream escribe un objeto stream comprimido; dict son las entradas extra
+// del diccionario
+func (p *pdfWriter) stream(id int, dict string, data []byte) {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write(data)
+	zw.Close()
+
+	if dict != "" {
+		dict += " "
+	}
+	p.offsets[id] = p.buf.Len()
+	fmt.Fprintf(&p.buf, "%d 0 obj\n<< %s/Length %d /Filter /FlateDecode >>\nstream\n", id, dict, z.Len())
+	p.buf.Write(z.Bytes())
+	p.buf.WriteString("\nendstream\nendobj\n")
+}
+
+// finish agrega la tabla xref y el trailer
+func (p *pdfWriter) finish(root, info int) []byte {
+	xref := p.buf.Len()
+	fmt.Fprintf(&p.buf, "xref\n0 %d\n0000000000 65535 f \n", len(p.offsets))
+	for _, offset := range p.offsets[1:] {
+		fmt.Fprintf(&p.buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&p.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(p.offsets), root, info, xref)
+	return p.buf.Bytes()
+}
+
+// pdfText codifica un texto de PDF en UTF-16, para que admita acentos
+func pdfText(s string) string {
+	var sb strings.Builder
+	sb.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&sb, "%04X", u)
+	}
+	sb.WriteString(">")
+	return sb.String()
+}
+
+func pdfColor(c [3]int) string {
+	return fmt.Sprintf("%.3f %.3f %.3f", float64(c[0])/255, float64(c[1])/255, float64(c[2])/255)
+}
+
+// Tamaño de la letra en los PDF, en puntos; las celdas miden lo que un
+// glifo de ancho y lo que va del ascendente al descendente de alto, así los
+// bloques y las líneas de las cajas se tocan entre filas
+const pdfFontSize = 10
+
+// pdfPage dibuja una grilla de celdas como el contenido de una página.
+// used junta los glifos que aparecen, para la tabla ToUnicode.
+func pdfPage(font *trueType, grid [][]cell, used map[int]rune) (content []byte, width, height float64) {
+	scale := float64(pdfFontSize) / float64(font.unitsPerEm)
+	cellW := float64(font.advance) * scale
+	cellH := float64(font.ascent-font.descent) * scale
+	baseline := float64(-font.descent) * scale
+
+	cols := 0
+	for _, row := range grid {
+		cols = max(cols, len(row))
+	}
+	// Margen de dos columnas y una fila alrededor
+	width = float64(cols+4) * cellW
+	height = float64(len(grid)+2) * cellH
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s rg 0 0 %.2f %.2f re f\n", pdfColor(pageBackground), width, height)
+	for y, row := range grid {
+		bottom := height - float64(y+2)*cellH
+
+		// Fondos, juntando las celdas seguidas del mismo color
+		for x := 0; x < len(row); {
+			st := parseSGR(row[x].sgr)
+			end := x + 1
+			for end < len(row) && parseSGR(row[end].sgr).bg == st.bg && parseSGR(row[end].sgr).hasBg == st.hasBg {
+				end++
+			}
+			if st.hasBg {
+				fmt.Fprintf(&sb, "%s rg %.2f %.2f %.2f %.2f re f\n", pdfColor(st.bg),
+					float64(x+2)*cellW, bottom, float64(end-x)*cellW, cellH)
+			}
+			x = end
+		}
+
+		// Texto, en tramos del mismo estilo. Un carácter ancho corta el
+		// tramo, porque la fuente lo dibuja de una sola columna.
+		for x := 0; x < len(row); {
+			if row[x].width == 0 || row[x].ch == " " && !parseSGR(row[x].sgr).underline && !parseSGR(row[x].sgr).strike {
+				x++
+				continue
+			}
+			if dots, ok := brailleDots(row[x].ch); ok {
+				writeBraille(&sb, parseSGR(row[x].sgr), dots, float64(x+2)*cellW, bottom, cellW, cellH)
+				x++
+				continue
+			}
+			sgr := row[x].sgr
+			var glyphs strings.Builder
+			start := x
+			for x < len(row) && row[x].sgr == sgr && row[x].width != 0 {
+				if _, ok := brailleDots(row[x].ch); ok {
+					break
+				}
+				r, _ := utf8.DecodeRuneInString(row[x].ch)
+				g := font.glyph(r)
+				used[g] = r
+				fmt.Fprintf(&glyphs, "%04X", g)
+				x++
+				if row[x-1].width == 2 {
+					x++
+					break
+				}
+			}
+			writeRun(&sb, parseSGR(sgr), glyphs.String(), float64(start+2)*cellW, bottom+baseline, float64(x-start)*cellW)
+		}
+	}
+	return []byte(sb.String()), width, height
+}
+
+// brailleDots devuelve los puntos de un carácter braille
+func brailleDots(ch string) (rune, bool) {
+	r, _ := utf8.DecodeRuneInString(ch)
+	return r - 0x2800, r >= 0x2800 && r <= 0x28FF
+}
+
+// writeBraille dibuja los puntos de una celda braille como cuadrados, ya
+// que la fuente no trae esos caracteres
+func writeBraille(sb *strings.Builder, st cellStyle, dots rune, x, bottom, cellW, cellH float64) {
+	fg := pageForeground
+	if st.hasFg {
+		fg = st.fg
+	}
+	size := min(cellW/2, cellH/4) * 0.7
+	fmt.Fprintf(sb, "%s rg\n", pdfColor(fg))
+	for dy, row := range brailleBits {
+		for dx, bit := range row {
+			if dots&bit == 0 {
+				continue
+			}
+			cx := x + (float64(dx)+0.5)*cellW/2
+			cy := bottom + cellH - (float64(dy)+0.5)*cellH/4
+			fmt.Fprintf(sb, "%.2f %.2f %.2f %.2f re f\n", cx-size/2, cy-size/2, size, size)
+		}
+	}
+}
+
+// writeRun dibuja un tramo de glifos. La negrita se simula con el trazo del
+// contorno y la cursiva inclinando el texto, ya que hay una sola fuente.
+func writeRun(sb *strings.Builder, st cellStyle, glyphs string, x, y, width float64) {
+	fg := pageForeground
+	if st.hasFg {
+		fg = st.fg
+	}
+	if st.faint {
+		bg := pageBackground
+		if st.hasBg {
+			bg = st.bg
+		}
+		for i := range fg {
+			fg[i] = (fg[i]*6 + bg[i]*4) / 10
+		}
+	}
+
+	fmt.Fprintf(sb, "%s rg\n", pdfColor(fg))
+	sb.WriteString("BT /F1 " + strconv.Itoa(pdfFontSize) + " Tf\n")
+	// El modo de dibujo del texto sigue vigente después de ET, así que se
+	// fija en cada tramo
+	if st.bold {
+		fmt.Fprintf(sb, "2 Tr %s RG %.2f w\n", pdfColor(fg), pdfFontSize*0.04)
+	} else {
+		sb.WriteString("0 Tr\n")
+	}
+	skew := 0.0
+	if st.italic {
+		skew = 0.2
+	}
+	fmt.Fprintf(sb, "1 0 %.2f 1 %.2f %.2f Tm <%s> Tj ET\n", skew, x, y, glyphs)
+
+	if st.underline {
+		fmt.Fprintf(sb, "%.2f %.2f %.2f %.2f re f\n", x, y-pdfFontSize*0.12, width, pdfFontSize*0.06)
+	}
+	if st.strike {
+		fmt.Fprintf(sb, "%.2f %.2f %.2f %.2f re f\n", x, y+pdfFontSize*0.3, width, pdfFontSize*0.06)
+	}
+}
+
+// toUnicode arma el CMap que permite copiar y buscar el texto del PDF
+func toUnicode(used map[int]rune) []byte {
+	glyphs := make([]int, 0, len(used))
+	for g := range used {
+		glyphs = append(glyphs, g)
+	}
+	sort.Ints(glyphs)
+
+	var sb strings.Builder
+	sb.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	// Cada bloque bfchar admite hasta 100 entradas
+	for len(glyphs) > 0 {
+		n := min(len(glyphs), 100)
+		fmt.Fprintf(&sb, "%d beginbfchar\n", n)
+		for _, g := range glyphs[:n] {
+			fmt.Fprintf(&sb, "<%04X> <", g)
+			for _, u := range utf16.Encode([]rune{used[g]}) {
+				fmt.Fprintf(&sb, "%04X", u)
+			}
+			sb.WriteString(">\n")
+		}
+		sb.WriteString("endbfchar\n")
+		glyphs = glyphs[n:]
+	}
+	sb.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return []byte(sb.String())
+}
+
+// pdfNotes agrega las notas del orador debajo de la vista de un slide
+func pdfNotes(view, notes string) string {
+	if notes == "" {
+		return view
+	}
+	_, lines := renderMarkdown(notes, slideWidth+2)
+	return view + "\n\n" + presenterLabelStyle.Render("Notas") + "\n" +
+		notesStyle.Width(slideWidth+2).Render(strings.Join(lines, "\n"))
+}
+
+// writePDF escribe un folleto con una página por slide, en su último paso;
+// con notes, las notas del orador van debajo de cada slide
+func writePDF(w io.Writer, title string, slides []exportedSlide, notes bool) error {
+	font, err := parseTrueType(monoFont)
+	if err != nil {
+		return err
+	}
+
+	p := newPDFWriter()
+	catalog, pages, info := p.reserve(), p.reserve(), p.reserve()
+	type0, cidFont, descriptor, fontFile, cmap := p.reserve(), p.reserve(), p.reserve(), p.reserve(), p.reserve()
 
-	nav := fmt.Sprintf("\n[%d/%d] Use ← → para navegar, 'q' para salir", 
-		m.currentIdx+1, len(m.slides))
+	used := map[int]rune{}
+	var kids []string
+	for _, s := range slides {
+		step := s.steps[len(s.steps)-1]
+		view := step.frames[len(step.frames)-1].view
+		if notes {
+			view = pdfNotes(view, s.notes)
+		}
+		content, width, height := pdfPage(font, parseCells(view), used)
+
+		page, stream := p.reserve(), p.reserve()
+		p.stream(stream, "", content)
+		p.object(page, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>", pages, width, height, type0, stream))
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+
+	p.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+	p.object(pages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	p.object(info, fmt.Sprintf("<< /Title %s /Producer (slides) >>", pdfText(title)))
+
+	em := func(v int) int { return v * 1000 / font.unitsPerEm }
+	p.object(type0, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /DejaVuSansMono /Encoding /Identity-H "+
+		"/DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>", cidFont, cmap))
+	p.object(cidFont, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /DejaVuSansMono "+
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+		"/FontDescriptor %d 0 R /DW %d /CIDToGIDMap /Identity >>", descriptor, em(font.advance)))
+	p.object(descriptor, fmt.Sprintf("<< /Type /FontDescriptor /FontName /DejaVuSansMono /Flags 33 "+
+		"/FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		em(font.bbox[0]), em(font.bbox[1]), em(font.bbox[2]), em(font.bbox[3]),
+		em(font.ascent), em(font.descent), em(font.ascent), fontFile))
+	p.stream(fontFile, fmt.Sprintf("/Length1 %d", len(font.data)), font.data)
+	p.stream(cmap, "", toUnicode(used))
+
+	_, err = w.Write(p.finish(catalog, info))
+	return err
+}
+
+// runExport implementa "slides export": dibuja el deck sin abrir la
+// interfaz y lo escribe en el formato pedido
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Uso: slides export --html|--pdf [opciones] deck.md [salida]\n")
+		fs.PrintDefaults()
+	}
+	asHTML := fs.Bool("html", false, "exporta una presentación HTML autocontenida")
+	asPDF := fs.Bool("pdf", false, "exporta un PDF con una página por slide")
+	notes := fs.Bool("notes", false, "agrega las notas del orador debajo de cada slide del PDF")
+	width := fs.Int("width", 76, "ancho de los slides exportados, en columnas (76 es lo que entra en una terminal de 80x24)")
+	height := fs.Int("height", 19, "alto de los slides exportados, en filas")
+	runCommands := fs.Bool("run-commands", false, "ejecuta los slides de comando para exportar su salida (por defecto solo se muestra el comando)")
+	fs.Parse(args)
+
+	if fs.NArg() < 1 || *asHTML == *asPDF {
+		fs.Usage()
+		os.Exit(2)
+	}
+	ext := ".html"
+	if *asPDF {
+		ext = ".pdf"
+	}
+	deckPath := fs.Arg(0)
+	out := fs.Arg(1)
+	if out == "" {
+		out = strings.TrimSuffix(deckPath, filepath.Ext(deckPath)) + ext
+	}
+
+	d, err := loadDeck(deckPath)
+	if err != nil {
+		return err
+	}
+	setSlideSize(max(*width, minSlideWidth), max(*height, minSlideHeight))
+	slides := renderDeck(d, *runCommands)
 
-	return slideView + "\n" + nav
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	title := strings.TrimSuffix(filepath.Base(deckPath), filepath.Ext(deckPath))
+	if *asPDF {
+		err = writePDF(f, title, slides, *notes)
+	} else {
+		err = writeHTML(f, title, d, slides)
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
 }
 
 func main() {
 	rand.Seed(time.Now().UnixNano())
-	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := runExport(os.Args[2:]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "credits" {
+		if err := runCredits(os.Args[2:]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Uso: slides [opciones] [deck.md]\n       slides export --html|--pdf deck.md [salida]\n       slides credits [repositorio]\n")
+		flag.PrintDefaults()
+	}
+	transitionKind := flag.String("transition", "", "transición por defecto: push, wipe, dissolve, fade o matrix")
+	presenter := flag.Bool("presenter", false, "abre la vista del presentador, que maneja a la audiencia")
+	socket := flag.String("socket", filepath.Join(os.TempDir(), "slides.sock"), "socket Unix que une presentador y audiencia")
+	follow := flag.Bool("follow", false, "conecta esta sesión como audiencia del presentador")
+	talk := flag.Duration("duration", 0, "duración prevista de la charla, para el tiempo restante y el ritmo")
+	advance := flag.String("advance", "", "avance automático: tiempo por slide (\"8s\") o end para pasar cuando cada slide termina; el deck puede fijar el suyo")
+	loop := flag.Bool("loop", false, "con avance automático, vuelve al primer slide después del último (modo kiosco)")
+	remote := flag.String("remote", "", "abre el control remoto por HTTP en esta dirección (\":8078\"); se empareja con el código que muestra la pantalla")
+	remoteToken := flag.String("remote-token", "", "código fijo para el control remoto, para usarlo desde scripts")
+	votes := flag.String("votes", ":8077", "dirección donde el público vota en las encuestas del deck")
+	projector := flag.Bool("projector", false, fmt.Sprintf("usa slides fijos de %dx%d en lugar de adaptarlos a la terminal", projectorWidth, projectorHeight))
+	flag.Parse()
+
+	m := initialModel()
+	if flag.NArg() > 0 {
+		d, err := loadDeck(flag.Arg(0))
+		if err != nil {
+			fmt.Printf("Error loading deck: %v\n", err)
+			os.Exit(1)
+		}
+		m.deck = d
+	}
+	if *advance != "" {
+		spec, err := parseAdvanceValue(*advance)
+		if err != nil {
+			fmt.Printf("Error in -advance: %v\n", err)
+			os.Exit(1)
+		}
+		for i, a := range m.advance {
+			switch {
+			case a == (advanceSpec{}):
+				m.advance[i] = spec
+			case a.onEnd && a.after == 0:
+				m.advance[i].after = spec.after
+			}
+		}
+	}
+	// La audiencia sigue al presentador: no avanza sola
+	m.auto = !*follow && slices.ContainsFunc(m.advance, func(a advanceSpec) bool { return a.after > 0 || a.onEnd })
+	m.loop = *loop
+	if *transitionKind != "" {
+		for i := range m.transitions {
+			if m.transitions[i].kind == "" {
+				m.transitions[i] = transitionSpec{kind: *transitionKind, duration: defaultTransitionDuration, easing: "spring"}
+			}
+		}
+	}
+
+	if *presenter || *follow {
+		var err error
+		if *presenter {
+			m.link, err = listenSync(*socket)
+		} else {
+			m.link, err = dialSync(*socket)
+		}
+		if err != nil {
+			fmt.Printf("Error connecting sessions: %v\n", err)
+			os.Exit(1)
+		}
+		defer m.link.close()
+	}
+	// Las encuestas las atiende el presentador (o la única sesión); la
+	// audiencia recibe los votos por el syncLink
+	if !*follow && slices.ContainsFunc(m.slides, func(s slide) bool { _, ok := s.(*pollSlide); return ok }) {
+		server, err := listenPolls(*votes)
+		if err != nil {
+			fmt.Printf("Error starting voting server: %v\n", err)
+			os.Exit(1)
+		}
+		defer server.shutdown()
+		for _, s := range m.slides {
+			if p, ok := s.(*pollSlide); ok {
+				p.server, p.url = server, server.url
+			}
+		}
+	}
+	if *remote != "" {
+		if *follow {
+			fmt.Println("Error: -remote no se puede usar con -follow: la audiencia sigue al presentador")
+			os.Exit(1)
+		}
+		var err error
+		m.remote, err = listenRemote(*remote, *remoteToken, len(m.slides))
+		if err != nil {
+			fmt.Printf("Error starting remote control: %v\n", err)
+			os.Exit(1)
+		}
+		defer m.remote.close()
+	}
+	m.presenter = *presenter
+	m.projector = *projector
+	m.started = time.Now()
+	m.now = m.started
+	m.talk = *talk
+
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	if m.link != nil {
+		go m.link.serve(p.Send)
+	}
+	if m.remote != nil {
+		go m.remote.serve(p.Send)
+	}
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error running program: %v", err)
 	}
@@ -0,0 +1,2070 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+func TestTransitionDropsStaleTicks(t *testing.T) {
+	m := initialModel()
+	for i := range m.transitions {
+		m.transitions[i] = transitionSpec{kind: "push", duration: time.Second, easing: "linear"}
+	}
+
+	m, _ = m.goTo(1)
+	first := m.transGen
+	m, _ = m.goTo(2)
+	if m.transGen == first {
+		t.Fatal("la segunda transición no cambió de generación")
+	}
+
+	// El tick pendiente de la primera transición no avanza la segunda
+	m, _ = m.update(transitionTickMsg{first})
+	if m.trans.frame != 0 {
+		t.Errorf("un tick viejo avanzó la transición al cuadro %d", m.trans.frame)
+	}
+	m, _ = m.update(transitionTickMsg{m.transGen})
+	if m.trans.frame != 1 {
+		t.Errorf("cuadro %d después de un tick, se esperaba 1", m.trans.frame)
+	}
+}
+
+var update = flag.Bool("update", false, "reescribe los archivos golden de testdata")
+
+// plainRows es la vista sin estilos, fila por fila
+func plainRows(view string) string {
+	var sb strings.Builder
+	for _, row := range parseCells(view) {
+		for _, c := range row {
+			if c.width > 0 {
+				sb.WriteString(c.ch)
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// checkGolden compara got con testdata/name; con -update lo reescribe
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (se genera con go test -update)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s no coincide:\n--- obtenido\n%s--- esperado\n%s", name, got, want)
+	}
+}
+
+// Tamaños de slide que se prueban: el mínimo, el del proyector y uno
+// cómodo
+var testSizes = [][2]int{{minSlideWidth, minSlideHeight}, {projectorWidth, projectorHeight}, {80, 24}}
+
+func TestChartSnapshots(t *testing.T) {
+	defer setSlideSize(projectorWidth, projectorHeight)
+	d, err := loadDeck(filepath.Join("testdata", "charts.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := []string{"line", "area", "sparkline", "pie", "donut", "bar", "bar_stacked"}
+	for _, size := range testSizes {
+		setSlideSize(size[0], size[1])
+		for i, s := range d.slides {
+			s.(resetter).reset()
+			// Al empezar la animación no hay nada dibujado todavía
+			s.View()
+			checkGolden(t, fmt.Sprintf("%s_%dx%d.golden", kinds[i], size[0], size[1]), plainRows(stillView(s)))
+		}
+	}
+}
+
+// Todos los slides del deck de ejemplo se dibujan, quietos y al empezar,
+// en los tamaños que se prueban
+func TestDeckRendersAtAllSizes(t *testing.T) {
+	defer setSlideSize(projectorWidth, projectorHeight)
+	d, err := loadDeck("deck.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range testSizes {
+		setSlideSize(size[0], size[1])
+		for i, s := range d.slides {
+			if r, ok := s.(resetter); ok {
+				r.reset()
+			}
+			if len(strings.Split(s.View(), "\n")) != size[1]+2 || len(strings.Split(stillView(s), "\n")) != size[1]+2 {
+				t.Errorf("slide %d a %dx%d: no mide lo que el marco", i+1, size[0], size[1])
+			}
+		}
+	}
+}
+
+func TestCommandViewKeepsOutput(t *testing.T) {
+	defer setSlideSize(projectorWidth, projectorHeight)
+	setSlideSize(minSlideWidth, minSlideHeight)
+	c := &commandSlide{title: "Salida", command: "echo"}
+	c.appendLine(strings.Repeat("a", slideWidth-5) + "界界界")
+	before := slices.Clone(c.lines[0])
+	c.View()
+	if !slices.Equal(c.lines[0], before) {
+		t.Errorf("View modificó la salida guardada:\n%v\n%v", before, c.lines[0])
+	}
+}
+
+// extractPDF devuelve la cantidad de páginas del PDF y su texto, traducido
+// con el CMap ToUnicode. Los tramos a la misma altura van en la misma línea.
+func extractPDF(t *testing.T, pdf []byte) (int, string) {
+	t.Helper()
+	var streams []string
+	for rest := pdf; ; {
+		_, after, ok := bytes.Cut(rest, []byte(">>\nstream\n"))
+		if !ok {
+			break
+		}
+		data, tail, _ := bytes.Cut(after, []byte("\nendstream"))
+		r, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		plain, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		streams = append(streams, string(plain))
+		rest = tail
+	}
+
+	glyphs := map[string]string{}
+	entry := regexp.MustCompile(`<([0-9A-F]{4})> <([0-9A-F]+)>`)
+	for _, s := range streams {
+		if strings.Contains(s, "beginbfchar") {
+			for _, m := range entry.FindAllStringSubmatch(s, -1) {
+				var units []uint16
+				for i := 0; i < len(m[2]); i += 4 {
+					u, _ := strconv.ParseUint(m[2][i:i+4], 16, 16)
+					units = append(units, uint16(u))
+				}
+				glyphs[m[1]] = string(utf16.Decode(units))
+			}
+		}
+	}
+
+	var sb strings.Builder
+	run := regexp.MustCompile(`(\S+) Tm <([0-9A-F]*)> Tj`)
+	page := 0
+	for _, s := range streams {
+		if !strings.Contains(s, " Tj") {
+			continue
+		}
+		page++
+		fmt.Fprintf(&sb, "--- página %d", page)
+		y := ""
+		for _, m := range run.FindAllStringSubmatch(s, -1) {
+			if m[1] != y {
+				sb.WriteString("\n")
+				y = m[1]
+			} else {
+				sb.WriteString(" ")
+			}
+			for i := 0; i < len(m[2]); i += 4 {
+				sb.WriteString(glyphs[m[2][i:i+4]])
+			}
+		}
+		sb.WriteString("\n")
+	}
+	pages := bytes.Count(pdf, []byte("/Type /Page "))
+	return pages, sb.String()
+}
+
+// extractHTML devuelve la cantidad de slides del HTML y el texto del último
+// cuadro de cada paso
+func extractHTML(t *testing.T, page string) (int, string) {
+	t.Helper()
+	tag := regexp.MustCompile(`<[^>]*>`)
+	var sb strings.Builder
+	sections := strings.Split(page, `<section class="slide"`)[1:]
+	for i, section := range sections {
+		fmt.Fprintf(&sb, "--- slide %d\n", i+1)
+		for _, step := range strings.Split(section, `<div class="step"`)[1:] {
+			frames := strings.Split(step, `<pre class="frame"`)
+			last, _, _ := strings.Cut(frames[len(frames)-1], "</pre>")
+			_, last, _ = strings.Cut(last, ">")
+			sb.WriteString(html.UnescapeString(tag.ReplaceAllString(last, "")) + "\n")
+		}
+	}
+	return len(sections), sb.String()
+}
+
+func TestExportGolden(t *testing.T) {
+	defer setSlideSize(projectorWidth, projectorHeight)
+	d, err := loadDeck(filepath.Join("testdata", "export.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setSlideSize(projectorWidth, projectorHeight)
+	slides := renderDeck(d, false)
+
+	var pdf bytes.Buffer
+	if err := writePDF(&pdf, "export", slides, true); err != nil {
+		t.Fatal(err)
+	}
+	pages, text := extractPDF(t, pdf.Bytes())
+	if pages != len(d.slides) {
+		t.Errorf("el PDF tiene %d páginas, se esperaban %d", pages, len(d.slides))
+	}
+	checkGolden(t, "export_pdf.golden", text)
+
+	var page strings.Builder
+	if err := writeHTML(&page, "export", d, slides); err != nil {
+		t.Fatal(err)
+	}
+	sections, text := extractHTML(t, page.String())
+	if sections != len(d.slides) {
+		t.Errorf("el HTML tiene %d slides, se esperaban %d", sections, len(d.slides))
+	}
+	checkGolden(t, "export_html.golden", text)
+	if strings.Contains(text, "ejecutado-42") {
+		t.Error("la exportación ejecutó el comando sin --run-commands")
+	}
+}
+
+func TestExportRunsCommandsOnRequest(t *testing.T) {
+	defer setSlideSize(projectorWidth, projectorHeight)
+	d, err := loadDeck(filepath.Join("testdata", "export.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setSlideSize(projectorWidth, projectorHeight)
+	slides := renderDeck(d, true)
+	steps := slides[1].steps
+	frames := steps[len(steps)-1].frames
+	if view := plainRows(frames[len(frames)-1].view); !strings.Contains(view, "ejecutado-42") {
+		t.Errorf("con --run-commands falta la salida del comando:\n%s", view)
+	}
+}
+
+// pollDeck escribe un deck con una encuesta en un directorio temporal, así
+// el CSV de votos queda ahí
+func pollDeck(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "encuesta.md")
+	deck := "# Antes\n\n---\n\n+++\nkind: poll\ntitle: ¿Qué lenguaje usás más?\nmode: named\n+++\n- Go\n- Rust\n"
+	if err := os.WriteFile(path, []byte(deck), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// syncSession carga el deck en un modelo unido al syncLink link, que
+// entrega lo que recibe en msgs
+func syncSession(t *testing.T, path string, link *syncLink) (model, chan tea.Msg) {
+	t.Helper()
+	d, err := loadDeck(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := initialModel()
+	m.deck, m.link = d, link
+	t.Cleanup(link.close)
+	msgs := make(chan tea.Msg, 64)
+	go link.serve(func(msg tea.Msg) { msgs <- msg })
+	return m, msgs
+}
+
+// pump aplica los mensajes que llegan a m hasta que done se cumple
+func pump(t *testing.T, m model, msgs chan tea.Msg, done func(model) bool) model {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for !done(m) {
+		select {
+		case msg := <-msgs:
+			m, _ = m.update(msg)
+		case <-timeout:
+			t.Fatal("la sesión no recibió lo esperado")
+		}
+	}
+	return m
+}
+
+// joinSync une una audiencia al presentador por socket y espera a que el
+// presentador la reciba
+func joinSync(t *testing.T, socket, path string, presenter model, presenterMsgs chan tea.Msg) (model, model, chan tea.Msg) {
+	t.Helper()
+	link, err := dialSync(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, msgs := syncSession(t, path, link)
+	select {
+	case msg := <-presenterMsgs:
+		presenter, _ = presenter.update(msg.(syncJoinMsg))
+	case <-time.After(2 * time.Second):
+		t.Fatal("el presentador no vio llegar a la audiencia")
+	}
+	return presenter, m, msgs
+}
+
+func TestFollowerSeesPollVotes(t *testing.T) {
+	path := pollDeck(t)
+	socket := filepath.Join(t.TempDir(), "slides.sock")
+	server, err := listenPolls("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.shutdown()
+
+	link, err := listenSync(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	presenter, presenterMsgs := syncSession(t, path, link)
+	poll := presenter.slides[1].(*pollSlide)
+	poll.server, poll.url = server, server.url
+
+	ballots := func(m model) int { return len(m.slides[1].(*pollSlide).ballots) }
+
+	var audience model
+	var audienceMsgs chan tea.Msg
+	presenter, audience, audienceMsgs = joinSync(t, socket, path, presenter, presenterMsgs)
+	presenter, _ = presenter.goTo(1)
+	vote := pollVoteMsg{session: poll.session, voter: "Ana", name: "Ana", option: 1, at: time.Now()}
+	presenter, _ = presenter.update(slideMsg{idx: 1, gen: presenter.gen[1], msg: vote})
+	if ballots(presenter) != 1 {
+		t.Fatal("el presentador no registró el voto")
+	}
+
+	audience = pump(t, audience, audienceMsgs, func(m model) bool { return ballots(m) == 1 })
+	status := plainRows(audience.slides[1].(*pollSlide).status())
+	if !strings.Contains(status, "votá en") || !strings.Contains(status, "1 voto") {
+		t.Errorf("la audiencia no muestra la votación: %q", status)
+	}
+	if got := audience.slides[1].(*pollSlide).counts(); got[1] != 1 {
+		t.Errorf("la audiencia cuenta %v", got)
+	}
+
+	// Quien se une tarde recibe los votos que ya hubo
+	presenter, late, lateMsgs := joinSync(t, socket, path, presenter, presenterMsgs)
+	pump(t, late, lateMsgs, func(m model) bool { return ballots(m) == 1 && m.currentIdx == 1 })
+
+	// Solo el presentador guarda el CSV
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(path), "votos.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows := strings.Count(string(data), "\n"); rows != 2 {
+		t.Errorf("el CSV tiene %d filas, se esperaban el encabezado y un voto:\n%s", rows, data)
+	}
+}
+
+// remoteSession arranca el control remoto en un httptest.Server. El modelo
+// atiende las órdenes en su propia goroutine, como con tea.Program; stop lo
+// detiene y devuelve cómo quedó.
+func remoteSession(t *testing.T) (srv *httptest.Server, stop func() model) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "remoto.md")
+	if err := os.WriteFile(path, []byte("# Uno\n\n---\n\n# Dos\n\n---\n\n# Tres\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	d, err := loadDeck(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := initialModel()
+	m.deck = d
+	m.remote = &remoteServer{token: "123456", total: len(d.slides), clients: map[*remoteClient]bool{}}
+	msgs := make(chan tea.Msg, 16)
+	m.remote.deliver = func(msg tea.Msg) { msgs <- msg }
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m = next.(model)
+
+	done := make(chan model)
+	go func() {
+		for msg := range msgs {
+			next, _ := m.Update(msg)
+			m = next.(model)
+		}
+		done <- m
+	}()
+	srv = httptest.NewServer(m.remote.handler())
+	t.Cleanup(srv.Close)
+	return srv, func() model {
+		close(msgs)
+		return <-done
+	}
+}
+
+// remoteCall hace un pedido a la API con el código en el encabezado
+func remoteCall(t *testing.T, srv *httptest.Server, method, path string) (int, remoteState) {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer 123456")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var state remoteState
+	json.NewDecoder(resp.Body).Decode(&state)
+	return resp.StatusCode, state
+}
+
+func TestRemotePairing(t *testing.T) {
+	m := initialModel()
+	m.remote = &remoteServer{addr: "192.168.0.2:8078", token: "123456"}
+	if line := m.statusLine(); !strings.Contains(line, "código 123456") {
+		t.Errorf("sin emparejar no se ve el código: %q", line)
+	}
+
+	srv, stop := remoteSession(t)
+	resp, err := http.Get(srv.URL + "/api/state?token=654321")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("con otro código: %d, se esperaba 401", resp.StatusCode)
+	}
+	resp, err = http.Get(srv.URL + "/api/state?token=123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("con el código en la URL: %d, se esperaba 200", resp.StatusCode)
+	}
+
+	m = stop()
+	if !m.paired || strings.Contains(m.statusLine(), "código") {
+		t.Errorf("después de emparejar sigue el código: %q", m.statusLine())
+	}
+}
+
+func TestRemoteNavigation(t *testing.T) {
+	srv, stop := remoteSession(t)
+	defer stop()
+
+	steps := []struct {
+		method, path string
+		status       int
+		slide        int
+		title        string
+	}{
+		{"GET", "/api/state", 200, 1, "Uno"},
+		{"POST", "/api/next", 200, 2, "Dos"},
+		{"POST", "/api/goto/3", 200, 3, "Tres"},
+		{"POST", "/api/prev", 200, 2, "Dos"},
+		{"POST", "/api/goto/9", 400, 0, ""},
+		{"POST", "/api/state", 400, 0, ""},
+		{"GET", "/api/next", 405, 0, ""},
+	}
+	for _, step := range steps {
+		status, state := remoteCall(t, srv, step.method, step.path)
+		if status != step.status {
+			t.Errorf("%s %s: %d, se esperaba %d", step.method, step.path, status, step.status)
+			continue
+		}
+		if status == 200 && (state.Slide != step.slide || state.Title != step.title || state.Total != 3) {
+			t.Errorf("%s %s: %+v, se esperaba el slide %d %q", step.method, step.path, state, step.slide, step.title)
+		}
+	}
+}
+
+func TestRemoteBlank(t *testing.T) {
+	srv, stop := remoteSession(t)
+	if _, state := remoteCall(t, srv, "POST", "/api/blank"); !state.Blank {
+		t.Error("blank no dejó la pantalla en negro")
+	}
+	if _, state := remoteCall(t, srv, "POST", "/api/next"); state.Slide != 2 || !state.Blank {
+		t.Errorf("al navegar en negro: %+v", state)
+	}
+	if _, state := remoteCall(t, srv, "POST", "/api/blank"); state.Blank {
+		t.Error("el segundo blank no volvió a mostrar el slide")
+	}
+	if m := stop(); m.blank {
+		t.Error("el modelo quedó en negro")
+	}
+}
+
+// wsFrame arma un frame de texto del cliente, que siempre va con máscara
+func wsFrame(text string) []byte {
+	mask := [4]byte{1, 2, 3, 4}
+	frame := append([]byte{0x80 | wsText, 0x80 | byte(len(text))}, mask[:]...)
+	for i := range len(text) {
+		frame = append(frame, text[i]^mask[i%4])
+	}
+	return frame
+}
+
+// readState lee un frame del servidor, sin máscara, con un estado
+func readState(t *testing.T, r *bufio.Reader) remoteState {
+	t.Helper()
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		t.Fatal(err)
+	}
+	payload := make([]byte, head[1]&0x7F)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatal(err)
+	}
+	var state remoteState
+	if err := json.Unmarshal(payload, &state); err != nil {
+		t.Fatalf("%v: %s", err, payload)
+	}
+	return state
+}
+
+func TestRemoteWebSocketPush(t *testing.T) {
+	srv, stop := remoteSession(t)
+	defer stop()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	fmt.Fprintf(conn, "GET /api/ws?token=123456 HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n", srv.Listener.Addr())
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// La respuesta del ejemplo de la RFC 6455
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("handshake: %s %v", resp.Status, resp.Header)
+	}
+
+	if state := readState(t, r); state.Slide != 1 {
+		t.Errorf("estado inicial %+v", state)
+	}
+	conn.Write(wsFrame("next"))
+	if state := readState(t, r); state.Slide != 2 || state.Title != "Dos" {
+		t.Errorf("después de next por el WebSocket: %+v", state)
+	}
+	// Lo que cambia por otro lado también llega
+	remoteCall(t, srv, "POST", "/api/goto/3")
+	if state := readState(t, r); state.Slide != 3 {
+		t.Errorf("después de goto por HTTP: %+v", state)
+	}
+	remoteCall(t, srv, "POST", "/api/blank")
+	if state := readState(t, r); !state.Blank {
+		t.Errorf("después de blank por HTTP: %+v", state)
+	}
+}
+
+func TestPresenterViewFitsTerminal(t *testing.T) {
+	defer setSlideSize(projectorWidth, projectorHeight)
+	d, err := loadDeck("deck.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range [][2]int{{80, 24}, {120, 40}, {200, 50}} {
+		m := initialModel()
+		m.deck, m.presenter = d, true
+		m.started, m.now, m.talk = time.Now(), time.Now(), 10*time.Minute
+		m.notes[0] = strings.Repeat("Una nota larga que ocupa varias líneas. ", 40)
+		next, _ := m.Update(tea.WindowSizeMsg{Width: size[0], Height: size[1]})
+		m = next.(model)
+		if m.tooSmall() {
+			t.Errorf("%dx%d: se considera chica", size[0], size[1])
+			continue
+		}
+		view := m.View()
+		if w, h := lipgloss.Width(view), lipgloss.Height(view); w != size[0] || h != size[1] {
+			t.Errorf("%dx%d: la vista del presentador mide %dx%d", size[0], size[1], w, h)
+		}
+	}
+
+	m := initialModel()
+	m.deck, m.presenter = d, true
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 45, Height: 30})
+	if m = next.(model); !m.tooSmall() {
+		t.Error("45 columnas no alcanzan para el slide y la miniatura")
+	}
+}
+
+func TestDiagramParseErrors(t *testing.T) {
+	tests := []struct {
+		meta map[string]string
+		body string
+		want string
+	}{
+		{nil, "", "no tiene cajas"},
+		{nil, "# sólo un comentario\n```\n```", "no tiene cajas"},
+		{nil, "a -> b\na -> a", "línea 2: la flecha de \"a\" vuelve a la misma caja"},
+		{nil, "a -> b -> b", "vuelve a la misma caja"},
+		{nil, "a -> -> b", "línea 1: falta el nombre de una caja"},
+		{nil, ": Sin nombre", "falta el nombre de una caja"},
+		{nil, "direction: up\na -> b", "dirección desconocida \"up\""},
+		{map[string]string{"direction": "left"}, "a -> b", "dirección desconocida \"left\""},
+		{map[string]string{"reveal": "boxes"}, "a -> b", "reveal desconocido \"boxes\""},
+	}
+	for _, tt := range tests {
+		_, err := newDiagramSlide(tt.meta, tt.body)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: error %v, se esperaba %q", tt.body, err, tt.want)
+		}
+	}
+}
+
+func TestDiagramParse(t *testing.T) {
+	d, err := newDiagramSlide(map[string]string{"title": "Flujo"}, "```\ndirection: right\n# cajas\nweb: Navegador\nweb -> api -> db: SQL\napi -> cache\n```")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.right || d.title != "Flujo" {
+		t.Errorf("right %v, título %q", d.right, d.title)
+	}
+	var labels []string
+	for _, n := range d.nodes {
+		labels = append(labels, n.id+"="+n.label)
+	}
+	if want := []string{"web=Navegador", "api=api", "db=db", "cache=cache"}; !slices.Equal(labels, want) {
+		t.Errorf("cajas %v, se esperaba %v", labels, want)
+	}
+	// La etiqueta es de la última flecha de la cadena
+	want := []diagramEdge{{0, 1, ""}, {1, 2, "SQL"}, {1, 3, ""}}
+	if !slices.Equal(d.edges, want) {
+		t.Errorf("flechas %v, se esperaba %v", d.edges, want)
+	}
+}
+
+func TestDiagramRanks(t *testing.T) {
+	tests := []struct {
+		name, body string
+		ranks      []int
+	}{
+		{"cadena", "a -> b -> c", []int{0, 1, 2}},
+		// La flecha que cierra el ciclo se cuenta al revés
+		{"ciclo", "a -> b -> c -> a", []int{0, 1, 2}},
+		{"abanico", "a -> b\na -> c\na -> d", []int{0, 1, 1, 1}},
+		{"rombo", "a -> b -> d\na -> c -> d", []int{0, 1, 2, 1}},
+		// El camino más largo manda
+		{"atajo", "a -> b -> c\na -> c", []int{0, 1, 2}},
+		{"sueltas", "a\nb -> c", []int{0, 0, 1}},
+	}
+	for _, tt := range tests {
+		d, err := newDiagramSlide(nil, tt.body)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !slices.Equal(d.ranks, tt.ranks) {
+			t.Errorf("%s: capas %v, se esperaba %v", tt.name, d.ranks, tt.ranks)
+		}
+		for r, layer := range d.layers {
+			for _, n := range layer {
+				if d.ranks[n] != r {
+					t.Errorf("%s: la caja %s está en la capa %d y tiene rango %d", tt.name, d.nodes[n].id, r, d.ranks[n])
+				}
+			}
+		}
+	}
+
+	// Las cajas de cada capa siguen el orden de sus vecinas, para que las
+	// flechas no se crucen
+	d, err := newDiagramSlide(nil, "r -> a\nr -> b\na -> a2\nb -> b2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last []string
+	for _, n := range d.layers[2] {
+		last = append(last, d.nodes[n].id)
+	}
+	if !slices.Equal(last, []string{"a2", "b2"}) {
+		t.Errorf("última capa %v, se esperaba [a2 b2]", last)
+	}
+}
+
+// inBox dice si p cae en b, con el borde incluido
+func inBox(p diagramPoint, b diagramBox) bool {
+	return p.x >= b.x && p.x < b.x+b.w && p.y >= b.y && p.y < b.y+b.h
+}
+
+func TestDiagramRoutes(t *testing.T) {
+	bodies := []string{
+		"a -> b -> c",
+		"a -> b\na -> c\na -> d: tres",
+		"a -> b -> c -> d\na -> d: salto",
+		"a -> b -> c -> a: vuelve",
+	}
+	for _, body := range bodies {
+		for _, direction := range []string{"down", "right"} {
+			d, err := newDiagramSlide(map[string]string{"direction": direction}, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			l, ok := d.layout(100, 40)
+			if !ok || l.right != d.right {
+				t.Fatalf("%q %s: no entra en 100x40 (%dx%d)", body, direction, l.width, l.height)
+			}
+			for i, route := range l.routes {
+				e := d.edges[i]
+				from, to := l.boxes[e.from], l.boxes[e.to]
+				if !inBox(route[0], from) || !inBox(route[len(route)-1], to) {
+					t.Errorf("%q %s: la flecha %d no va de %s a %s: %v", body, direction, i, d.nodes[e.from].id, d.nodes[e.to].id, route)
+					continue
+				}
+				for k := 1; k < len(route); k++ {
+					if dx, dy := route[k].x-route[k-1].x, route[k].y-route[k-1].y; dx*dx+dy*dy != 1 {
+						t.Errorf("%q %s: la flecha %d salta de %v a %v", body, direction, i, route[k-1], route[k])
+					}
+				}
+				// Entre las puntas la flecha no pisa ninguna caja
+				for _, p := range route[1 : len(route)-1] {
+					for n, b := range l.boxes {
+						if inBox(p, b) {
+							t.Errorf("%q %s: la flecha %d pasa por la caja %s en %v", body, direction, i, d.nodes[n].id, p)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestDiagramOverflow(t *testing.T) {
+	defer setSlideSize(projectorWidth, projectorHeight)
+	setSlideSize(80, 24)
+
+	// Siete cajas en una capa no entran una debajo de la otra, pero sí una
+	// al lado de la otra
+	d, err := newDiagramSlide(map[string]string{"direction": "right"}, "a -> b\na -> c\na -> d\na -> e\na -> f\na -> g\na -> h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, ok := d.layout(slideWidth-4, slideHeight-2)
+	if !ok || l.right {
+		t.Errorf("entra %v, de izquierda a derecha %v; se esperaba que se diera vuelta", ok, l.right)
+	}
+
+	// Once capas no entran en ninguna dirección: se avisa en vez de cortar
+	// las cajas
+	d, err = newDiagramSlide(nil, "a -> b -> c -> d -> e -> f -> g -> h -> i -> j -> k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range testSizes {
+		setSlideSize(size[0], size[1])
+		if _, ok := d.layout(slideWidth-4, slideHeight-2); ok {
+			t.Errorf("%dx%d: once capas entran", size[0], size[1])
+			continue
+		}
+		view := plainRows(stillView(d))
+		if !strings.Contains(strings.Join(strings.Fields(view), " "), "no entra") || strings.Contains(view, "┌") {
+			t.Errorf("%dx%d: falta el aviso:\n%s", size[0], size[1], view)
+		}
+		if w, h := lipgloss.Width(view), len(strings.Split(stillView(d), "\n")); w != size[0]+2 || h != size[1]+2 {
+			t.Errorf("%dx%d: el aviso mide %dx%d", size[0], size[1], w, h)
+		}
+	}
+}
+
+func TestDiagramRevealEdges(t *testing.T) {
+	d, err := newDiagramSlide(map[string]string{"reveal": "edges"}, "a -> b\na -> c\na -> d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := d.fragments(); n != 3 {
+		t.Fatalf("%d fragmentos, se esperaban 3", n)
+	}
+	tips := func() int { return strings.Count(plainRows(d.View()), "▼") }
+	for n := 0; n <= 3; n++ {
+		d.showFragments(n)
+		for d.animating {
+			d.Update(tickMsg{})
+		}
+		if got := tips(); got != n {
+			t.Errorf("con %d flechas a la vista se dibujaron %d puntas", n, got)
+		}
+	}
+	// Volver atrás no anima: la flecha desaparece de una vez
+	if d.showFragments(1); d.animating || tips() != 1 {
+		t.Errorf("al volver atrás: animando %v, %d puntas", d.animating, tips())
+	}
+}
+
+// plainLines son las líneas sin estilos
+func plainLines(lines []string) []string {
+	if len(lines) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(plainRows(strings.Join(lines, "\n")), "\n"), "\n")
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name, src string
+		width     int
+		title     string
+		lines     []string
+	}{
+		{"título", "# Título\n\nTexto", 30, "Título", []string{"Texto"}},
+		{"segundo título", "# Uno\n# Dos\n### Tres", 30, "Uno", []string{"Dos", "Tres"}},
+		{"párrafos", "uno\ndos\n\n\ntres\n\n", 30, "", []string{"uno dos", "", "tres"}},
+		{"énfasis", "**negrita**, *cursiva*, _otra_ y `código`", 40, "", []string{"negrita, cursiva, otra y código"}},
+		{"corte", "una frase que no entra", 10, "", []string{"una frase", "que no", "entra"}},
+		{"listas", "- a\n  - b\n* c\n+ d\n1. e", 30, "", []string{"• a", "  • b", "• c", "• d", "1. e"}},
+		{"lista larga", "- palabra palabra", 10, "", []string{"• palabra", "  palabra"}},
+		{"cita", "> una cita larga", 10, "", []string{"│ una cita", "│ larga"}},
+		{"tabla", "| a | bb |\n|---|:-:|\n| ccc | d |", 30, "", []string{"a   │ bb", "────┼───", "ccc │ d "}},
+		{"tabla angosta", "| nombre | valor |\n| largo | 1 |", 12, "", []string{"nom… │ valor", "─────┼──────", "lar… │ 1    "}},
+		{"código", "texto\n```go\nx\t:= 1\n# no es título\n```", 10, "", []string{"texto", "x    := 1 ", "# no es t…"}},
+		{"código largo", "```\n1234567890abc\n```", 8, "", []string{"1234567…"}},
+		{"código sin cerrar", "antes\n```\nuno\ndos", 6, "", []string{"antes", "uno   ", "dos   "}},
+	}
+	for _, tt := range tests {
+		title, lines := renderMarkdown(tt.src, tt.width)
+		if got := plainLines(lines); title != tt.title || !slices.Equal(got, tt.lines) {
+			t.Errorf("%s: título %q y líneas %q, se esperaba %q y %q", tt.name, title, got, tt.title, tt.lines)
+		}
+		for _, l := range lines {
+			if w := lipgloss.Width(l); w > tt.width {
+				t.Errorf("%s: la línea %q mide %d, más que %d", tt.name, l, w, tt.width)
+			}
+		}
+	}
+}
+
+func TestParseInline(t *testing.T) {
+	type styled struct {
+		text         string
+		bold, italic bool
+		code         bool
+	}
+	tests := []struct {
+		text string
+		want []styled
+	}{
+		{"**a** b", []styled{{"a", true, false, false}, {" b", false, false, false}}},
+		{"*a* _b_", []styled{{"a", false, true, false}, {" ", false, false, false}, {"b", false, true, false}}},
+		{"***a***", []styled{{"a", true, true, false}}},
+		// Dentro del código no hay énfasis, y un "_" dentro de una palabra
+		// es parte de ella
+		{"`*x*` snake_case", []styled{{"*x*", false, false, true}, {" snake_case", false, false, false}}},
+	}
+	for _, tt := range tests {
+		var got []styled
+		for _, sp := range parseInline(tt.text, lipgloss.NewStyle()) {
+			code := sp.style.GetBackground() == codeStyle.GetBackground() && sp.style.GetForeground() == codeStyle.GetForeground()
+			got = append(got, styled{sp.text, sp.style.GetBold(), sp.style.GetItalic(), code})
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("parseInline(%q) = %+v, se esperaba %+v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSplitFrontMatter(t *testing.T) {
+	tests := []struct {
+		chunk string
+		meta  map[string]string
+		body  string
+		err   string
+	}{
+		{"# Sin metadatos", map[string]string{}, "# Sin metadatos", ""},
+		{"\n\n+++\nKind: chart\n# un comentario\n\ntitle:  Ventas: 2024 \n+++\ncuerpo", map[string]string{"kind": "chart", "title": "Ventas: 2024"}, "cuerpo", ""},
+		{"+++\n+++", map[string]string{}, "", ""},
+		{"+++\nkind: chart\n", nil, "", "falta el cierre"},
+		{"+++\nsin dos puntos\n+++", nil, "", "metadato inválido \"sin dos puntos\""},
+	}
+	for _, tt := range tests {
+		meta, body, err := splitFrontMatter(tt.chunk)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: error %v, se esperaba %q", tt.chunk, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || body != tt.body || fmt.Sprint(meta) != fmt.Sprint(tt.meta) {
+			t.Errorf("%q: %v, %q, %v; se esperaba %v, %q", tt.chunk, meta, body, err, tt.meta, tt.body)
+		}
+	}
+}
+
+// writeDeck guarda un deck en una carpeta temporal y devuelve su ruta
+func writeDeck(t *testing.T, doc string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "deck.md")
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDeckKinds(t *testing.T) {
+	doc := strings.Join([]string{
+		"+++\ntransition: wipe\nanimation: freeze\n+++",
+		"# Markdown\n\n```\n---\n```\n???\nnotas del slide",
+		"+++\nkind: markdown\nanimation: replay\n+++\ntexto",
+		"+++\nkind: chart\nvalues: 1, 2\nlabels: a, b\n+++",
+		"+++\nkind: line\nvalues: 1, 2\n+++",
+		"+++\nkind: area\nvalues: 1, 2\n+++",
+		"+++\nkind: sparkline\nvalues: 1, 2\n+++",
+		"+++\nkind: pie\nvalues: 1, 2\nlabels: a, b\n+++",
+		"+++\nkind: donut\nvalues: 1, 2\nlabels: a, b\n+++",
+		"+++\nkind: particles\n+++",
+		"+++\nkind: code\nlanguage: go\n+++\n```go\nfunc main() {}\n```",
+		"+++\nkind: diagram\n+++\na -> b",
+		"+++\nkind: command\ncommand: echo hola\n+++",
+	}, "\n---\n")
+	d, err := loadDeck(writeDeck(t, doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []string
+	for _, s := range d.slides {
+		kinds = append(kinds, strings.TrimPrefix(fmt.Sprintf("%T", s), "*main."))
+	}
+	want := []string{"markdownSlide", "markdownSlide", "barChartSlide", "lineChartSlide", "lineChartSlide", "lineChartSlide", "pieSlide", "pieSlide", "particleSlide", "codeSlide", "diagramSlide", "commandSlide"}
+	if !slices.Equal(kinds, want) {
+		t.Errorf("tipos %v, se esperaba %v", kinds, want)
+	}
+	// El primer bloque sin cuerpo son los valores por defecto del deck y
+	// un "---" dentro de un bloque de código no corta el slide
+	if d.transitions[0].kind != "wipe" || !d.freeze[0] || d.freeze[1] {
+		t.Errorf("transición %q, animación congelada %v", d.transitions[0].kind, d.freeze[:2])
+	}
+	if d.notes[0] != "notas del slide" || !strings.Contains(d.slides[0].(*markdownSlide).source, "---") {
+		t.Errorf("notas %q, fuente %q", d.notes[0], d.slides[0].(*markdownSlide).source)
+	}
+}
+
+func TestLoadDeckErrors(t *testing.T) {
+	tests := []struct{ doc, want string }{
+		{"", "el deck no tiene slides"},
+		{"---\n\n---", "el deck no tiene slides"},
+		{"uno\n---\n+++\nkind: video\n+++", "slide 2: tipo de slide desconocido \"video\""},
+		{"+++\nkind: chart\nvalues: 1\n", "slide 1: falta el cierre"},
+		{"+++\nanimation: pausa\n+++\ntexto", "animación desconocida \"pausa\""},
+		{"+++\nadvance: pronto\n+++\ntexto", "avance \"pronto\" inválido"},
+		{"+++\nkind: line\nvalues: 1, dos\n+++", "valor inválido \"dos\""},
+	}
+	for _, tt := range tests {
+		_, err := loadDeck(writeDeck(t, tt.doc))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: error %v, se esperaba %q", tt.doc, err, tt.want)
+		}
+	}
+}
+
+func TestSyncRelaysNavigation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deck.md")
+	if err := os.WriteFile(path, []byte("# Uno\n---\n# Dos\n---\n# Tres\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	socket := filepath.Join(t.TempDir(), "slides.sock")
+	link, err := listenSync(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	presenter, presenterMsgs := syncSession(t, path, link)
+	presenter, a, aMsgs := joinSync(t, socket, path, presenter, presenterMsgs)
+	presenter, b, bMsgs := joinSync(t, socket, path, presenter, presenterMsgs)
+	key := func(m model, k string) model {
+		m, _ = m.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		return m
+	}
+
+	// Lo que hace una audiencia llega al presentador, y él lo reenvía a
+	// las demás
+	a, _ = a.update(tea.KeyMsg{Type: tea.KeyRight})
+	presenter = pump(t, presenter, presenterMsgs, func(m model) bool { return m.currentIdx == 1 })
+	b = pump(t, b, bMsgs, func(m model) bool { return m.currentIdx == 1 })
+
+	presenter, _ = presenter.navigate(2)
+	a = pump(t, a, aMsgs, func(m model) bool { return m.currentIdx == 2 })
+	b = pump(t, b, bMsgs, func(m model) bool { return m.currentIdx == 2 })
+
+	presenter = key(presenter, "b")
+	a = pump(t, a, aMsgs, func(m model) bool { return m.blank })
+	b = pump(t, b, bMsgs, func(m model) bool { return m.blank })
+
+	b = key(b, "b")
+	presenter = pump(t, presenter, presenterMsgs, func(m model) bool { return !m.blank })
+	pump(t, a, aMsgs, func(m model) bool { return !m.blank })
+
+	// Un slide que no existe en el deck de la audiencia se ignora
+	if b, _ = b.update(syncMsg{idx: 7}); b.currentIdx != 2 {
+		t.Errorf("un goto fuera del deck llevó al slide %d", b.currentIdx+1)
+	}
+}
+
+func TestPace(t *testing.T) {
+	m := initialModel()
+	m.slides = make([]slide, 10)
+	m.talk = 10 * time.Minute
+	tests := []struct {
+		idx     int
+		elapsed time.Duration
+		want    string
+	}{
+		{0, 0, "en ritmo"},
+		{0, 2 * time.Minute, "en ritmo"},
+		{0, 3 * time.Minute, "atrasado"},
+		{2, 2 * time.Minute, "en ritmo"},
+		{3, 2 * time.Minute, "adelantado"},
+		{5, time.Minute, "adelantado"},
+		{4, 5 * time.Minute, "en ritmo"},
+		{1, 5 * time.Minute, "atrasado"},
+		// Pasado el tiempo de la charla, aun en el último slide
+		{9, 12 * time.Minute, "atrasado"},
+	}
+	for _, tt := range tests {
+		m.currentIdx = tt.idx
+		if got := plainRows(m.pace(tt.elapsed)); !strings.Contains(got, tt.want) {
+			t.Errorf("slide %d a los %v: %q, se esperaba %q", tt.idx+1, tt.elapsed, strings.TrimSpace(got), tt.want)
+		}
+	}
+}
+
+func TestPresenterPreviewIsStill(t *testing.T) {
+	defer setSlideSize(projectorWidth, projectorHeight)
+	d, err := loadDeck(filepath.Join("testdata", "charts.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := initialModel()
+	m.deck, m.presenter = d, true
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 160, Height: 50})
+	m = next.(model)
+
+	// El siguiente slide todavía no empezó su animación, pero la miniatura
+	// lo muestra terminado
+	d.slides[1].(resetter).reset()
+	view := plainRows(m.presenterView())
+	for _, row := range strings.Split(plainRows(thumbnail(stillView(d.slides[1]), 2)), "\n") {
+		if row = strings.TrimSpace(row); row != "" && !strings.Contains(view, row) {
+			t.Fatalf("la miniatura no tiene la fila %q:\n%s", row, view)
+		}
+	}
+}
+
+func TestParseSimpleYAML(t *testing.T) {
+	tests := []struct{ name, src, want string }{
+		{"vacío", "", `null`},
+		{"sólo comentarios", "# nada\n\n   # indentado\n", `null`},
+		{"mapa", "a: uno\nb: dos", `{"a":"uno","b":"dos"}`},
+		{"clave sin valor", "a:", `{"a":null}`},
+		{"mapas anidados", "end:\n  title: Fin\n  extra:\n    x: 1\nnext: 2", `{"end":{"extra":{"x":"1"},"title":"Fin"},"next":"2"}`},
+		{"lista indentada", "names:\n  - Ana\n  - Beto", `{"names":["Ana","Beto"]}`},
+		{"lista a la altura de la clave", "names:\n- Ana\n- Beto\nrole: Equipo", `{"names":["Ana","Beto"],"role":"Equipo"}`},
+		{"lista en línea", "names: [Ana, \"Beto, hijo\", 'Cata, ''la'' jefa', ]", `{"names":["Ana","Beto, hijo","Cata, 'la' jefa"]}`},
+		{"lista en la raíz", "- a\n- b", `["a","b"]`},
+		{"comillas escapadas en una lista", `q: ["dijo \"sí, claro\"", b]`, `{"q":["dijo \"sí, claro\"","b"]}`},
+		{"lista de mapas", "sections:\n  - title: Equipo\n    roles:\n      - role: Dirección\n        names: [Ana]\n      - names:\n          - Beto\n  - title: Otra", `{"sections":[{"roles":[{"names":["Ana"],"role":"Dirección"},{"names":["Beto"]}],"title":"Equipo"},{"title":"Otra"}]}`},
+		{"elemento en la línea siguiente", "items:\n  -\n    a: b\n  -\n    - c", `{"items":[{"a":"b"},["c"]]}`},
+		{"comillas dobles", `a: "uno: dos # sigue"` + "\n" + `b: "\u00e9\t"`, `{"a":"uno: dos # sigue","b":"é\t"}`},
+		{"comillas simples", "a: 'it''s # sigue'\nb: ''", `{"a":"it's # sigue","b":""}`},
+		{"comentarios", "# cabecera\n\na: texto # nota\n  # entre claves\nb: c#no", `{"a":"texto","b":"c#no"}`},
+		{"dos puntos sin espacio", "url: https://example.com", `{"url":"https://example.com"}`},
+		{"CRLF", "a: b\r\nc: d\r\n", `{"a":"b","c":"d"}`},
+		{"bloque literal", "logo: |\n   /\\_/\\\n  ( o.o )\n\n   > ^ <\n\nnext: x", `{"logo":" /\\_/\\\n( o.o )\n\n \u003e ^ \u003c","next":"x"}`},
+		{"bloque sin salto final", "logo: |-\n    # no es comentario\n      ##\nnext: x", `{"logo":"# no es comentario\n  ##","next":"x"}`},
+		{"bloque al final", "logo: |\n  ***\n", `{"logo":"***"}`},
+		{"bloque en una lista", "- logo: |\n    /\\\n    \\/\n  title: Rombo", `[{"logo":"/\\\n\\/","title":"Rombo"}]`},
+	}
+	for _, tt := range tests {
+		value, err := parseSimpleYAML(tt.src)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		got, err := json.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s:\n%s\nse esperaba\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseSimpleYAMLErrors(t *testing.T) {
+	tests := []struct{ src, want string }{
+		{"a: b\n   c: d", "línea 2: se esperaba \"clave: valor\""},
+		{"a: b\nsolo texto", "línea 2: se esperaba \"clave: valor\""},
+		{"\"a\": b", "línea 1: se esperaba \"clave: valor\""},
+		{"a:\n  b: c\n d: e", "línea 3: se esperaba \"clave: valor\""},
+		{"- a\n  - b", "línea 2: se esperaba un elemento de la lista"},
+		{"- a\nb: c", "línea 2: indentación inesperada"},
+		{"names:\n  - a\nroles:\n  - b\n    c", "línea 5: se esperaba un elemento de la lista"},
+	}
+	for _, tt := range tests {
+		value, err := parseSimpleYAML(tt.src)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%q: %v (%v), se esperaba %q", tt.src, err, value, tt.want)
+		}
+	}
+}
+
+func TestLoadCreditsYAMLMatchesJSON(t *testing.T) {
+	dir := t.TempDir()
+	yaml := "sections:\n  - logo: |\n      /\\\n      \\/\n    title: Equipo\n    roles:\n      - role: Dirección\n        names: [Ana]\nend:\n  title: Gracias\n  lines:\n    - 'por venir'\n"
+	json := `{"sections": [{"logo": "/\\\n\\/", "title": "Equipo", "roles": [{"role": "Dirección", "names": ["Ana"]}]}],
+		"end": {"title": "Gracias", "lines": ["por venir"]}}`
+	for name, data := range map[string]string{"creditos.yaml": yaml, "creditos.json": json} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fromYAML, err := loadCredits(filepath.Join(dir, "creditos.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := loadCredits(filepath.Join(dir, "creditos.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(fromYAML) != fmt.Sprint(fromJSON) {
+		t.Errorf("YAML y JSON dan créditos distintos:\n%+v\n%+v", fromYAML, fromJSON)
+	}
+
+	// Un error del YAML dice en qué archivo está
+	if err := os.WriteFile(filepath.Join(dir, "roto.yml"), []byte("a: b\n  c"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadCredits(filepath.Join(dir, "roto.yml")); err == nil || !strings.Contains(err.Error(), "roto.yml: línea 2") {
+		t.Errorf("error %v", err)
+	}
+}
+
+func TestParseShortlog(t *testing.T) {
+	tests := []struct {
+		name, out string
+		want      []string
+	}{
+		{"resumen", "    42\tAna Pérez\n     3\tBeto\n", []string{"Ana Pérez", "Beto"}},
+		{"con correo", "    42\tAna Pérez <ana@example.com>\n", []string{"Ana Pérez"}},
+		{"completo", "Ana Pérez (2):\n      Primer commit\n      Otro (con paréntesis):\n\nBeto <b@example.com> (1):\n      Arreglo\n", []string{"Ana Pérez", "Beto"}},
+		{"vacío", "", nil},
+	}
+	for _, tt := range tests {
+		if got := parseShortlog(tt.out); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: %q, se esperaba %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGitCredits(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("no está git")
+	}
+	repo := filepath.Join(t.TempDir(), "proyecto")
+	git := func(author string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME="+author, "GIT_AUTHOR_EMAIL=a@example.com",
+			"GIT_COMMITTER_NAME="+author, "GIT_COMMITTER_EMAIL=a@example.com",
+			"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_SYSTEM=/dev/null")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	if err := os.MkdirAll(repo, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	git("", "init", "-q")
+
+	if _, err := gitCredits(repo); err == nil || !strings.Contains(err.Error(), "git shortlog en") {
+		t.Errorf("un repositorio sin commits dio %v", err)
+	}
+
+	for _, author := range []string{"Beto", "Ana", "Ana"} {
+		git(author, "commit", "-q", "--allow-empty", "-m", "cambio")
+	}
+	roll, err := gitCredits(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := roll.Sections[0].Roles[0].Names; !slices.Equal(names, []string{"Ana", "Beto"}) {
+		t.Errorf("autores %q, se esperaba de más a menos commits", names)
+	}
+	if roll.End.Title != "Fin" || !slices.Equal(roll.End.Lines, []string{"proyecto"}) {
+		t.Errorf("tarjeta final %+v", roll.End)
+	}
+}
+
+func TestCreditsEndCard(t *testing.T) {
+	defer setSlideSize(projectorWidth, projectorHeight)
+	for _, size := range testSizes {
+		setSlideSize(size[0], size[1])
+		for _, tt := range []struct {
+			end  creditEnd
+			want []string
+		}{
+			{creditEnd{}, []string{"F I N"}},
+			{creditEnd{Title: "Gracias", Lines: []string{"por venir", "hasta la próxima"}}, []string{"G R A C I A S", "por venir", "hasta la próxima"}},
+		} {
+			roll := creditsRoll{Sections: []creditSection{{Roles: []creditRole{{Role: "Dirección", Names: []string{"Ana"}}}}}, End: tt.end}
+			c := &creditsSlide{roll: roll, lines: roll.lines(), speed: 1000}
+			for i := 0; !c.showAll; i++ {
+				if i > 1000 {
+					t.Fatal("los créditos no terminan")
+				}
+				c.Update(tickMsg{})
+			}
+			if done, _ := c.complete(); !done {
+				t.Error("los créditos terminaron sin completarse")
+			}
+			view := plainRows(c.View())
+			for _, want := range tt.want {
+				if !strings.Contains(view, want) {
+					t.Errorf("%dx%d: la tarjeta final no dice %q:\n%s", size[0], size[1], want, view)
+				}
+			}
+			if strings.Contains(view, "Ana") {
+				t.Errorf("%dx%d: la lista sigue a la vista en la tarjeta final", size[0], size[1])
+			}
+			if h := len(strings.Split(c.View(), "\n")); h != size[1]+2 {
+				t.Errorf("%dx%d: la tarjeta final mide %d filas", size[0], size[1], h)
+			}
+		}
+	}
+}
+
+func TestCodeSlideSteps(t *testing.T) {
+	body := "```go\na\nb\nc\nd\n```"
+	c, err := newCodeSlide(map[string]string{"steps": "1, 2-3, 4-4"}, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][2]int{{1, 1}, {2, 3}, {4, 4}}; !slices.Equal(c.steps, want) || c.lang != "go" {
+		t.Errorf("pasos %v en %q, se esperaba %v en go", c.steps, c.lang, want)
+	}
+
+	tests := []struct{ steps, want string }{
+		{"0", "paso inválido \"0\""},
+		{"3-2", "paso inválido \"3-2\""},
+		{"uno", "paso inválido \"uno\""},
+		{"2-", "paso inválido \"2-\""},
+		{"5", "el paso \"5\" pasa la última línea (el código tiene 4)"},
+		{"1, 3-9", "el paso \"3-9\" pasa la última línea (el código tiene 4)"},
+	}
+	for _, tt := range tests {
+		if _, err := newCodeSlide(map[string]string{"steps": tt.steps}, body); err == nil || err.Error() != tt.want {
+			t.Errorf("steps %q: %v, se esperaba %q", tt.steps, err, tt.want)
+		}
+	}
+	if _, err := newCodeSlide(map[string]string{"lang": "cobol"}, "x"); err == nil {
+		t.Error("un lenguaje desconocido no dio error")
+	}
+}
+
+// tokens resume lo que marcó el resaltador: cada trozo con la inicial de
+// su estilo, juntando los vecinos del mismo estilo
+func tokens(spans []span) []string {
+	classes := map[string]string{
+		fmt.Sprint(plainStyle.GetForeground()):    "p",
+		fmt.Sprint(keywordStyle.GetForeground()):  "k",
+		fmt.Sprint(stringStyle.GetForeground()):   "s",
+		fmt.Sprint(commentStyle.GetForeground()):  "c",
+		fmt.Sprint(numberStyle.GetForeground()):   "n",
+		fmt.Sprint(typeStyle.GetForeground()):     "t",
+		fmt.Sprint(variableStyle.GetForeground()): "v",
+	}
+	var out []string
+	last := ""
+	for _, sp := range spans {
+		class := classes[fmt.Sprint(sp.style.GetForeground())]
+		if class == last {
+			out[len(out)-1] += sp.text
+			continue
+		}
+		out = append(out, class+":"+sp.text)
+		last = class
+	}
+	return out
+}
+
+func TestHighlightLine(t *testing.T) {
+	tests := []struct {
+		lang, line string
+		want       []string
+	}{
+		{"go", `func f(s string) int { return 42 } // fin`, []string{"k:func", "p: f(s ", "t:string", "p:) ", "t:int", "p: { ", "k:return", "p: ", "n:42", "p: } ", "c:// fin"}},
+		{"go", `x := "a\"b" + 'c' + ` + "`\\d`", []string{"p:x := ", `s:"a\"b"`, "p: + ", "s:'c'", "p: + ", "s:`\\d`"}},
+		{"go", `y := 1.5e3 /* nota */ - -2`, []string{"p:y := ", "n:1.5e3", "p: ", "c:/* nota */", "p: - ", "n:-2"}},
+		{"go", `"sin cerrar`, []string{`s:"sin cerrar`}},
+		{"sh", `echo $HOME ${A:-x} $1 mi-cmd # nota`, []string{"t:echo", "p: ", "v:$HOME", "p: ", "v:${A:-x}", "p: ", "v:$1", "p: mi-cmd ", "c:# nota"}},
+		{"sh", `if [ "$x" ]; then a#b; fi`, []string{"k:if", "p: [ ", `s:"$x"`, "p: ]; ", "k:then", "p: a#b; ", "k:fi"}},
+		{"yaml", `- name: "x" # c`, []string{"p:- ", "t:name", "p:: ", `s:"x"`, "p: ", "c:# c"}},
+		{"yaml", `  retries: -3`, []string{"p:  ", "t:retries", "p:: ", "n:-3"}},
+		{"yaml", `on: true`, []string{"t:on", "p:: ", "k:true"}},
+		{"json", `{"a": "b", "n": 1.5, "ok": null}`, []string{"p:{", `t:"a"`, "p:: ", `s:"b"`, "p:, ", `t:"n"`, "p:: ", "n:1.5", "p:, ", `t:"ok"`, "p:: ", "k:null", "p:}"}},
+		{"", `func 1 "x" # y`, []string{"p:func ", "n:1", `p: "x" # y`}},
+	}
+	for _, tt := range tests {
+		got := tokens(highlightLine(tt.lang, tt.line, &lexState{}))
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s %q:\n%q\nse esperaba\n%q", tt.lang, tt.line, got, tt.want)
+		}
+	}
+
+	// Un comentario de bloque sigue en las líneas siguientes
+	state := lexState{}
+	var got []string
+	for _, line := range []string{"a /* uno", "dos", "tres */ b"} {
+		got = append(got, strings.Join(tokens(highlightLine("go", line, &state)), "|"))
+	}
+	if want := []string{"p:a |c:/* uno", "c:dos", "c:tres */|p: b"}; !slices.Equal(got, want) || state.inComment {
+		t.Errorf("comentario de bloque: %q, se esperaba %q (abierto %v)", got, want, state.inComment)
+	}
+}
+
+func TestCodeSlideScrolls(t *testing.T) {
+	defer setSlideSize(projectorWidth, projectorHeight)
+	setSlideSize(minSlideWidth, minSlideHeight)
+	var lines []string
+	for i := 1; i <= 30; i++ {
+		lines = append(lines, fmt.Sprintf("linea%d", i))
+	}
+	c, err := newCodeSlide(map[string]string{"steps": "2, 25-27, 29-30"}, strings.Join(lines, "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	visible := c.visibleLines()
+	key := func(k string) { c.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}) }
+	shown := func(n int) bool { return strings.Contains(plainRows(c.View()), fmt.Sprintf("linea%d ", n)) }
+
+	for range 50 {
+		key("j")
+	}
+	if c.offset != 30-visible || !shown(30) || shown(30-visible) {
+		t.Errorf("abajo de todo: desplazamiento %d, se esperaba %d", c.offset, 30-visible)
+	}
+	for range 50 {
+		key("k")
+	}
+	if c.offset != 0 || !shown(1) {
+		t.Errorf("arriba de todo: desplazamiento %d", c.offset)
+	}
+
+	// Cada paso deja su rango a la vista
+	for _, r := range [][2]int{{2, 2}, {25, 27}, {29, 30}} {
+		if !c.next() {
+			t.Fatal("no hay más pasos")
+		}
+		if !shown(r[0]) || !shown(r[1]) {
+			t.Errorf("el paso %d-%d no está a la vista (desplazamiento %d)", r[0], r[1], c.offset)
+		}
+	}
+	if c.next() {
+		t.Error("hubo un paso de más")
+	}
+	for c.prev() {
+	}
+	if c.step != 0 || !shown(c.offset+1) {
+		t.Errorf("al volver: paso %d", c.step)
+	}
+}
+
+func TestCodeSlideTypewriter(t *testing.T) {
+	c, err := newCodeSlide(map[string]string{"typewriter": "true"}, "ab\ncd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Las líneas de código que se ven, sin el número ni el marco
+	gutter := regexp.MustCompile(`\d │ (.*?) *│?$`)
+	view := func() string {
+		var code []string
+		for _, row := range strings.Split(plainRows(c.View()), "\n") {
+			if m := gutter.FindStringSubmatch(strings.TrimRight(row, " ")); m != nil {
+				code = append(code, m[1])
+			}
+		}
+		return strings.Join(code, "/")
+	}
+
+	// Total: 2 caracteres y el salto por línea; cada tick escribe dos
+	if c.total != 6 || c.revealed != 0 || c.Init() == nil {
+		t.Fatalf("total %d, escritos %d", c.total, c.revealed)
+	}
+	want := []string{"ab▌", "ab/c▌", "ab/cd"}
+	for i, w := range want {
+		c.Update(tickMsg{})
+		if got := view(); got != w {
+			t.Errorf("tick %d: %q, se esperaba %q", i+1, got, w)
+		}
+		if done, has := c.complete(); done != (i == len(want)-1) || !has {
+			t.Errorf("tick %d: completo %v", i+1, done)
+		}
+	}
+	if _, cmd := c.Update(tickMsg{}); cmd != nil {
+		t.Error("siguió escribiendo después del final")
+	}
+	if c.reset(); c.revealed != 0 || view() != "▌" {
+		t.Errorf("después de reset: %q", view())
+	}
+
+	// Sin typewriter el código está entero desde el principio y el slide
+	// no tiene un final propio
+	c, _ = newCodeSlide(nil, "ab\ncd")
+	if _, has := c.complete(); has || c.Init() != nil || view() != "ab/cd" {
+		t.Errorf("sin typewriter: %q", view())
+	}
+}
+
+// tickingSlide cuenta los ticks que recibe y en cada uno pide otro, como
+// un slide animado
+type tickingSlide struct{ ticks, resets int }
+
+func (s *tickingSlide) View() string  { return renderFrame(strconv.Itoa(s.ticks)) }
+func (s *tickingSlide) Init() tea.Cmd { return func() tea.Msg { return tickMsg{} } }
+func (s *tickingSlide) reset()        { s.ticks, s.resets = 0, s.resets+1 }
+
+func (s *tickingSlide) Update(msg tea.Msg) (slide, tea.Cmd) {
+	if _, ok := msg.(tickMsg); ok {
+		s.ticks++
+		return s, s.Init()
+	}
+	return s, nil
+}
+
+// slideMsgs ejecuta cmd y devuelve los mensajes de slides que produce
+func slideMsgs(cmd tea.Cmd) []slideMsg {
+	if cmd == nil {
+		return nil
+	}
+	switch msg := cmd().(type) {
+	case slideMsg:
+		return []slideMsg{msg}
+	case tea.BatchMsg:
+		var msgs []slideMsg
+		for _, c := range msg {
+			msgs = append(msgs, slideMsgs(c)...)
+		}
+		return msgs
+	}
+	return nil
+}
+
+// tickingDeck carga doc y reemplaza sus slides por tickingSlides, con la
+// configuración de animación que dice el deck
+func tickingDeck(t *testing.T, doc string) (model, []*tickingSlide) {
+	t.Helper()
+	d, err := loadDeck(writeDeck(t, doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var slides []*tickingSlide
+	for i := range d.slides {
+		s := &tickingSlide{}
+		d.slides[i] = s
+		slides = append(slides, s)
+	}
+	m := initialModel()
+	m.deck = d
+	return m, slides
+}
+
+func TestStaleSlideMsgsDropped(t *testing.T) {
+	m, slides := tickingDeck(t, "uno\n---\ndos\n---\ntres")
+	m, cmd := m.goTo(1)
+	first := slideMsgs(cmd)
+	if len(first) != 1 {
+		t.Fatalf("al entrar se pidieron %d ticks, se esperaba 1", len(first))
+	}
+	m, cmd = m.update(first[0])
+	if slides[1].ticks != 1 {
+		t.Fatalf("%d ticks, se esperaba 1", slides[1].ticks)
+	}
+	pending := slideMsgs(cmd)
+
+	// Al salir, lo que quedó pendiente se descarta, también después de
+	// volver a entrar
+	m, _ = m.goTo(2)
+	m, _ = m.update(pending[0])
+	m, cmd = m.goTo(1)
+	m, _ = m.update(pending[0])
+	m, _ = m.update(first[0])
+	if slides[1].ticks != 0 || slides[2].ticks != 0 {
+		t.Errorf("ticks viejos llegaron a los slides: %d y %d", slides[1].ticks, slides[2].ticks)
+	}
+
+	// Los de la visita nueva sí llegan
+	for _, msg := range slideMsgs(cmd) {
+		m, _ = m.update(msg)
+	}
+	if slides[1].ticks != 1 {
+		t.Errorf("%d ticks de la visita nueva, se esperaba 1", slides[1].ticks)
+	}
+	if msgs := slideMsgs(cmd); msgs[0].gen != m.gen[1] || pending[0].gen == m.gen[1] {
+		t.Errorf("generación %d, la vieja %d, la actual %d", msgs[0].gen, pending[0].gen, m.gen[1])
+	}
+}
+
+func TestAnimationFreezeAndReplay(t *testing.T) {
+	m, slides := tickingDeck(t, "+++\nanimation: freeze\n+++\n---\nuno\n---\n+++\nanimation: replay\n+++\ndos\n---\ntres")
+	if !slices.Equal(m.freeze, []bool{true, false, true}) {
+		t.Fatalf("freeze %v", m.freeze)
+	}
+	visit := func(idx int) {
+		var cmd tea.Cmd
+		m, cmd = m.goTo(idx)
+		for _, msg := range slideMsgs(cmd) {
+			m, cmd = m.update(msg)
+		}
+		for _, msg := range slideMsgs(cmd) {
+			m, _ = m.update(msg)
+		}
+	}
+	visit(0)
+	visit(1)
+	visit(2)
+	visit(0)
+	visit(1)
+
+	// El congelado sigue donde quedó; el que se repite vuelve a empezar
+	if slides[0].resets != 0 || slides[0].ticks != 4 {
+		t.Errorf("freeze: %d reinicios y %d ticks, se esperaban 0 y 4", slides[0].resets, slides[0].ticks)
+	}
+	if slides[1].resets != 2 || slides[1].ticks != 2 {
+		t.Errorf("replay: %d reinicios y %d ticks, se esperaban 2 y 2", slides[1].resets, slides[1].ticks)
+	}
+}
+
+func TestNiceStep(t *testing.T) {
+	for _, tt := range [][2]float64{
+		{1, 1}, {1.5, 2}, {2, 2}, {2.1, 5}, {0.3, 0.5}, {5, 5}, {7, 10},
+		{12, 20}, {25, 50}, {450, 500}, {0.012, 0.02}, {1000, 1000},
+	} {
+		if got := niceStep(tt[0]); math.Abs(got-tt[1]) > 1e-9*tt[1] {
+			t.Errorf("niceStep(%v) = %v, se esperaba %v", tt[0], got, tt[1])
+		}
+	}
+}
+
+func TestChartBounds(t *testing.T) {
+	series := func(values ...[]float64) chartData {
+		d := chartData{labels: make([]string, len(values[0]))}
+		for _, v := range values {
+			d.series = append(d.series, chartSeries{targets: v})
+		}
+		return d
+	}
+	withLimits := series([]float64{3, 4})
+	withLimits.maxValue = 100
+	tests := []struct {
+		name         string
+		data         chartData
+		stacked      bool
+		lo, hi, step float64
+	}{
+		{"positivos", series([]float64{1, 10, 4}), false, 0, 10, 5},
+		{"negativos", series([]float64{-3, 7}), false, -5, 10, 5},
+		{"agrupadas", series([]float64{3, 4}, []float64{5, -2}), false, -2, 6, 2},
+		{"apiladas", series([]float64{3, 4}, []float64{5, -2}), true, -5, 10, 5},
+		{"apiladas negativas", series([]float64{-3, -1}, []float64{-4, 2}), true, -10, 5, 5},
+		{"límite pedido", withLimits, false, 0, 100, 50},
+		{"todo cero", series([]float64{0, 0}), false, 0, 1, 0.5},
+		{"decimales", series([]float64{0.12, 0.31}), false, 0, 0.4, 0.1},
+	}
+	for _, tt := range tests {
+		lo, hi, step := tt.data.bounds(tt.stacked)
+		if math.Abs(lo-tt.lo) > 1e-9 || math.Abs(hi-tt.hi) > 1e-9 || math.Abs(step-tt.step) > 1e-9 {
+			t.Errorf("%s: [%v, %v] de a %v, se esperaba [%v, %v] de a %v", tt.name, lo, hi, step, tt.lo, tt.hi, tt.step)
+		}
+	}
+}
+
+func TestParseChartData(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"datos.csv":    "mes,Web,Móvil\nEne, 1, 2\nFeb,3,4\n",
+		"vacio.csv":    "mes,Web\n",
+		"roto.csv":     "mes,Web\nEne,uno\n",
+		"datos.json":   `{"labels": ["a", "b"], "series": [{"name": "S", "values": [5, 6]}]}`,
+		"roto.json":    `{"series": [`,
+		"sinlabs.json": `{"series": [{"name": "S", "values": [1, 2, 3]}]}`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// describe resume los datos como "etiquetas | serie: valores | ..."
+	describe := func(d chartData) string {
+		parts := []string{strings.Join(d.labels, ",")}
+		for _, s := range d.series {
+			parts = append(parts, fmt.Sprintf("%s: %v", s.name, s.targets))
+		}
+		return strings.Join(parts, " | ")
+	}
+
+	tests := []struct {
+		meta map[string]string
+		want string
+	}{
+		{map[string]string{"values": "1, 2.5, -3"}, "1,2,3 | Serie: [1 2.5 -3]"},
+		{map[string]string{"values": "1, 2", "title": "Ventas", "labels": "a, b, c"}, "a,b | Ventas: [1 2]"},
+		{map[string]string{"series": "A: 1, 2; B: 3, 4", "labels": "x"}, "x,2 | A: [1 2] | B: [3 4]"},
+		{map[string]string{"csv": "datos.csv"}, "Ene,Feb | Web: [1 3] | Móvil: [2 4]"},
+		{map[string]string{"csv": filepath.Join(dir, "datos.csv"), "labels": "p, q"}, "p,q | Web: [1 3] | Móvil: [2 4]"},
+		{map[string]string{"json": "datos.json"}, "a,b | S: [5 6]"},
+		{map[string]string{"json": "sinlabs.json"}, "1,2,3 | S: [1 2 3]"},
+	}
+	for _, tt := range tests {
+		d, err := parseChartData(tt.meta, dir)
+		if err != nil {
+			t.Errorf("%v: %v", tt.meta, err)
+			continue
+		}
+		if got := describe(d); got != tt.want {
+			t.Errorf("%v: %q, se esperaba %q", tt.meta, got, tt.want)
+		}
+	}
+
+	d, err := parseChartData(map[string]string{"values": "1", "min": "-5", "max": "20"}, dir)
+	if err != nil || d.minValue != -5 || d.maxValue != 20 {
+		t.Errorf("min %v, max %v, error %v", d.minValue, d.maxValue, err)
+	}
+
+	errors := []struct {
+		meta map[string]string
+		want string
+	}{
+		{map[string]string{}, "el gráfico no tiene valores"},
+		{map[string]string{"values": "1, dos"}, "valor inválido \"dos\""},
+		{map[string]string{"values": " , "}, "el gráfico no tiene valores"},
+		{map[string]string{"series": "A: 1, 2; 3, 4"}, "serie sin nombre \"3, 4\""},
+		{map[string]string{"series": "A: 1, 2; B: 3"}, "la serie \"B\" tiene 1 valores y se esperaban 2"},
+		{map[string]string{"values": "1", "max": "mucho"}, "max inválido \"mucho\""},
+		{map[string]string{"csv": "vacio.csv"}, "se necesita un encabezado y al menos una fila"},
+		{map[string]string{"csv": "roto.csv"}, "valor inválido \"uno\""},
+		{map[string]string{"csv": "falta.csv"}, "falta.csv"},
+		{map[string]string{"json": "roto.json"}, "roto.json"},
+	}
+	for _, tt := range errors {
+		if _, err := parseChartData(tt.meta, dir); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: error %v, se esperaba %q", tt.meta, err, tt.want)
+		}
+	}
+}
+
+func TestTrackCells(t *testing.T) {
+	defer lipgloss.SetColorProfile(lipgloss.ColorProfile())
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	blocks := []string{" ", "1", "2", "3", "4", "5", "6", "7", "8"}
+	red, blue := lipgloss.Color("#FF0000"), lipgloss.Color("#0000FF")
+	reversed := func(cell string) bool {
+		return strings.Contains(cell, "\x1b[7") || strings.Contains(cell, ";7m") || strings.Contains(cell, ";7;")
+	}
+
+	tests := []struct {
+		name     string
+		segments []barSegment
+		want     string
+		reversed []bool
+	}{
+		{"vacía", nil, "   ", []bool{false, false, false}},
+		{"desde el inicio", []barSegment{{0, 20, red}}, "884", []bool{false, false, false}},
+		{"termina en un borde", []barSegment{{4, 24, red}}, "488", []bool{true, false, false}},
+		{"en medio de una celda", []barSegment{{10, 13, red}}, " 3 ", []bool{false, false, false}},
+		{"dos tramos", []barSegment{{0, 12, red}, {12, 24, blue}}, "848", []bool{false, false, false}},
+	}
+	for _, tt := range tests {
+		cells := trackCells(3, tt.segments, blocks)
+		var got strings.Builder
+		for i, cell := range cells {
+			got.WriteString(strings.TrimSuffix(plainRows(cell), "\n"))
+			if reversed(cell) != tt.reversed[i] {
+				t.Errorf("%s: celda %d invertida %v, se esperaba %v (%q)", tt.name, i, reversed(cell), tt.reversed[i], cell)
+			}
+		}
+		if got.String() != tt.want {
+			t.Errorf("%s: %q, se esperaba %q", tt.name, got.String(), tt.want)
+		}
+	}
+
+	// Donde se juntan dos tramos, el que no se dibuja queda de fondo
+	cells := trackCells(3, []barSegment{{0, 12, red}, {12, 24, blue}}, blocks)
+	if want := lipgloss.NewStyle().Foreground(red).Background(blue).Render("4"); cells[1] != want {
+		t.Errorf("celda compartida %q, se esperaba %q", cells[1], want)
+	}
+}
+
+// endingSlide es un slide con un final propio que el test decide
+type endingSlide struct{ done, ends bool }
+
+func (s *endingSlide) View() string                        { return renderFrame("fin") }
+func (s *endingSlide) Init() tea.Cmd                       { return nil }
+func (s *endingSlide) Update(msg tea.Msg) (slide, tea.Cmd) { return s, nil }
+func (s *endingSlide) complete() (done, ends bool)         { return s.done, s.ends }
+
+// autoSession carga doc con el avance automático encendido, como lo deja
+// main, y devuelve una función que hace pasar el tiempo de a ticks
+func autoSession(t *testing.T, doc string) (*model, func(time.Duration)) {
+	t.Helper()
+	d, err := loadDeck(writeDeck(t, doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := initialModel()
+	m.deck, m.auto = d, true
+	now := time.Now()
+	m, _ = m.update(advanceTickMsg(now))
+	wait := func(d time.Duration) {
+		for end := now.Add(d); now.Before(end); {
+			now = now.Add(250 * time.Millisecond)
+			m, _ = m.update(advanceTickMsg(now))
+		}
+	}
+	return &m, wait
+}
+
+func TestAdvancePerSlide(t *testing.T) {
+	m, wait := autoSession(t, "+++\nadvance: 10s\n+++\n---\nuno\n---\n+++\nadvance: 2s\n+++\ndos\n---\n+++\nadvance: off\n+++\ntres\n---\ncuatro")
+	wait(9 * time.Second)
+	if m.currentIdx != 0 {
+		t.Fatalf("a los 9s ya pasó al slide %d; el deck pide 10s", m.currentIdx+1)
+	}
+	wait(time.Second)
+	if m.currentIdx != 1 {
+		t.Fatalf("a los 10s sigue en el slide %d", m.currentIdx+1)
+	}
+	// El slide 2 pide su propio tiempo
+	wait(2 * time.Second)
+	if m.currentIdx != 2 {
+		t.Fatalf("a los 2s del slide 2 sigue en el %d", m.currentIdx+1)
+	}
+	wait(time.Minute)
+	if m.currentIdx != 2 {
+		t.Errorf("un slide con advance: off pasó al %d", m.currentIdx+1)
+	}
+}
+
+func TestAdvanceOnEnd(t *testing.T) {
+	m, wait := autoSession(t, "+++\nadvance: 5s\n+++\n---\n+++\nadvance: end\n+++\na\n---\n+++\nadvance: end\n+++\nb\n---\n+++\nadvance: end\n+++\nc\n---\nd")
+	ending, timed := &endingSlide{ends: true}, &endingSlide{}
+	m.slides[0], m.slides[2] = ending, timed
+
+	wait(time.Minute)
+	if m.currentIdx != 0 {
+		t.Fatalf("pasó al slide %d antes de que el primero terminara", m.currentIdx+1)
+	}
+	ending.done = true
+	wait(250 * time.Millisecond)
+	if m.currentIdx != 1 {
+		t.Fatalf("el slide terminó y sigue en el %d", m.currentIdx+1)
+	}
+	// Sin un final propio, end pasa con el tiempo por defecto del deck
+	wait(4 * time.Second)
+	if m.currentIdx != 1 {
+		t.Fatalf("pasó al slide %d antes de los 5s", m.currentIdx+1)
+	}
+	wait(time.Second)
+	if m.currentIdx != 2 {
+		t.Fatalf("a los 5s sigue en el slide %d", m.currentIdx+1)
+	}
+	wait(5 * time.Second)
+	if m.currentIdx != 3 {
+		t.Errorf("un slide que no termina solo no pasó con el tiempo: slide %d", m.currentIdx+1)
+	}
+}
+
+func TestAdvanceLoop(t *testing.T) {
+	doc := "+++\nadvance: 1s\n+++\n---\n+++\nreveal: items\n+++\n- x\n- y\n- z\n---\nfin"
+	for _, loop := range []bool{false, true} {
+		m, wait := autoSession(t, doc)
+		m.loop = loop
+		// Llega al último slide después de mostrar los fragmentos del
+		// primero, y le da tiempo de sobra para pasar
+		for i := 0; m.currentIdx != 1; i++ {
+			if i > 20 {
+				t.Fatalf("loop %v: no llegó al último slide", loop)
+			}
+			wait(time.Second)
+		}
+		if m.fragment[0] != 2 {
+			t.Fatalf("loop %v: se mostraron %d fragmentos, se esperaban 2", loop, m.fragment[0])
+		}
+		wait(1500 * time.Millisecond)
+		if want := map[bool]int{false: 1, true: 0}[loop]; m.currentIdx != want {
+			t.Errorf("loop %v: terminó en el slide %d, se esperaba el %d", loop, m.currentIdx+1, want+1)
+		}
+		if loop && m.fragment[0] != 0 {
+			t.Errorf("al volver al principio se ven %d fragmentos", m.fragment[0])
+		}
+	}
+}
+
+func TestAnyKeyPausesAdvance(t *testing.T) {
+	m, wait := autoSession(t, "+++\nadvance: 2s\n+++\n---\nuno\n---\ndos\n---\ntres")
+	wait(time.Second)
+	next, _ := m.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	*m = next
+	if !m.autoPaused {
+		t.Fatal("la tecla no pausó el avance")
+	}
+	wait(time.Minute)
+	if m.currentIdx != 0 {
+		t.Fatalf("en pausa pasó al slide %d", m.currentIdx+1)
+	}
+
+	// La tecla además hace lo suyo
+	next, _ = m.update(tea.KeyMsg{Type: tea.KeyRight})
+	if *m = next; m.currentIdx != 1 || !m.autoPaused {
+		t.Fatalf("→ en pausa: slide %d, pausado %v", m.currentIdx+1, m.autoPaused)
+	}
+
+	// "a" lo retoma, sin contar el tiempo en pausa
+	next, _ = m.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	*m = next
+	wait(time.Second)
+	if m.autoPaused || m.currentIdx != 1 {
+		t.Fatalf("después de retomar: slide %d, pausado %v", m.currentIdx+1, m.autoPaused)
+	}
+	wait(time.Second)
+	if m.currentIdx != 2 {
+		t.Errorf("a los 2s de retomar sigue en el slide %d", m.currentIdx+1)
+	}
+}
+
+// press manda teclas al modelo: "enter", "esc", "backspace" y las flechas
+// por su nombre, cualquier otra cosa como texto
+func press(m model, keys ...string) model {
+	types := map[string]tea.KeyType{
+		"enter": tea.KeyEnter, "esc": tea.KeyEsc, "backspace": tea.KeyBackspace,
+		"left": tea.KeyLeft, "right": tea.KeyRight, "up": tea.KeyUp, "down": tea.KeyDown,
+		"home": tea.KeyHome, "end": tea.KeyEnd, " ": tea.KeySpace,
+	}
+	for _, k := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		if t, ok := types[k]; ok {
+			msg = tea.KeyMsg{Type: t}
+		}
+		m, _ = m.update(msg)
+	}
+	return m
+}
+
+func searchDeck(t *testing.T) model {
+	t.Helper()
+	d, err := loadDeck(writeDeck(t, "# Manzanas\n\nRojas y VERDES\n???\nfruta   de\nestación\n---\n# Peras\n\nDe agua\n???\nVer manzanas antes\n---\n# Uvas\n\nMoradas\n---\n# Kiwis\n\nVerdes"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := initialModel()
+	m.deck = d
+	return m
+}
+
+func TestSearch(t *testing.T) {
+	m := searchDeck(t)
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"manzanas", []int{0, 1}},
+		{"MANZANAS", []int{0, 1}},
+		{"verdes", []int{0, 3}},
+		{"rojas y  verdes", []int{0}},
+		// Las notas cuentan, con sus saltos de línea como espacios
+		{"fruta de estación", []int{0}},
+		{"de agua", []int{1}},
+		{"kiwi", []int{3}},
+		{"bananas", nil},
+	}
+	for _, tt := range tests {
+		if got := m.search(tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("search(%q) = %v, se esperaba %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchNavigation(t *testing.T) {
+	m := searchDeck(t)
+	m, _ = m.goTo(2)
+
+	// Desde un slide sin resultados, la búsqueda va al siguiente que tiene,
+	// dando la vuelta al final
+	m = press(m, "/", "MANZ", "x", "backspace", "anas", "enter")
+	if m.query != "MANZanas" || !slices.Equal(m.matches, []int{0, 1}) || m.currentIdx != 0 {
+		t.Fatalf("búsqueda %q: resultados %v, slide %d", m.query, m.matches, m.currentIdx+1)
+	}
+	for _, step := range []struct {
+		key  string
+		want int
+	}{{"n", 1}, {"n", 0}, {"N", 1}, {"N", 0}} {
+		if m = press(m, step.key); m.currentIdx != step.want {
+			t.Errorf("%s: slide %d, se esperaba %d", step.key, m.currentIdx+1, step.want+1)
+		}
+	}
+
+	// Si el slide actual tiene resultados, se queda
+	m, _ = m.goTo(3)
+	if m = press(m, "/", "verdes", "enter"); m.currentIdx != 3 {
+		t.Errorf("se fue al slide %d teniendo resultados en el actual", m.currentIdx+1)
+	}
+	// Con un solo resultado, n no se mueve
+	if m = press(m, "esc", "/", "kiwis", "enter", "n", "N"); m.currentIdx != 3 {
+		t.Errorf("con un solo resultado n llevó al slide %d", m.currentIdx+1)
+	}
+
+	// Sin resultados no se mueve, y esc limpia la búsqueda
+	m = press(m, "/", "bananas", "enter")
+	if m.currentIdx != 3 || len(m.matches) != 0 || !strings.Contains(m.statusLine(), "Sin resultados") {
+		t.Errorf("sin resultados: slide %d, %q", m.currentIdx+1, m.statusLine())
+	}
+	if m = press(m, "esc"); m.query != "" {
+		t.Errorf("esc no limpió la búsqueda %q", m.query)
+	}
+	// Esc mientras se escribe cancela sin buscar
+	if m = press(m, "/", "uvas", "esc"); m.query != "" || m.prompt != "" || m.currentIdx != 3 {
+		t.Errorf("esc al escribir: búsqueda %q, slide %d", m.query, m.currentIdx+1)
+	}
+}
+
+func TestGoToPrompt(t *testing.T) {
+	m := searchDeck(t)
+	tests := []struct {
+		keys []string
+		want int
+	}{
+		{[]string{"g", "3", "enter"}, 2},
+		{[]string{"g", "0", "enter"}, 2},
+		{[]string{"g", "5", "enter"}, 2},
+		{[]string{"g", "-", "1", "enter"}, 0},
+		{[]string{"g", "4", "x", "enter"}, 3},
+		{[]string{"g", "42", "backspace", "enter"}, 3},
+		{[]string{"g", "2", "esc"}, 3},
+		{[]string{"g", "enter"}, 3},
+		{[]string{"g", "0", "2", "enter"}, 1},
+	}
+	for _, tt := range tests {
+		if m = press(m, tt.keys...); m.currentIdx != tt.want || m.prompt != "" {
+			t.Errorf("%q: slide %d (prompt %q), se esperaba %d", tt.keys, m.currentIdx+1, m.prompt, tt.want+1)
+		}
+	}
+	// Mientras se escribe, las teclas no navegan
+	if m = press(m, "g", "n", " ", "l"); m.currentIdx != 1 || m.input != "" {
+		t.Errorf("al escribir: slide %d, texto %q", m.currentIdx+1, m.input)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	view := "Hola MUNDO, mundo\n日本語 mun\ndo"
+	marked := func(view string) string {
+		var sb strings.Builder
+		for _, row := range parseCells(view) {
+			for _, c := range row {
+				switch {
+				case c.width == 0:
+				case c.sgr != "":
+					sb.WriteString("^")
+				default:
+					sb.WriteString(".")
+				}
+			}
+			sb.WriteString("\n")
+		}
+		return sb.String()
+	}
+	tests := []struct{ query, want string }{
+		{"mundo", ".....^^^^^..^^^^^\n.......\n..\n"},
+		{"o, m", ".........^^^^....\n.......\n..\n"},
+		// Lo que quedó cortado entre dos líneas no se encuentra
+		{"mundo m", ".................\n.......\n..\n"},
+		{"本", ".................\n.^.....\n..\n"},
+	}
+	for _, tt := range tests {
+		got := highlight(view, tt.query)
+		if plainRows(got) != plainRows(view) {
+			t.Errorf("%q: cambió el texto:\n%s", tt.query, plainRows(got))
+		}
+		if m := marked(got); m != tt.want {
+			t.Errorf("%q:\n%s\nse esperaba\n%s", tt.query, m, tt.want)
+		}
+	}
+	if highlight(view, "") != view {
+		t.Error("una búsqueda vacía cambió la vista")
+	}
+}
+
+func TestOverviewNavigation(t *testing.T) {
+	defer setSlideSize(projectorWidth, projectorHeight)
+	d, err := loadDeck(writeDeck(t, strings.Repeat("# Slide\n---\n", 9)+"# Último"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := initialModel()
+	m.deck = d
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m = next.(model)
+	m, _ = m.goTo(4)
+	cols := m.overviewColumns()
+	if cols < 2 || cols >= len(m.slides) {
+		t.Fatalf("%d columnas", cols)
+	}
+
+	m = press(m, "o")
+	if !m.overview || m.selected != 4 {
+		t.Fatalf("vista general %v, elegido %d", m.overview, m.selected+1)
+	}
+	steps := []struct {
+		key  string
+		want int
+	}{
+		{"right", 5}, {"left", 4}, {"up", 4 - cols}, {"home", 0}, {"left", 0}, {"up", 0},
+		{"down", cols}, {"end", 9}, {"right", 9}, {"down", 9}, {"h", 8}, {"l", 9},
+	}
+	for _, s := range steps {
+		if m = press(m, s.key); m.selected != s.want {
+			t.Errorf("%s: elegido %d, se esperaba %d", s.key, m.selected+1, s.want+1)
+		}
+		if h := lipgloss.Height(m.overviewView()); h > m.height-2 {
+			t.Errorf("%s: la vista general mide %d filas en %d", s.key, h, m.height)
+		}
+		if !strings.Contains(plainRows(m.overviewView()), fmt.Sprintf("%d ", m.selected+1)) {
+			t.Errorf("%s: el elegido no está a la vista", s.key)
+		}
+	}
+
+	// Esc vuelve sin moverse; enter abre el elegido
+	if m = press(m, "esc"); m.overview || m.currentIdx != 4 {
+		t.Errorf("esc: vista general %v, slide %d", m.overview, m.currentIdx+1)
+	}
+	if m = press(m, "o", "left", "left", "enter"); m.overview || m.currentIdx != 2 {
+		t.Errorf("enter: vista general %v, slide %d", m.overview, m.currentIdx+1)
+	}
+	// En la vista general n no cambia de slide
+	if m = press(m, "o", "n"); !m.overview || m.currentIdx != 2 {
+		t.Errorf("n en la vista general: vista general %v, slide %d", m.overview, m.currentIdx+1)
+	}
+}
@@ -0,0 +1,327 @@
+// Package color lee los colores que se aceptan en los metadatos de los
+// slides y arma degradés de varias paradas, interpolados en OKLab, OKLCH,
+// HSL o RGB.
+package color
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// namedColors son los nombres de color que se aceptan, los básicos de CSS
+var namedColors = map[string]string{
+	"black": "#000000", "white": "#FFFFFF", "gray": "#808080", "grey": "#808080",
+	"silver": "#C0C0C0", "red": "#FF0000", "maroon": "#800000", "orange": "#FFA500",
+	"gold": "#FFD700", "yellow": "#FFFF00", "olive": "#808000", "lime": "#00FF00",
+	"green": "#008000", "teal": "#008080", "cyan": "#00FFFF", "aqua": "#00FFFF",
+	"blue": "#0000FF", "navy": "#000080", "indigo": "#4B0082", "purple": "#800080",
+	"violet": "#EE82EE", "magenta": "#FF00FF", "fuchsia": "#FF00FF", "pink": "#FFC0CB",
+	"brown": "#A52A2A", "coral": "#FF7F50", "salmon": "#FA8072", "turquoise": "#40E0D0",
+}
+
+// Parse lee un color: #RGB, #RRGGBB, #RRGGBBAA (el alfa se ignora,
+// la terminal no tiene transparencia), rgb(r, g, b) o rgba(r, g, b, a) con
+// valores de 0 a 255 o porcentajes, un nombre (ver namedColors) o un
+// índice de la paleta ANSI de 256 colores
+func Parse(value string) (colorful.Color, error) {
+	invalid := fmt.Errorf("color %q inválido (se espera #RGB, #RRGGBB, #RRGGBBAA, rgb(r, g, b), un nombre o un índice ANSI de 0 a 255)", value)
+	s := strings.ToLower(strings.TrimSpace(value))
+	if hex, ok := namedColors[s]; ok {
+		s = hex
+	}
+
+	switch {
+	case strings.HasPrefix(s, "#"):
+		digits := s[1:]
+		if len(digits) == 3 || len(digits) == 4 {
+			var long strings.Builder
+			for _, d := range digits {
+				long.WriteString(strings.Repeat(string(d), 2))
+			}
+			digits = long.String()
+		}
+		if len(digits) == 8 {
+			digits = digits[:6]
+		}
+		n, err := strconv.ParseUint(digits, 16, 32)
+		if len(digits) != 6 || err != nil {
+			return colorful.Color{}, invalid
+		}
+		return colorful.Color{R: float64(n>>16) / 255, G: float64(n>>8&0xFF) / 255, B: float64(n&0xFF) / 255}, nil
+
+	case strings.HasPrefix(s, "rgb(") || strings.HasPrefix(s, "rgba("):
+		open := strings.Index(s, "(")
+		if !strings.HasSuffix(s, ")") {
+			return colorful.Color{}, invalid
+		}
+		args := strings.FieldsFunc(s[open+1:len(s)-1], func(r rune) bool { return r == ',' || r == ' ' || r == '/' })
+		if len(args) != 3 && len(args) != 4 {
+			return colorful.Color{}, invalid
+		}
+		var rgb [3]float64
+		for i := range rgb {
+			arg, scale := args[i], 255.0
+			if strings.HasSuffix(arg, "%") {
+				arg, scale = strings.TrimSuffix(arg, "%"), 100
+			}
+			v, err := strconv.ParseFloat(arg, 64)
+			if err != nil || v < 0 || v > scale {
+				return colorful.Color{}, invalid
+			}
+			rgb[i] = v / scale
+		}
+		return colorful.Color{R: rgb[0], G: rgb[1], B: rgb[2]}, nil
+	}
+
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n <= 255 {
+		c := Xterm256(n)
+		return colorful.Color{R: float64(c[0]) / 255, G: float64(c[1]) / 255, B: float64(c[2]) / 255}, nil
+	}
+	return colorful.Color{}, invalid
+}
+
+// Stop es una parada de un degradé: un color y su posición, de 0 a 1
+type Stop struct {
+	Color colorful.Color
+	At    float64
+}
+
+// Gradient es un degradé de una o más paradas; Space es el espacio en el
+// que se interpola entre ellas: oklab (por defecto), oklch, hsl o rgb.
+// OKLab y OKLCH son perceptuales: los pasos intermedios no se ven grises
+// ni más oscuros que las puntas, como pasa en RGB.
+type Gradient struct {
+	Stops []Stop
+	Space string
+}
+
+var colorSpaces = map[string]bool{"oklab": true, "oklch": true, "hsl": true, "rgb": true}
+
+// ParseGradient lee las paradas de un degradé separadas por comas, cada
+// una con su posición opcional en porcentaje ("#FF10F0, gold 30%, blue").
+// Las que no la tienen se reparten entre sus vecinas, como en CSS.
+func ParseGradient(spec, space string) (Gradient, error) {
+	g := Gradient{Space: space}
+	if !colorSpaces[space] {
+		return g, fmt.Errorf("espacio de color %q desconocido (se espera oklab, oklch, hsl o rgb)", space)
+	}
+
+	// Las comas de rgb() no separan paradas
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range spec {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, spec[start:i])
+				start = i + 1
+			}
+		}
+	}
+	parts = append(parts, spec[start:])
+
+	known := []bool{}
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		at, hasAt := 0.0, false
+		if i := strings.LastIndex(part, " "); i > 0 && strings.HasSuffix(part, "%") {
+			v, err := strconv.ParseFloat(strings.TrimSuffix(part[i+1:], "%"), 64)
+			if err != nil || v < 0 || v > 100 {
+				return g, fmt.Errorf("posición %q inválida (se espera un porcentaje de 0%% a 100%%)", part[i+1:])
+			}
+			part, at, hasAt = strings.TrimSpace(part[:i]), v/100, true
+		}
+		c, err := Parse(part)
+		if err != nil {
+			return g, err
+		}
+		g.Stops = append(g.Stops, Stop{Color: c, At: at})
+		known = append(known, hasAt)
+	}
+	if len(g.Stops) == 0 {
+		return g, fmt.Errorf("el degradé no tiene colores")
+	}
+
+	last := len(g.Stops) - 1
+	if !known[0] {
+		g.Stops[0].At, known[0] = 0, true
+	}
+	if !known[last] {
+		g.Stops[last].At, known[last] = 1, true
+	}
+	for i := 1; i <= last; i++ {
+		if known[i] {
+			// Una parada no puede quedar antes que la anterior
+			g.Stops[i].At = max(g.Stops[i].At, g.Stops[i-1].At)
+			continue
+		}
+		next := i + 1
+		for !known[next] {
+			next++
+		}
+		from, to := g.Stops[i-1].At, max(g.Stops[next].At, g.Stops[i-1].At)
+		g.Stops[i].At = from + (to-from)/float64(next-i+1)
+	}
+	return g, nil
+}
+
+// At es el color del degradé en t, de 0 a 1
+func (g Gradient) At(t float64) colorful.Color {
+	t = min(max(t, 0), 1)
+	if t <= g.Stops[0].At {
+		return g.Stops[0].Color
+	}
+	for i := 1; i < len(g.Stops); i++ {
+		a, b := g.Stops[i-1], g.Stops[i]
+		if t > b.At {
+			continue
+		}
+		if b.At == a.At {
+			return b.Color
+		}
+		return interpolate(a.Color, b.Color, (t-a.At)/(b.At-a.At), g.Space)
+	}
+	return g.Stops[len(g.Stops)-1].Color
+}
+
+// Loop devuelve el degradé comprimido para que termine en su primer color,
+// así se puede repetir sin saltos
+func (g Gradient) Loop() Gradient {
+	n := len(g.Stops)
+	if n < 2 {
+		return g
+	}
+	looped := Gradient{Space: g.Space}
+	for _, s := range g.Stops {
+		looped.Stops = append(looped.Stops, Stop{Color: s.Color, At: s.At * float64(n-1) / float64(n)})
+	}
+	looped.Stops = append(looped.Stops, Stop{Color: g.Stops[0].Color, At: 1})
+	return looped
+}
+
+// Describe lista las paradas, para mostrarlas en un slide
+func (g Gradient) Describe() string {
+	var colors []string
+	for _, s := range g.Stops {
+		colors = append(colors, strings.ToUpper(s.Color.Hex()))
+	}
+	return g.Space + ": " + strings.Join(colors, " → ")
+}
+
+// interpolate mezcla dos colores en el espacio pedido. En HSL y OKLCH el
+// tono va por el camino más corto y un gris toma el tono del otro color,
+// así el degradé no pasa por tonos que no están en ninguna de las puntas.
+func interpolate(a, b colorful.Color, t float64, space string) colorful.Color {
+	lerp := func(x, y float64) float64 { return x + (y-x)*t }
+	switch space {
+	case "rgb":
+		return a.BlendRgb(b, t)
+
+	case "hsl":
+		h1, s1, l1 := a.Hsl()
+		h2, s2, l2 := b.Hsl()
+		h1, h2 = achromaticHue(h1, s1, h2, s2)
+		return colorful.Hsl(lerpHue(h1, h2, t), lerp(s1, s2), lerp(l1, l2)).Clamped()
+
+	case "oklch":
+		l1, c1, h1 := oklch(a)
+		l2, c2, h2 := oklch(b)
+		h1, h2 = achromaticHue(h1, c1*100, h2, c2*100)
+		h := lerpHue(h1, h2, t) * math.Pi / 180
+		c := lerp(c1, c2)
+		return fromOklab(lerp(l1, l2), c*math.Cos(h), c*math.Sin(h))
+	}
+
+	l1, a1, b1 := oklab(a)
+	l2, a2, b2 := oklab(b)
+	return fromOklab(lerp(l1, l2), lerp(a1, a2), lerp(b1, b2))
+}
+
+// achromaticHue da a un gris (saturación o croma casi nulos) el tono del
+// otro color
+func achromaticHue(h1, s1, h2, s2 float64) (float64, float64) {
+	const gray = 1e-3
+	switch {
+	case s1 < gray && s2 >= gray:
+		return h2, h2
+	case s2 < gray && s1 >= gray:
+		return h1, h1
+	}
+	return h1, h2
+}
+
+// lerpHue interpola dos tonos en grados por el camino más corto
+func lerpHue(h1, h2, t float64) float64 {
+	d := math.Mod(h2-h1+540, 360) - 180
+	return math.Mod(h1+d*t+360, 360)
+}
+
+// oklab convierte a OKLab (Björn Ottosson, 2020)
+func oklab(c colorful.Color) (l, a, b float64) {
+	r, g, bl := c.LinearRgb()
+	lms := [3]float64{
+		math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*bl),
+		math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*bl),
+		math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*bl),
+	}
+	return 0.2104542553*lms[0] + 0.7936177850*lms[1] - 0.0040720468*lms[2],
+		1.9779984951*lms[0] - 2.4285922050*lms[1] + 0.4505937099*lms[2],
+		0.0259040371*lms[0] + 0.7827717662*lms[1] - 0.8086757660*lms[2]
+}
+
+func fromOklab(l, a, b float64) colorful.Color {
+	cube := func(x float64) float64 { return x * x * x }
+	l1 := cube(l + 0.3963377774*a + 0.2158037573*b)
+	m1 := cube(l - 0.1055613458*a - 0.0638541728*b)
+	s1 := cube(l - 0.0894841775*a - 1.2914855480*b)
+	return colorful.LinearRgb(
+		4.0767416621*l1-3.3077115913*m1+0.2309699292*s1,
+		-1.2684380046*l1+2.6097574011*m1-0.3413193965*s1,
+		-0.0041960863*l1-0.7034186147*m1+1.7076147010*s1,
+	).Clamped()
+}
+
+// oklch es OKLab en coordenadas polares: luminosidad, croma y tono en
+// grados
+func oklch(c colorful.Color) (l, chroma, h float64) {
+	l, a, b := oklab(c)
+	h = math.Atan2(b, a) * 180 / math.Pi
+	if h < 0 {
+		h += 360
+	}
+	return l, math.Hypot(a, b), h
+}
+
+// Xterm256 convierte un índice de la paleta de 256 colores a RGB
+func Xterm256(n int) [3]int {
+	basic := [16][3]int{
+		{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+		{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+		{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+		{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+	}
+	switch {
+	case n < 0 || n > 255:
+		return [3]int{}
+	case n < 16:
+		return basic[n]
+	case n < 232:
+		levels := []int{0, 95, 135, 175, 215, 255}
+		n -= 16
+		return [3]int{levels[n/36], levels[n/6%6], levels[n%6]}
+	default:
+		g := 8 + 10*(n-232)
+		return [3]int{g, g, g}
+	}
+}
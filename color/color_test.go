@@ -0,0 +1,169 @@
+package color
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"#F0A", "#ff00aa"},
+		{"#F0A8", "#ff00aa"},
+		{"#FF8C10", "#ff8c10"},
+		{"#ff8c1080", "#ff8c10"},
+		{"  Gold ", "#ffd700"},
+		{"rgb(255, 140, 16)", "#ff8c10"},
+		{"rgba(255 140 16 / 0.5)", "#ff8c10"},
+		{"rgb(100%, 0%, 50%)", "#ff0080"},
+		{"9", "#ff0000"},
+		{"196", "#ff0000"},
+		{"244", "#808080"},
+	}
+	for _, tt := range tests {
+		c, err := Parse(tt.value)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.value, err)
+			continue
+		}
+		if got := c.Hex(); got != tt.want {
+			t.Errorf("Parse(%q) = %s, se esperaba %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, value := range []string{"", "#12", "#GGGGGG", "rgb(1, 2)", "rgb(1, 2, 300)", "rgb(1, 2, 3", "256", "-1", "rosa"} {
+		if c, err := Parse(value); err == nil {
+			t.Errorf("Parse(%q) = %s, se esperaba un error", value, c.Hex())
+		}
+	}
+}
+
+func TestParseGradientPositions(t *testing.T) {
+	g, err := ParseGradient("red, rgb(0, 255, 0) 20%, yellow, blue, white 100%", "rgb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Las paradas sin posición se reparten entre sus vecinas, como en CSS
+	want := []float64{0, 0.2, 0.2 + 0.8/3, 0.2 + 1.6/3, 1}
+	if len(g.Stops) != len(want) {
+		t.Fatalf("%d paradas, se esperaban %d", len(g.Stops), len(want))
+	}
+	for i, s := range g.Stops {
+		if math.Abs(s.At-want[i]) > 1e-9 {
+			t.Errorf("parada %d en %.3f, se esperaba %.3f", i, s.At, want[i])
+		}
+	}
+
+	// Una posición menor que la anterior se lleva a la anterior
+	g, err = ParseGradient("red 60%, blue 30%", "oklab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Stops[1].At != 0.6 {
+		t.Errorf("la segunda parada quedó en %.2f, se esperaba 0.60", g.Stops[1].At)
+	}
+}
+
+func TestParseGradientRejects(t *testing.T) {
+	for _, tt := range [][2]string{
+		{"red, blue", "lab"},
+		{"", "oklab"},
+		{"red, blue 120%", "oklab"},
+		{"red, azul", "oklab"},
+	} {
+		if _, err := ParseGradient(tt[0], tt[1]); err == nil {
+			t.Errorf("ParseGradient(%q, %q) no devolvió un error", tt[0], tt[1])
+		}
+	}
+}
+
+func TestGradientAt(t *testing.T) {
+	g, err := ParseGradient("#000000, #FFFFFF", "rgb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		t    float64
+		want string
+	}{{-1, "#000000"}, {0, "#000000"}, {0.5, "#808080"}, {1, "#ffffff"}, {2, "#ffffff"}} {
+		if got := g.At(tt.t).Hex(); got != tt.want {
+			t.Errorf("At(%v) = %s, se esperaba %s", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	red, blue := colorful.Color{R: 1}, colorful.Color{B: 1}
+
+	// En RGB el medio entre rojo y azul es violeta oscuro
+	if got := interpolate(red, blue, 0.5, "rgb").Hex(); got != "#800080" {
+		t.Errorf("rgb: %s, se esperaba #800080", got)
+	}
+	// En OKLab el medio no es más oscuro que las puntas
+	l, _, _ := oklab(interpolate(red, blue, 0.5, "oklab"))
+	lRed, _, _ := oklab(red)
+	lBlue, _, _ := oklab(blue)
+	if l < min(lRed, lBlue) {
+		t.Errorf("oklab: luminosidad %.3f, menor que las puntas %.3f y %.3f", l, lRed, lBlue)
+	}
+	// En HSL el tono va por el camino corto: de rojo (0°) a azul (240°)
+	// pasa por magenta (300°), no por verde
+	if h, _, _ := interpolate(red, blue, 0.5, "hsl").Hsl(); math.Abs(h-300) > 1 {
+		t.Errorf("hsl: tono %.1f°, se esperaba 300°", h)
+	}
+	// Un gris toma el tono del otro color en vez de pasar por el rojo
+	gray := colorful.Color{R: 0.5, G: 0.5, B: 0.5}
+	if h, _, _ := interpolate(gray, blue, 0.5, "hsl").Hsl(); math.Abs(h-240) > 1 {
+		t.Errorf("hsl desde gris: tono %.1f°, se esperaba 240°", h)
+	}
+	_, _, hBlue := oklch(blue)
+	if _, _, h := oklch(interpolate(gray, blue, 0.5, "oklch")); math.Abs(h-hBlue) > 1 {
+		t.Errorf("oklch desde gris: tono %.1f°, se esperaba %.1f°", h, hBlue)
+	}
+}
+
+func TestOklabRoundTrip(t *testing.T) {
+	for _, hex := range []string{"#000000", "#ffffff", "#ff8c10", "#3a5fcd", "#40e0d0"} {
+		c, _ := colorful.Hex(hex)
+		if got := fromOklab(oklab(c)).Hex(); got != hex {
+			t.Errorf("%s vuelve de OKLab como %s", hex, got)
+		}
+	}
+}
+
+func TestGradientLoop(t *testing.T) {
+	g, err := ParseGradient("red, lime, blue", "oklab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	looped := g.Loop()
+	if len(looped.Stops) != 4 || looped.At(1) != g.At(0) || looped.At(0) != g.At(0) {
+		t.Errorf("el degradé repetido no vuelve al primer color: %s", looped.Describe())
+	}
+	if got := g.Describe(); !strings.HasPrefix(got, "oklab: #FF0000 → #00FF00") {
+		t.Errorf("Describe() = %q", got)
+	}
+}
+
+func TestXterm256(t *testing.T) {
+	for n, want := range map[int][3]int{
+		1:   {205, 0, 0},
+		16:  {0, 0, 0},
+		21:  {0, 0, 255},
+		231: {255, 255, 255},
+		232: {8, 8, 8},
+		255: {238, 238, 238},
+		256: {},
+	} {
+		if got := Xterm256(n); got != want {
+			t.Errorf("Xterm256(%d) = %v, se esperaba %v", n, got, want)
+		}
+	}
+}
@@ -1,12 +1,24 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"math"
+	"net"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/harmonica"
 	"github.com/charmbracelet/lipgloss"
@@ -23,55 +35,1103 @@ var (
 
 // Particle representa una partícula que sigue al cursor
 type Particle struct {
-	x, y           float64
+	x, y             float64
 	targetX, targetY float64
-	xVel, yVel     float64
+	homeX, homeY     float64
+	xVel, yVel       float64
 	springX, springY harmonica.Spring
-	char            string
-	style           *lipgloss.Style
+	char             string
+	style            *lipgloss.Style
 }
 
 type model struct {
-	width, height   int
+	width, height    int
 	cursorX, cursorY int
-	particles       []Particle
-	frameCount      int
-	trail           [][2]int
-	maxTrail        int
-	clickX, clickY  int
-	clickActive     bool
-	clickRadius     int
-	clickMaxRadius  int
-	clickStyle      *lipgloss.Style
+	particles        []Particle
+	frameCount       int
+	trail            [][2]int
+	maxTrail         int
+	clickX, clickY   int
+	clickActive      bool
+	clickRadius      int
+	clickMaxRadius   int
+	clickStyle       *lipgloss.Style
+	formation        bool
+	shape            [][2]int
+	word             string
+	typing           bool
+	input            string
+	physics          physics
+	panel            tuningPanel
+	room             *roomClient
+	peers            map[int]*remotePeer
+	sentX, sentY     int
+	mouseSeen        bool
+	mouseWait        time.Time
+	keyboard         bool
+	keyDir           string
+	keyStreak        int
+	keyLast          time.Time
+	fields           []forceField
+	fieldsPath       string
+	notice           string
+}
+
+// Si en este tiempo no llega ningún evento del mouse se asume que la
+// terminal no los reporta (SSH, consola serie) y se activa el teclado. Se
+// mide desde mouseWait con la hora de los ticks, no contándolos, porque
+// con la terminal cargada llegan menos de 30 por segundo.
+const mouseTimeout = 3 * time.Second
+
+// physics agrupa los parámetros de los resortes y de la órbita que el panel
+// de ajuste modifica en vivo. Se guarda tal cual en los presets.
+type physics struct {
+	Frequency     float64 `json:"frequency"`
+	FrequencyStep float64 `json:"frequency_step"`
+	Damping       float64 `json:"damping"`
+	DampingStep   float64 `json:"damping_step"`
+	Orbit         float64 `json:"orbit"`
+	OrbitSwing    float64 `json:"orbit_swing"`
+	Particles     int     `json:"particles"`
+	Trail         int     `json:"trail"`
+}
+
+func defaultPhysics() physics {
+	return physics{
+		Frequency:     4.0,
+		FrequencyStep: 0.5,
+		Damping:       0.3,
+		DampingStep:   0.05,
+		Orbit:         5.0,
+		OrbitSwing:    1.5,
+		Particles:     8,
+		Trail:         20,
+	}
 }
 
 func initialModel() model {
 	m := model{
-		width:         80,
-		height:        24,
-		maxTrail:      20,
+		width:          80,
+		height:         24,
 		clickMaxRadius: 10,
+		physics:        defaultPhysics(),
+		panel:          newTuningPanel(),
+		peers:          map[int]*remotePeer{},
+		sentX:          -1,
+		sentY:          -1,
+		mouseWait:      time.Now(),
 	}
+	m.maxTrail = m.physics.Trail
 
 	// Partículas que siguen al cursor
-	m.particles = make([]Particle, 8)
+	m.particles = make([]Particle, m.physics.Particles)
 	for i := range m.particles {
-		frequency := 4.0 + float64(i)*0.5
-		damping := 0.3 + float64(i)*0.05
+		m.particles[i] = m.newParticle(i, float64(m.width/2), float64(m.height/2))
+	}
+
+	return m
+}
+
+// newParticle crea una partícula cuyo resorte depende de su índice, de modo
+// que cada grupo de partículas llega a su destino con un ritmo distinto.
+func (m *model) newParticle(i int, x, y float64) Particle {
+	frequency := m.physics.Frequency + float64(i%8)*m.physics.FrequencyStep
+	damping := m.physics.Damping + float64(i%8)*m.physics.DampingStep
+
+	return Particle{
+		x:       x,
+		y:       y,
+		targetX: x,
+		targetY: y,
+		springX: harmonica.NewSpring(harmonica.FPS(30), frequency, damping),
+		springY: harmonica.NewSpring(harmonica.FPS(30), frequency, damping),
+		char:    "●",
+		style:   neonColors[i%len(neonColors)],
+	}
+}
+
+// formShape hace que las partículas salten a las celdas de una figura. La
+// cantidad de partículas crece (o se recorta) para cubrir todas las celdas, y
+// cada carácter de la figura usa su propio resorte para llegar escalonado.
+func (m *model) formShape(cells [][2]int, groups []int) {
+	if len(cells) == 0 {
+		return
+	}
+
+	for len(m.particles) < len(cells) {
+		n := len(m.particles)
+		m.particles = append(m.particles, m.newParticle(n, float64(m.cursorX), float64(m.cursorY)))
+	}
+	m.particles = m.particles[:len(cells)]
+
+	for i := range cells {
+		p := m.newParticle(groups[i], m.particles[i].x, m.particles[i].y)
+		p.xVel, p.yVel = m.particles[i].xVel, m.particles[i].yVel
+		p.style = neonColors[i%len(neonColors)]
+		m.particles[i] = p
+	}
+	m.shape = cells
+	m.word = ""
+	m.formation = true
+	m.placeShape()
+}
 
-		m.particles[i] = Particle{
-			x:       float64(m.width / 2),
-			y:       float64(m.height / 2),
-			targetX: float64(m.width / 2),
-			targetY: float64(m.height / 2),
-			springX: harmonica.NewSpring(harmonica.FPS(30), frequency, damping),
-			springY: harmonica.NewSpring(harmonica.FPS(30), frequency, damping),
-			char:    "●",
-			style:   neonColors[i%len(neonColors)],
+// formText forma el texto con figFont al ancho de la pantalla
+func (m *model) formText(text string) {
+	m.formShape(textCells(text, m.width))
+	m.word = text
+}
+
+// scatter deshace la formación: las partículas vuelven a orbitar el cursor,
+// tantas como pide la física
+func (m *model) scatter() {
+	m.formation = false
+	m.word = ""
+	m.applyPhysics()
+}
+
+// placeShape centra la figura actual en la pantalla
+func (m *model) placeShape() {
+	maxX, maxY := 0, 0
+	for _, c := range m.shape {
+		if c[0] > maxX {
+			maxX = c[0]
+		}
+		if c[1] > maxY {
+			maxY = c[1]
 		}
 	}
+	offX := (m.width - maxX - 1) / 2
+	offY := (m.height - maxY - 1) / 2
 
-	return m
+	for i, c := range m.shape {
+		m.particles[i].homeX = float64(offX + c[0])
+		m.particles[i].homeY = float64(offY + c[1])
+	}
+}
+
+// Fuente de bloques estilo FIGlet (5x5) para las formaciones de texto
+var figFont = map[rune][]string{
+	'A': {" ### ", "#   #", "#####", "#   #", "#   #"},
+	'B': {"#### ", "#   #", "#### ", "#   #", "#### "},
+	'C': {" ####", "#    ", "#    ", "#    ", " ####"},
+	'D': {"#### ", "#   #", "#   #", "#   #", "#### "},
+	'E': {"#####", "#    ", "#### ", "#    ", "#####"},
+	'F': {"#####", "#    ", "#### ", "#    ", "#    "},
+	'G': {" ####", "#    ", "#  ##", "#   #", " ####"},
+	'H': {"#   #", "#   #", "#####", "#   #", "#   #"},
+	'I': {"#####", "  #  ", "  #  ", "  #  ", "#####"},
+	'J': {"#####", "   # ", "   # ", "#  # ", " ##  "},
+	'K': {"#   #", "#  # ", "###  ", "#  # ", "#   #"},
+	'L': {"#    ", "#    ", "#    ", "#    ", "#####"},
+	'M': {"#   #", "## ##", "# # #", "#   #", "#   #"},
+	'N': {"#   #", "##  #", "# # #", "#  ##", "#   #"},
+	'Ñ': {" ### ", "#   #", "##  #", "# # #", "#  ##"},
+	'O': {" ### ", "#   #", "#   #", "#   #", " ### "},
+	'P': {"#### ", "#   #", "#### ", "#    ", "#    "},
+	'Q': {" ### ", "#   #", "# # #", "#  # ", " ## #"},
+	'R': {"#### ", "#   #", "#### ", "#  # ", "#   #"},
+	'S': {" ####", "#    ", " ### ", "    #", "#### "},
+	'T': {"#####", "  #  ", "  #  ", "  #  ", "  #  "},
+	'U': {"#   #", "#   #", "#   #", "#   #", " ### "},
+	'V': {"#   #", "#   #", "#   #", " # # ", "  #  "},
+	'W': {"#   #", "#   #", "# # #", "## ##", "#   #"},
+	'X': {"#   #", " # # ", "  #  ", " # # ", "#   #"},
+	'Y': {"#   #", " # # ", "  #  ", "  #  ", "  #  "},
+	'Z': {"#####", "   # ", "  #  ", " #   ", "#####"},
+	'0': {" ### ", "#  ##", "# # #", "##  #", " ### "},
+	'1': {"  #  ", " ##  ", "  #  ", "  #  ", " ### "},
+	'2': {" ### ", "#   #", "  ## ", " #   ", "#####"},
+	'3': {"#### ", "    #", " ### ", "    #", "#### "},
+	'4': {"#  # ", "#  # ", "#####", "   # ", "   # "},
+	'5': {"#####", "#    ", "#### ", "    #", "#### "},
+	'6': {" ### ", "#    ", "#### ", "#   #", " ### "},
+	'7': {"#####", "    #", "   # ", "  #  ", "  #  "},
+	'8': {" ### ", "#   #", " ### ", "#   #", " ### "},
+	'9': {" ### ", "#   #", " ####", "    #", " ### "},
+	'!': {"  #  ", "  #  ", "  #  ", "     ", "  #  "},
+	'?': {" ### ", "#   #", "  ## ", "     ", "  #  "},
+	'.': {"     ", "     ", "     ", "     ", "  #  "},
+	'-': {"     ", "     ", "#####", "     ", "     "},
+	' ': {"     ", "     ", "     ", "     ", "     "},
+}
+
+// Acentos que la fuente no tiene se dibujan con la letra base
+var figAccents = strings.NewReplacer("Á", "A", "É", "E", "Í", "I", "Ó", "O", "Ú", "U", "Ü", "U")
+
+// Cada letra de figFont ocupa 5x5 celdas y deja una de separación
+const figStep = 6
+
+// textCells devuelve las celdas ocupadas por el texto renderizado con figFont
+// junto con el índice del carácter al que pertenece cada celda. Las palabras
+// que no entran en width columnas pasan a otra fila de letras; una palabra
+// más larga que una fila se corta.
+func textCells(text string, width int) ([][2]int, []int) {
+	perRow := max(1, (width+1)/figStep)
+
+	var lines [][]rune
+	var line []rune
+	for _, word := range strings.Fields(figAccents.Replace(strings.ToUpper(text))) {
+		for rest := []rune(word); len(rest) > 0; {
+			if len(line) > 0 && len(line)+1+len(rest) > perRow {
+				lines = append(lines, line)
+				line = nil
+			}
+			if len(line) > 0 {
+				line = append(line, ' ')
+			}
+			n := min(len(rest), perRow-len(line))
+			line = append(line, rest[:n]...)
+			rest = rest[n:]
+		}
+	}
+	if len(line) > 0 {
+		lines = append(lines, line)
+	}
+
+	var cells [][2]int
+	var groups []int
+	i := 0
+	for row, line := range lines {
+		for col, r := range line {
+			glyph, ok := figFont[r]
+			if !ok {
+				glyph = figFont['?']
+			}
+			for y, glyphRow := range glyph {
+				for x, c := range glyphRow {
+					if c != ' ' {
+						cells = append(cells, [2]int{col*figStep + x, row*figStep + y})
+						groups = append(groups, i)
+					}
+				}
+			}
+			i++
+		}
+	}
+
+	return cells, groups
+}
+
+// artCells lee un archivo de arte ASCII; cada carácter visible es una celda.
+// Las filas se agrupan de a tres para que la figura llegue por franjas.
+func artCells(path string) ([][2]int, []int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	var cells [][2]int
+	var groups []int
+
+	scanner := bufio.NewScanner(f)
+	for y := 0; scanner.Scan(); y++ {
+		for x, r := range []rune(scanner.Text()) {
+			if !unicode.IsSpace(r) {
+				cells = append(cells, [2]int{x, y})
+				groups = append(groups, y/3)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return cells, groups, nil
+}
+
+// Ancho del panel de ajuste y lo mínimo que tiene que quedar para el lienzo
+// a su lado
+const (
+	panelWidth     = 34
+	minCanvasWidth = 10
+)
+
+// panelFits indica si el panel de ajuste entra junto al lienzo
+func (m model) panelFits() bool {
+	return m.width >= panelWidth+minCanvasWidth
+}
+
+// slider describe un parámetro ajustable desde el panel
+type slider struct {
+	label    string
+	min, max float64
+	step     float64
+	get      func(p *physics) float64
+	set      func(p *physics, v float64)
+}
+
+var sliders = []slider{
+	{"Frecuencia", 0.5, 15, 0.5,
+		func(p *physics) float64 { return p.Frequency },
+		func(p *physics, v float64) { p.Frequency = v }},
+	{"Frecuencia/partícula", 0, 2, 0.1,
+		func(p *physics) float64 { return p.FrequencyStep },
+		func(p *physics, v float64) { p.FrequencyStep = v }},
+	{"Amortiguación", 0.05, 1.5, 0.05,
+		func(p *physics) float64 { return p.Damping },
+		func(p *physics, v float64) { p.Damping = v }},
+	{"Amortiguación/partícula", 0, 0.3, 0.01,
+		func(p *physics) float64 { return p.DampingStep },
+		func(p *physics, v float64) { p.DampingStep = v }},
+	{"Radio de órbita", 0, 20, 0.5,
+		func(p *physics) float64 { return p.Orbit },
+		func(p *physics, v float64) { p.Orbit = v }},
+	{"Oscilación de órbita", 0, 10, 0.25,
+		func(p *physics) float64 { return p.OrbitSwing },
+		func(p *physics, v float64) { p.OrbitSwing = v }},
+	{"Partículas", 1, 64, 1,
+		func(p *physics) float64 { return float64(p.Particles) },
+		func(p *physics, v float64) { p.Particles = int(v) }},
+	{"Largo del rastro", 0, 100, 1,
+		func(p *physics) float64 { return float64(p.Trail) },
+		func(p *physics, v float64) { p.Trail = int(v) }},
+}
+
+// tuningPanel es el panel lateral para ajustar la física en vivo
+type tuningPanel struct {
+	open     bool
+	selected int
+	bar      progress.Model
+	name     textinput.Model
+	naming   bool
+	saving   bool
+	presets  []string
+	status   string
+}
+
+func newTuningPanel() tuningPanel {
+	bar := progress.New(progress.WithGradient("#FF10F0", "#10F0FF"), progress.WithoutPercentage())
+	bar.Width = panelWidth - 4
+
+	name := textinput.New()
+	name.Placeholder = "nombre del preset"
+	name.CharLimit = 24
+	name.Width = panelWidth - 6
+
+	return tuningPanel{bar: bar, name: name}
+}
+
+func (m model) updatePanel(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Escribiendo el nombre de un preset
+	if m.panel.naming {
+		switch msg.Type {
+		case tea.KeyEnter:
+			name := strings.TrimSpace(m.panel.name.Value())
+			m.panel.naming = false
+			m.panel.name.Blur()
+			if name == "" {
+				return m, nil
+			}
+			if m.panel.saving {
+				if err := savePreset(name, m.physics); err != nil {
+					m.panel.status = "Error: " + err.Error()
+				} else {
+					m.panel.status = "Guardado: " + name
+				}
+				m.panel.presets = presetNames()
+			} else {
+				p, err := loadPreset(name)
+				if err != nil {
+					m.panel.status = "Error: " + err.Error()
+				} else {
+					m.physics = p
+					m.applyPhysics()
+					m.panel.status = "Cargado: " + name
+				}
+			}
+			return m, nil
+		case tea.KeyEsc:
+			m.panel.naming = false
+			m.panel.name.Blur()
+			return m, nil
+		}
+
+		var cmd tea.Cmd
+		m.panel.name, cmd = m.panel.name.Update(msg)
+		return m, cmd
+	}
+
+	s := sliders[m.panel.selected]
+	switch msg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+	case "p", "esc":
+		m.panel.open = false
+	case "up", "k":
+		m.panel.selected = (m.panel.selected + len(sliders) - 1) % len(sliders)
+	case "down", "j":
+		m.panel.selected = (m.panel.selected + 1) % len(sliders)
+	case "left", "h":
+		s.set(&m.physics, math.Max(s.min, s.get(&m.physics)-s.step))
+		m.applyPhysics()
+	case "right", "l":
+		s.set(&m.physics, math.Min(s.max, s.get(&m.physics)+s.step))
+		m.applyPhysics()
+	case "r":
+		m.physics = defaultPhysics()
+		m.applyPhysics()
+		m.panel.status = "Valores por defecto"
+	case "s", "o":
+		m.panel.naming = true
+		m.panel.saving = msg.String() == "s"
+		m.panel.name.SetValue("")
+		return m, m.panel.name.Focus()
+	}
+
+	return m, nil
+}
+
+// applyPhysics recrea los resortes con los parámetros actuales conservando
+// posición y velocidad, ajusta la cantidad de partículas y recorta el rastro.
+func (m *model) applyPhysics() {
+	if !m.formation {
+		for len(m.particles) < m.physics.Particles {
+			m.particles = append(m.particles, m.newParticle(len(m.particles), float64(m.cursorX), float64(m.cursorY)))
+		}
+		m.particles = m.particles[:m.physics.Particles]
+	}
+
+	for i, old := range m.particles {
+		p := m.newParticle(i, old.x, old.y)
+		p.xVel, p.yVel = old.xVel, old.yVel
+		p.homeX, p.homeY = old.homeX, old.homeY
+		p.style = old.style
+		m.particles[i] = p
+	}
+
+	m.maxTrail = m.physics.Trail
+	if len(m.trail) > m.maxTrail {
+		m.trail = m.trail[len(m.trail)-m.maxTrail:]
+	}
+}
+
+func (m model) panelView() string {
+	var sb strings.Builder
+	sb.WriteString(neonPink.Render("Ajustes de física") + "\n\n")
+
+	for i, s := range sliders {
+		v := s.get(&m.physics)
+		label := fmt.Sprintf("  %s: %g", s.label, v)
+		if i == m.panel.selected {
+			label = neonYellow.Render(fmt.Sprintf("> %s: %g", s.label, v))
+		}
+		sb.WriteString(label + "\n")
+		sb.WriteString("  " + m.panel.bar.ViewAs((v-s.min)/(s.max-s.min)) + "\n")
+	}
+
+	sb.WriteString("\n")
+	if m.panel.naming {
+		action := "Cargar"
+		if m.panel.saving {
+			action = "Guardar"
+		}
+		sb.WriteString(action + " preset:\n" + m.panel.name.View() + "\n")
+	} else if len(m.panel.presets) > 0 {
+		sb.WriteString("Presets: " + strings.Join(m.panel.presets, ", ") + "\n")
+	}
+	if m.panel.status != "" {
+		sb.WriteString(neonGreen.Render(m.panel.status) + "\n")
+	}
+	sb.WriteString(neonBlue.Render("↑↓ elegir  ←→ ajustar\nr reiniciar  s guardar  o cargar\np cerrar"))
+
+	return lipgloss.NewStyle().
+		Width(panelWidth - 2).
+		Height(m.height - 2).
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#FF10F0")).
+		Render(sb.String())
+}
+
+// presetsPath devuelve el archivo donde se guardan los presets con nombre
+func presetsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go_charm", "presets.json"), nil
+}
+
+func readPresets() (map[string]physics, error) {
+	path, err := presetsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	presets := map[string]physics{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return presets, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &presets); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return presets, nil
+}
+
+func savePreset(name string, p physics) error {
+	presets, err := readPresets()
+	if err != nil {
+		return err
+	}
+	presets[name] = p
+
+	path, err := presetsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(presets, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func loadPreset(name string) (physics, error) {
+	presets, err := readPresets()
+	if err != nil {
+		return physics{}, err
+	}
+	p, ok := presets[name]
+	if !ok {
+		return physics{}, fmt.Errorf("no existe el preset %q", name)
+	}
+	p.clamp()
+	return p, nil
+}
+
+// clamp lleva cada parámetro al rango de su slider, para que un preset
+// editado a mano (o roto) no deje, por ejemplo, cero partículas
+func (p *physics) clamp() {
+	for _, s := range sliders {
+		v := s.get(p)
+		if math.IsNaN(v) {
+			v = s.min
+		}
+		s.set(p, math.Max(s.min, math.Min(s.max, v)))
+	}
+}
+
+func presetNames() []string {
+	presets, err := readPresets()
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Protocolo de sala compartida (versión 1)
+//
+// El relay (`go run main.go relay -addr :7777`) y los clientes
+// (`go run main.go -join host:7777 -name ana`) intercambian objetos JSON
+// sobre TCP, uno por línea. Todos los mensajes llevan el campo "v" con la
+// versión del protocolo; el relay rechaza con un mensaje "error" y cierra la
+// conexión si la versión del "hello" no coincide con la suya.
+//
+//	cliente → relay
+//	  {"v":1,"type":"hello","name":"ana"}   primer mensaje, obligatorio
+//	  {"v":1,"type":"move","x":10,"y":4}    posición del cursor
+//	  {"v":1,"type":"click","x":10,"y":4}   clic (onda expansiva)
+//
+//	relay → cliente
+//	  {"v":1,"type":"welcome","id":3,"color":"#FF8C10"}
+//	  {"v":1,"type":"join","id":2,"name":"luis","color":"#10F0FF","x":0,"y":0}
+//	  {"v":1,"type":"move","id":2,"x":11,"y":4}
+//	  {"v":1,"type":"click","id":2,"x":11,"y":4}
+//	  {"v":1,"type":"leave","id":2}
+//	  {"v":1,"type":"error","error":"..."}
+//
+// Al conectarse, el relay envía un "join" por cada participante presente con
+// su última posición conocida. Los ids y colores los asigna el relay.
+const protocolVersion = 1
+
+type wireMsg struct {
+	V     int    `json:"v"`
+	Type  string `json:"type"`
+	ID    int    `json:"id,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Color string `json:"color,omitempty"`
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+	Error string `json:"error,omitempty"`
+}
+
+// Mensajes que la conexión con la sala entrega al programa
+type roomMsg wireMsg
+type roomClosedMsg struct{ err error }
+
+// Colores que el relay reparte entre los participantes
+var peerColors = []string{"#FF8C10", "#10F0FF", "#10FF50", "#FFFF10", "#B010FF", "#FF1050", "#10FFC8", "#FFFFFF"}
+
+// roomClient es la conexión de este cursor con un relay. Si la conexión se
+// corta, closed queda en true y status dice por qué.
+type roomClient struct {
+	conn   net.Conn
+	dec    *json.Decoder
+	name   string
+	id     int
+	color  string
+	status string
+	closed bool
+	out    chan wireMsg
+	done   chan struct{}
+	once   sync.Once
+}
+
+// dialRoom se conecta al relay y espera su respuesta al "hello": devuelve
+// un error si el relay lo rechaza o no contesta a tiempo
+func dialRoom(addr, name string) (*roomClient, error) {
+	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	enc := json.NewEncoder(conn)
+	if err := enc.Encode(wireMsg{V: protocolVersion, Type: "hello", Name: name}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	dec := json.NewDecoder(conn)
+	var welcome wireMsg
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	err = dec.Decode(&welcome)
+	conn.SetReadDeadline(time.Time{})
+	switch {
+	case err != nil:
+		conn.Close()
+		return nil, fmt.Errorf("el relay no respondió: %w", err)
+	case welcome.Type == "error":
+		conn.Close()
+		return nil, fmt.Errorf("el relay rechazó la conexión: %s", welcome.Error)
+	case welcome.Type != "welcome":
+		conn.Close()
+		return nil, fmt.Errorf("respuesta inesperada del relay %q", welcome.Type)
+	}
+
+	r := &roomClient{
+		conn:  conn,
+		dec:   dec,
+		name:  name,
+		id:    welcome.ID,
+		color: welcome.Color,
+		out:   make(chan wireMsg, 64),
+		done:  make(chan struct{}),
+	}
+	go r.writeLoop(enc)
+	return r, nil
+}
+
+// send encola un mensaje sin bloquear; si la cola está llena se descarta,
+// ya que la próxima posición reemplaza a la anterior de todos modos.
+func (r *roomClient) send(msg wireMsg) {
+	if r == nil {
+		return
+	}
+	msg.V = protocolVersion
+	select {
+	case r.out <- msg:
+	case <-r.done:
+	default:
+	}
+}
+
+func (r *roomClient) writeLoop(enc *json.Encoder) {
+	for {
+		select {
+		case msg := <-r.out:
+			if err := enc.Encode(msg); err != nil {
+				return
+			}
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// listen entrega cada mensaje del relay al programa hasta que se cierra
+func (r *roomClient) listen(deliver func(tea.Msg)) {
+	for {
+		var msg wireMsg
+		if err := r.dec.Decode(&msg); err != nil {
+			deliver(roomClosedMsg{err})
+			return
+		}
+		deliver(roomMsg(msg))
+	}
+}
+
+// disconnectReason describe por qué se cortó la conexión con el relay
+func disconnectReason(err error) string {
+	if err == nil || errors.Is(err, io.EOF) {
+		return "el relay cerró la conexión"
+	}
+	return err.Error()
+}
+
+func (r *roomClient) close() {
+	r.once.Do(func() {
+		close(r.done)
+		r.conn.Close()
+	})
+}
+
+func (m *model) handleRoom(msg wireMsg) {
+	if m.room == nil {
+		return
+	}
+
+	switch msg.Type {
+	case "welcome":
+		m.room.id = msg.ID
+	case "join":
+		peer := &remotePeer{
+			name:    msg.Name,
+			style:   lipgloss.NewStyle().Foreground(lipgloss.Color(msg.Color)),
+			x:       float64(msg.X),
+			y:       float64(msg.Y),
+			targetX: float64(msg.X),
+			targetY: float64(msg.Y),
+			spring:  harmonica.NewSpring(harmonica.FPS(30), 6.0, 1.0),
+		}
+		peer.particles = make([]Particle, 6)
+		for i := range peer.particles {
+			peer.particles[i] = m.newParticle(i, peer.x, peer.y)
+		}
+		m.peers[msg.ID] = peer
+	case "move":
+		if peer, ok := m.peers[msg.ID]; ok {
+			peer.targetX, peer.targetY = float64(msg.X), float64(msg.Y)
+		}
+	case "click":
+		if peer, ok := m.peers[msg.ID]; ok {
+			peer.rippleX, peer.rippleY = msg.X, msg.Y
+			peer.ripple = 1
+		}
+	case "leave":
+		delete(m.peers, msg.ID)
+	case "error":
+		m.room.status = msg.Error
+	}
+}
+
+// remotePeer es el cursor de otro participante. Su posición se suaviza con
+// un resorte para disimular la latencia y los saltos entre mensajes.
+type remotePeer struct {
+	name             string
+	style            lipgloss.Style
+	x, y             float64
+	xVel, yVel       float64
+	targetX, targetY float64
+	spring           harmonica.Spring
+	particles        []Particle
+	rippleX, rippleY int
+	ripple           int
+}
+
+func (p *remotePeer) update(frame, maxRadius int) {
+	p.x, p.xVel = p.spring.Update(p.x, p.xVel, p.targetX)
+	p.y, p.yVel = p.spring.Update(p.y, p.yVel, p.targetY)
+
+	for i := range p.particles {
+		angle := float64(i) * (2 * math.Pi / float64(len(p.particles)))
+		offset := 3.0 + math.Sin(float64(frame)/10.0)
+		pt := &p.particles[i]
+		pt.x, pt.xVel = pt.springX.Update(pt.x, pt.xVel, p.x+math.Cos(angle)*offset)
+		pt.y, pt.yVel = pt.springY.Update(pt.y, pt.yVel, p.y+math.Sin(angle)*offset)
+	}
+
+	if p.ripple > 0 {
+		p.ripple++
+		if p.ripple > maxRadius {
+			p.ripple = 0
+		}
+	}
+}
+
+func (p *remotePeer) draw(screen [][]string) {
+	set := func(x, y int, s string) {
+		if y >= 0 && y < len(screen) && x >= 0 && x < len(screen[y]) {
+			screen[y][x] = s
+		}
+	}
+
+	if p.ripple > 0 {
+		for y := p.rippleY - p.ripple; y <= p.rippleY+p.ripple; y++ {
+			for x := p.rippleX - p.ripple; x <= p.rippleX+p.ripple; x++ {
+				distance := math.Hypot(float64(x-p.rippleX), float64(y-p.rippleY))
+				if distance <= float64(p.ripple) && distance > float64(p.ripple-1) {
+					set(x, y, p.style.Render("○"))
+				}
+			}
+		}
+	}
+
+	for _, pt := range p.particles {
+		set(int(pt.x+0.5), int(pt.y+0.5), p.style.Render("•"))
+	}
+
+	x, y := int(p.x+0.5), int(p.y+0.5)
+	set(x, y, p.style.Render("█"))
+	for i, r := range []rune(p.name) {
+		set(x+1+i, y-1, p.style.Render(string(r)))
+	}
+}
+
+// relay reenvía los mensajes de cada participante al resto de la sala
+type relay struct {
+	mu      sync.Mutex
+	nextID  int
+	clients map[int]*relayClient
+}
+
+type relayClient struct {
+	id    int
+	name  string
+	color string
+	x, y  int
+	out   chan wireMsg
+}
+
+func runRelay(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Relay escuchando en %s (protocolo v%d)\n", ln.Addr(), protocolVersion)
+	return serveRelay(ln)
+}
+
+func serveRelay(ln net.Listener) error {
+	r := &relay{clients: map[int]*relayClient{}}
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go r.serve(conn)
+	}
+}
+
+func (r *relay) serve(conn net.Conn) {
+	defer conn.Close()
+
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+
+	var hello wireMsg
+	if err := dec.Decode(&hello); err != nil {
+		return
+	}
+	if hello.Type != "hello" || hello.V != protocolVersion {
+		enc.Encode(wireMsg{
+			V:     protocolVersion,
+			Type:  "error",
+			Error: fmt.Sprintf("se esperaba hello con protocolo v%d", protocolVersion),
+		})
+		return
+	}
+
+	r.mu.Lock()
+	r.nextID++
+	c := &relayClient{
+		id:    r.nextID,
+		name:  hello.Name,
+		color: peerColors[(r.nextID-1)%len(peerColors)],
+		out:   make(chan wireMsg, 64+len(r.clients)),
+	}
+	c.out <- wireMsg{V: protocolVersion, Type: "welcome", ID: c.id, Color: c.color}
+	for _, other := range r.clients {
+		c.out <- other.joinMsg()
+	}
+	r.clients[c.id] = c
+	r.broadcastLocked(c.id, c.joinMsg())
+	r.mu.Unlock()
+
+	go func() {
+		for msg := range c.out {
+			if enc.Encode(msg) != nil {
+				conn.Close()
+			}
+		}
+	}()
+
+	for {
+		var msg wireMsg
+		if err := dec.Decode(&msg); err != nil {
+			break
+		}
+
+		r.mu.Lock()
+		switch msg.Type {
+		case "move":
+			c.x, c.y = msg.X, msg.Y
+			r.broadcastLocked(c.id, wireMsg{V: protocolVersion, Type: "move", ID: c.id, X: msg.X, Y: msg.Y})
+		case "click":
+			r.broadcastLocked(c.id, wireMsg{V: protocolVersion, Type: "click", ID: c.id, X: msg.X, Y: msg.Y})
+		}
+		r.mu.Unlock()
+	}
+
+	r.mu.Lock()
+	delete(r.clients, c.id)
+	close(c.out)
+	r.broadcastLocked(c.id, wireMsg{V: protocolVersion, Type: "leave", ID: c.id})
+	r.mu.Unlock()
+}
+
+func (c *relayClient) joinMsg() wireMsg {
+	return wireMsg{V: protocolVersion, Type: "join", ID: c.id, Name: c.name, Color: c.color, X: c.x, Y: c.y}
+}
+
+// broadcastLocked envía msg a todos salvo al emisor. Un cliente lento pierde
+// mensajes en lugar de frenar a la sala. Se llama con r.mu tomado.
+func (r *relay) broadcastLocked(from int, msg wireMsg) {
+	for id, c := range r.clients {
+		if id == from {
+			continue
+		}
+		select {
+		case c.out <- msg:
+		default:
+		}
+	}
+}
+
+// recordTrail agrega la posición del cursor al rastro
+func (m *model) recordTrail() {
+	m.trail = append(m.trail, [2]int{m.cursorX, m.cursorY})
+	if len(m.trail) > m.maxTrail {
+		m.trail = m.trail[1:]
+	}
+}
+
+// click lanza la onda expansiva en (x, y) y dispersa la formación de vuelta
+// a la órbita
+func (m *model) click(x, y int) {
+	m.scatter()
+	m.clickActive = true
+	m.clickX, m.clickY = x, y
+	m.clickRadius = 1
+	m.clickStyle = neonColors[m.frameCount%len(neonColors)]
+	m.room.send(wireMsg{Type: "click", X: x, Y: y})
+}
+
+// moveByKey mueve el cursor virtual. Pulsaciones seguidas en la misma
+// dirección aceleran el movimiento, como al mantener la tecla apretada.
+func (m *model) moveByKey(key string) {
+	dir := map[string]string{"k": "up", "j": "down", "h": "left", "l": "right"}[key]
+	if dir == "" {
+		dir = key
+	}
+
+	now := time.Now()
+	if dir == m.keyDir && now.Sub(m.keyLast) < 150*time.Millisecond {
+		m.keyStreak++
+	} else {
+		m.keyStreak = 0
+	}
+	m.keyDir, m.keyLast = dir, now
+
+	step := 1 + min(m.keyStreak/3, 4)
+	switch dir {
+	case "up":
+		m.cursorY -= step
+	case "down":
+		m.cursorY += step
+	case "left":
+		m.cursorX -= step * 2
+	case "right":
+		m.cursorX += step * 2
+	}
+	m.cursorX = max(0, min(m.width-1, m.cursorX))
+	m.cursorY = max(0, min(m.height-1, m.cursorY))
+
+	m.recordTrail()
+}
+
+// forceField es un punto de atracción (Strength > 0) o repulsión
+// (Strength < 0) que desvía las partículas y el rastro
+type forceField struct {
+	X        int     `json:"x"`
+	Y        int     `json:"y"`
+	Strength float64 `json:"strength"`
+}
+
+// fieldPull devuelve el empuje que los campos ejercen en (x, y) por cuadro.
+// Decae con el cuadrado de la distancia, suavizado para no diverger encima
+// del campo.
+func (m *model) fieldPull(x, y float64) (float64, float64) {
+	var fx, fy float64
+	for _, f := range m.fields {
+		dx, dy := float64(f.X)-x, float64(f.Y)-y
+		d2 := dx*dx + dy*dy + 1
+		d := math.Sqrt(d2)
+		a := f.Strength * 15 / d2
+		fx += a * dx / d
+		fy += a * dy / d
+	}
+	return fx, fy
+}
+
+// fieldAt devuelve el índice del campo más cercano a (x, y) a no más de dos
+// celdas, o -1 si no hay ninguno
+func (m *model) fieldAt(x, y int) int {
+	best, bestDist := -1, 2.0
+	for i, f := range m.fields {
+		if d := math.Hypot(float64(f.X-x), float64(f.Y-y)); d <= bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+func (m *model) removeField(x, y int) {
+	if i := m.fieldAt(x, y); i >= 0 {
+		m.fields = append(m.fields[:i], m.fields[i+1:]...)
+	}
+}
+
+// adjustField cambia la intensidad del campo sin cambiar si atrae o repele
+func (m *model) adjustField(x, y int, delta float64) {
+	i := m.fieldAt(x, y)
+	if i < 0 {
+		return
+	}
+	f := &m.fields[i]
+	magnitude := math.Max(0.5, math.Min(5, math.Abs(f.Strength)+delta))
+	f.Strength = math.Copysign(magnitude, f.Strength)
+}
+
+func loadFields(path string) ([]forceField, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fields []forceField
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return fields, nil
+}
+
+func saveFields(path string, fields []forceField) error {
+	data, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
 }
 
 func (m model) Init() tea.Cmd {
@@ -82,54 +1142,146 @@ func (m model) Init() tea.Cmd {
 }
 
 func tick() tea.Cmd {
-	return tea.Tick(time.Second/30, func(time.Time) tea.Msg {
-		return tickMsg{}
+	return tea.Tick(time.Second/30, func(t time.Time) tea.Msg {
+		return tickMsg(t)
 	})
 }
 
-type tickMsg struct{}
+type tickMsg time.Time
 type mouseMsg struct{ x, y int }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		if msg.String() == "q" || msg.String() == "ctrl+c" {
+		// Mientras se escribe una palabra, las teclas van al texto
+		if m.typing {
+			switch msg.Type {
+			case tea.KeyCtrlC:
+				return m, tea.Quit
+			case tea.KeyEnter:
+				m.typing = false
+				m.formText(m.input)
+			case tea.KeyEsc:
+				m.typing = false
+			case tea.KeyBackspace:
+				if r := []rune(m.input); len(r) > 0 {
+					m.input = string(r[:len(r)-1])
+				}
+			case tea.KeyRunes, tea.KeySpace:
+				m.input += string(msg.Runes)
+			}
+			return m, nil
+		}
+
+		if m.panel.open {
+			return m.updatePanel(msg)
+		}
+
+		switch msg.String() {
+		case "q", "ctrl+c":
 			return m, tea.Quit
+		case "f":
+			m.typing = true
+			m.input = ""
+		case "p":
+			if !m.panelFits() {
+				m.notice = fmt.Sprintf("La terminal es muy angosta para el panel de ajuste: se necesitan %d columnas", panelWidth+minCanvasWidth)
+				break
+			}
+			m.panel.open = true
+			m.panel.presets = presetNames()
+		case "m":
+			m.keyboard = !m.keyboard
+		case "x":
+			m.scatter()
+		case "a":
+			m.fields = append(m.fields, forceField{X: m.cursorX, Y: m.cursorY, Strength: 1})
+		case "r":
+			m.fields = append(m.fields, forceField{X: m.cursorX, Y: m.cursorY, Strength: -1})
+		case "d":
+			m.removeField(m.cursorX, m.cursorY)
+		case "+", "=":
+			m.adjustField(m.cursorX, m.cursorY, 0.5)
+		case "-":
+			m.adjustField(m.cursorX, m.cursorY, -0.5)
+		case "w":
+			if err := saveFields(m.fieldsPath, m.fields); err != nil {
+				m.notice = "Error: " + err.Error()
+			} else {
+				m.notice = fmt.Sprintf("Campos guardados en %s", m.fieldsPath)
+			}
+		case "up", "k", "down", "j", "left", "h", "right", "l":
+			m.keyboard = true
+			m.moveByKey(msg.String())
+		case " ", "enter":
+			m.click(m.cursorX, m.cursorY)
 		}
 
 	case tea.MouseMsg:
+		// Si llegan eventos del mouse, el cursor vuelve a seguirlo
+		m.mouseSeen = true
+		m.keyboard = false
 		m.cursorX, m.cursorY = msg.X, msg.Y
 
 		// Registra el rastro del cursor
 		if m.frameCount%2 == 0 {
-			m.trail = append(m.trail, [2]int{m.cursorX, m.cursorY})
-			if len(m.trail) > m.maxTrail {
-				m.trail = m.trail[1:]
-			}
+			m.recordTrail()
 		}
 
-		// Maneja los clics
-		if msg.Type == tea.MouseLeft {
-			m.clickActive = true
-			m.clickX, m.clickY = msg.X, msg.Y
-			m.clickRadius = 1
-			m.clickStyle = neonColors[m.frameCount%len(neonColors)]
+		// Maneja los clics: shift-clic atrae, ctrl-clic repele, clic derecho
+		// borra un campo y la rueda cambia su intensidad
+		switch {
+		case msg.Type == tea.MouseLeft && (msg.Shift || msg.Ctrl):
+			strength := 1.0
+			if msg.Ctrl {
+				strength = -1.0
+			}
+			m.fields = append(m.fields, forceField{X: msg.X, Y: msg.Y, Strength: strength})
+		case msg.Type == tea.MouseLeft:
+			m.click(msg.X, msg.Y)
+		case msg.Type == tea.MouseRight:
+			m.removeField(msg.X, msg.Y)
+		case msg.Type == tea.MouseWheelUp:
+			m.adjustField(msg.X, msg.Y, 0.5)
+		case msg.Type == tea.MouseWheelDown:
+			m.adjustField(msg.X, msg.Y, -0.5)
 		}
 
 	case tea.WindowSizeMsg:
 		m.width, m.height = msg.Width, msg.Height
+		// Si la terminal se achica, el panel se cierra en vez de taparlo todo
+		if m.panel.open && !m.panelFits() {
+			m.panel.open = false
+		}
+		// El texto se vuelve a cortar al ancho nuevo; el arte sólo se centra
+		switch {
+		case m.word != "":
+			m.formText(m.word)
+		case m.formation:
+			m.placeShape()
+		}
 
 	case tickMsg:
 		m.frameCount++
+		if !m.mouseWait.IsZero() && time.Time(msg).Sub(m.mouseWait) >= mouseTimeout {
+			m.mouseWait = time.Time{}
+			if !m.mouseSeen && !m.keyboard {
+				m.keyboard = true
+				m.cursorX, m.cursorY = m.width/2, m.height/2
+			}
+		}
 
 		// Actualiza las partículas con harmonica
 		for i := range m.particles {
 			// Calcula la posición objetivo con offset
 			angle := float64(i) * (2 * math.Pi / float64(len(m.particles)))
-			offset := 5.0 + 1.5*math.Sin(float64(m.frameCount)/10.0)
+			offset := m.physics.Orbit + m.physics.OrbitSwing*math.Sin(float64(m.frameCount)/10.0)
 
 			targetX := float64(m.cursorX) + math.Cos(angle)*offset
 			targetY := float64(m.cursorY) + math.Sin(angle)*offset
+			if m.formation {
+				targetX, targetY = m.particles[i].homeX, m.particles[i].homeY
+			}
 
 			m.particles[i].targetX = targetX
 			m.particles[i].targetY = targetY
@@ -139,6 +1291,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.particles[i].x, m.particles[i].xVel, m.particles[i].targetX)
 			m.particles[i].y, m.particles[i].yVel = m.particles[i].springY.Update(
 				m.particles[i].y, m.particles[i].yVel, m.particles[i].targetY)
+
+			// Los campos de fuerza desvían la trayectoria
+			fx, fy := m.fieldPull(m.particles[i].x, m.particles[i].y)
+			m.particles[i].xVel += fx
+			m.particles[i].yVel += fy
 		}
 
 		// Actualiza el efecto de clic
@@ -149,26 +1306,57 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		// Comparte el cursor con la sala solo cuando se mueve
+		if m.cursorX != m.sentX || m.cursorY != m.sentY {
+			m.room.send(wireMsg{Type: "move", X: m.cursorX, Y: m.cursorY})
+			m.sentX, m.sentY = m.cursorX, m.cursorY
+		}
+		for _, peer := range m.peers {
+			peer.update(m.frameCount, m.clickMaxRadius)
+		}
+
 		return m, tick()
+
+	case roomMsg:
+		m.handleRoom(wireMsg(msg))
+
+	case roomClosedMsg:
+		// La sala queda en la línea de estado con el motivo del corte
+		if m.room != nil {
+			m.room.closed = true
+			if m.room.status == "" {
+				m.room.status = disconnectReason(msg.err)
+			}
+		}
+		m.peers = map[int]*remotePeer{}
 	}
 
 	return m, nil
 }
 
 func (m model) View() string {
+	// El panel de ajuste ocupa el lado derecho de la pantalla
+	width := m.width
+	if m.panel.open {
+		width = max(0, width-panelWidth)
+	}
+
 	// Creamos una matriz de caracteres para representar la pantalla
 	screen := make([][]string, m.height)
 	for y := range screen {
-		screen[y] = make([]string, m.width)
+		screen[y] = make([]string, width)
 		for x := range screen[y] {
 			screen[y][x] = " "
 		}
 	}
 
-	// Dibuja el rastro
+	// Dibuja el rastro; los puntos más viejos se curvan más hacia los campos
 	for i, pos := range m.trail {
-		if pos[0] >= 0 && pos[0] < m.width && pos[1] >= 0 && pos[1] < m.height {
-			opacity := float64(i) / float64(len(m.trail))
+		opacity := float64(i) / float64(len(m.trail))
+		fx, fy := m.fieldPull(float64(pos[0]), float64(pos[1]))
+		pos[0] += int(math.Round(fx * 3 * (1 - opacity)))
+		pos[1] += int(math.Round(fy * 3 * (1 - opacity)))
+		if pos[0] >= 0 && pos[0] < width && pos[1] >= 0 && pos[1] < m.height {
 			idx := int(opacity * float64(len(neonColors)))
 			if idx >= len(neonColors) {
 				idx = len(neonColors) - 1
@@ -177,6 +1365,21 @@ func (m model) View() string {
 		}
 	}
 
+	// Dibuja los campos de fuerza con su intensidad
+	for _, f := range m.fields {
+		glyph, style := "⊕", neonGreen
+		if f.Strength < 0 {
+			glyph, style = "⊖", neonPink
+		}
+		label := glyph + fmt.Sprintf("%g", math.Abs(f.Strength))
+		for i, r := range []rune(label) {
+			x := f.X + i
+			if x >= 0 && x < width && f.Y >= 0 && f.Y < m.height {
+				screen[f.Y][x] = style.Render(string(r))
+			}
+		}
+	}
+
 	// Dibuja el efecto de clic
 	if m.clickActive {
 		for y := m.clickY - m.clickRadius; y <= m.clickY + m.clickRadius; y++ {
@@ -184,17 +1387,22 @@ func (m model) View() string {
 				// Comprueba si el punto está dentro del círculo y de la pantalla
 				distance := math.Sqrt(math.Pow(float64(x-m.clickX), 2) + math.Pow(float64(y-m.clickY), 2))
 				if distance <= float64(m.clickRadius) && distance > float64(m.clickRadius-1) &&
-					 x >= 0 && x < m.width && y >= 0 && y < m.height {
+					 x >= 0 && x < width && y >= 0 && y < m.height {
 					screen[y][x] = m.clickStyle.Render("○")
 				}
 			}
 		}
 	}
 
+	// Dibuja a los demás participantes de la sala
+	for _, peer := range m.peers {
+		peer.draw(screen)
+	}
+
 	// Dibuja las partículas
 	for i, p := range m.particles {
 		x, y := int(p.x+0.5), int(p.y+0.5)
-		if x >= 0 && x < m.width && y >= 0 && y < m.height {
+		if x >= 0 && x < width && y >= 0 && y < m.height {
 			// Alterna entre caracteres para crear variación
 			char := "●"
 			if i%2 == 0 {
@@ -207,28 +1415,103 @@ func (m model) View() string {
 	}
 
 	// Dibuja el cursor
-	if m.cursorX >= 0 && m.cursorX < m.width && m.cursorY >= 0 && m.cursorY < m.height {
+	if m.cursorX >= 0 && m.cursorX < width && m.cursorY >= 0 && m.cursorY < m.height {
 		screen[m.cursorY][m.cursorX] = neonPink.Render("█")
 	}
 
 	// Convierte la matriz en un solo string
 	var result strings.Builder
-	for _, row := range screen {
-		result.WriteString(strings.Join(row, ""))
-		result.WriteString("\n")
+	rows := make([]string, len(screen))
+	for y, row := range screen {
+		rows[y] = strings.Join(row, "")
+	}
+	canvas := strings.Join(rows, "\n")
+	if m.panel.open {
+		canvas = lipgloss.JoinHorizontal(lipgloss.Top, canvas, m.panelView())
 	}
+	result.WriteString(canvas + "\n")
 
 	// Agrega instrucciones
-	result.WriteString("\n" + neonBlue.Render("Mueve el mouse - Haz clic para efectos - q para salir"))
+	if m.notice != "" {
+		result.WriteString(neonYellow.Render(m.notice) + "\n")
+	}
+	if m.room != nil {
+		status := fmt.Sprintf("Sala como %s (%d participantes)", m.room.name, len(m.peers)+1)
+		if m.room.closed {
+			status = fmt.Sprintf("Sala como %s: desconectado", m.room.name)
+		}
+		if m.room.status != "" {
+			status += " - " + m.room.status
+		}
+		result.WriteString(neonGreen.Render(status) + "\n")
+	}
+	if m.typing {
+		result.WriteString("\n" + neonYellow.Render("Texto: "+m.input+"█  (enter para formar, esc para cancelar)"))
+	} else if m.keyboard {
+		result.WriteString("\n" + neonBlue.Render("Modo teclado: flechas/hjkl mover - espacio clic - a/r atraer/repeler - d borrar - +/- fuerza - w guardar - f texto - x dispersar - p ajustes - m mouse - q salir"))
+	} else {
+		result.WriteString("\n" + neonBlue.Render("Mueve el mouse - Haz clic para efectos - shift/ctrl-clic atraer/repeler - f formar texto - p ajustes - m teclado - q salir"))
+	}
 
 	return result.String()
 }
 
 func main() {
-	p := tea.NewProgram(initialModel(), 
+	if len(os.Args) > 1 && os.Args[1] == "relay" {
+		fs := flag.NewFlagSet("relay", flag.ExitOnError)
+		addr := fs.String("addr", ":7777", "dirección donde escucha el relay")
+		fs.Parse(os.Args[2:])
+
+		if err := runRelay(*addr); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	text := flag.String("text", "", "palabra que las partículas forman al iniciar")
+	art := flag.String("art", "", "archivo de arte ASCII que las partículas forman al iniciar")
+	join := flag.String("join", "", "dirección host:puerto de un relay para compartir el cursor")
+	name := flag.String("name", os.Getenv("USER"), "nombre que ven los demás participantes")
+	fields := flag.String("fields", "fields.json", "archivo donde se cargan y guardan los campos de fuerza")
+	flag.Parse()
+
+	m := initialModel()
+	m.fieldsPath = *fields
+	if saved, err := loadFields(*fields); err == nil {
+		m.fields = saved
+	} else if !os.IsNotExist(err) {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *art != "" {
+		cells, groups, err := artCells(*art)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		m.formShape(cells, groups)
+	} else if *text != "" {
+		m.formText(*text)
+	}
+
+	if *join != "" {
+		room, err := dialRoom(*join, *name)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer room.close()
+		m.room = room
+	}
+
+	p := tea.NewProgram(m, 
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
 		tea.WithMouseAllMotion())
+	if m.room != nil {
+		go m.room.listen(p.Send)
+	}
 
 	if err := p.Start(); err != nil {
 		fmt.Printf("Error: %v\n", err)
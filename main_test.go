@@ -0,0 +1,349 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestLoadPresetClamps(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	path, err := presetsPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data := `{"roto": {"frequency": 99, "damping": -1, "particles": -3, "trail": 5000},
+		"grande": {"particles": 10000}}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := loadPreset("roto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := physics{Frequency: 15, Damping: 0.05, Particles: 1, Trail: 100}
+	if p != want {
+		t.Errorf("loadPreset(roto) = %+v, se esperaba %+v", p, want)
+	}
+
+	m := initialModel()
+	if m.physics, err = loadPreset("grande"); err != nil {
+		t.Fatal(err)
+	}
+	m.applyPhysics()
+	if len(m.particles) != 64 {
+		t.Errorf("%d partículas, se esperaban 64", len(m.particles))
+	}
+}
+
+// startRelay levanta un relay en un puerto libre de loopback
+func startRelay(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go serveRelay(ln)
+	return ln.Addr().String()
+}
+
+// joinRoom conecta un cliente y junta lo que le llega del relay
+func joinRoom(t *testing.T, addr, name string) (*roomClient, chan tea.Msg) {
+	t.Helper()
+	r, err := dialRoom(addr, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(r.close)
+	msgs := make(chan tea.Msg, 64)
+	go r.listen(func(msg tea.Msg) { msgs <- msg })
+	return r, msgs
+}
+
+// expect espera un mensaje del tipo dado, salteando los demás
+func expect(t *testing.T, msgs chan tea.Msg, kind string) wireMsg {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case msg := <-msgs:
+			if m, ok := msg.(roomMsg); ok && m.Type == kind {
+				return wireMsg(m)
+			}
+		case <-timeout:
+			t.Fatalf("no llegó ningún %q", kind)
+		}
+	}
+}
+
+func TestRelayRoom(t *testing.T) {
+	addr := startRelay(t)
+	ana, anaMsgs := joinRoom(t, addr, "ana")
+	luis, luisMsgs := joinRoom(t, addr, "luis")
+	eva, evaMsgs := joinRoom(t, addr, "eva")
+
+	if ana.id == 0 || ana.id == luis.id || luis.id == eva.id || ana.color == luis.color {
+		t.Fatalf("ids o colores repetidos: %d %s, %d %s, %d %s", ana.id, ana.color, luis.id, luis.color, eva.id, eva.color)
+	}
+	// eva ve a los que ya estaban; ana ve llegar a eva
+	if j := expect(t, evaMsgs, "join"); j.Name != "ana" && j.Name != "luis" {
+		t.Errorf("eva recibió el join de %q", j.Name)
+	}
+	expect(t, anaMsgs, "join")
+
+	ana.send(wireMsg{Type: "move", X: 10, Y: 4})
+	for _, msgs := range []chan tea.Msg{luisMsgs, evaMsgs} {
+		if mv := expect(t, msgs, "move"); mv.ID != ana.id || mv.X != 10 || mv.Y != 4 {
+			t.Errorf("move = %+v", mv)
+		}
+	}
+
+	eva.close()
+	if l := expect(t, luisMsgs, "leave"); l.ID != eva.id {
+		t.Errorf("leave de %d, se esperaba %d", l.ID, eva.id)
+	}
+}
+
+func TestRelayRejectsVersion(t *testing.T) {
+	addr := startRelay(t)
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	json.NewEncoder(conn).Encode(wireMsg{V: protocolVersion + 1, Type: "hello", Name: "vieja"})
+	var reply wireMsg
+	if err := json.NewDecoder(conn).Decode(&reply); err != nil || reply.Type != "error" {
+		t.Fatalf("respuesta %+v, %v; se esperaba un error", reply, err)
+	}
+}
+
+// fakeRelay responde al hello con reply y cierra la conexión
+func fakeRelay(t *testing.T, reply wireMsg) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var hello wireMsg
+		json.NewDecoder(conn).Decode(&hello)
+		json.NewEncoder(conn).Encode(reply)
+	}()
+	return ln.Addr().String()
+}
+
+func TestDialRoomReportsRejection(t *testing.T) {
+	addr := fakeRelay(t, wireMsg{V: protocolVersion, Type: "error", Error: "se esperaba hello con protocolo v9"})
+	_, err := dialRoom(addr, "ana")
+	if err == nil || !strings.Contains(err.Error(), "protocolo v9") {
+		t.Fatalf("dialRoom = %v, se esperaba el error del relay", err)
+	}
+}
+
+func TestRoomDisconnectShowsReason(t *testing.T) {
+	addr := fakeRelay(t, wireMsg{V: protocolVersion, Type: "welcome", ID: 1, Color: "#FF8C10"})
+	r, msgs := joinRoom(t, addr, "ana")
+
+	m := initialModel()
+	m.room = r
+	timeout := time.After(2 * time.Second)
+	for !m.room.closed {
+		select {
+		case msg := <-msgs:
+			next, _ := m.Update(msg)
+			m = next.(model)
+		case <-timeout:
+			t.Fatal("no se detectó el corte")
+		}
+	}
+	if view := m.View(); !strings.Contains(view, "desconectado") || !strings.Contains(view, "el relay cerró la conexión") {
+		t.Errorf("la línea de estado no muestra el corte:\n%s", view)
+	}
+}
+
+func TestPanelOnNarrowTerminal(t *testing.T) {
+	m := initialModel()
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 30, Height: 20})
+	m = next.(model)
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	m = next.(model)
+	if m.panel.open || m.notice == "" {
+		t.Errorf("el panel se abrió en 30 columnas (aviso %q)", m.notice)
+	}
+	m.View()
+
+	// Abierto en una terminal ancha, se cierra si se achica
+	next, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	m = next.(model)
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	m = next.(model)
+	if !m.panel.open {
+		t.Fatal("el panel no se abrió en 80 columnas")
+	}
+	m.View()
+	next, _ = m.Update(tea.WindowSizeMsg{Width: 30, Height: 20})
+	m = next.(model)
+	if m.panel.open {
+		t.Error("el panel siguió abierto al achicar la terminal")
+	}
+	m.View()
+
+	// Aunque quede abierto, View no arma un lienzo de ancho negativo
+	m.panel.open = true
+	m.View()
+}
+
+func TestClickScattersFormation(t *testing.T) {
+	m := initialModel()
+	m.formText("Hola")
+	if len(m.particles) <= m.physics.Particles {
+		t.Fatalf("la formación tiene %d partículas, se esperaban más de %d", len(m.particles), m.physics.Particles)
+	}
+	next, _ := m.Update(tea.MouseMsg{X: 5, Y: 5, Type: tea.MouseLeft})
+	m = next.(model)
+	if m.formation || len(m.particles) != m.physics.Particles {
+		t.Errorf("después del clic: formación %v, %d partículas, se esperaban %d", m.formation, len(m.particles), m.physics.Particles)
+	}
+
+	// Con pocas letras la formación tiene menos partículas que la órbita
+	m.formText("-")
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if m = next.(model); len(m.particles) != m.physics.Particles {
+		t.Errorf("después de x: %d partículas, se esperaban %d", len(m.particles), m.physics.Particles)
+	}
+}
+
+func TestTextCellsWraps(t *testing.T) {
+	all, _ := textCells("Hola mundo extraordinario", 1000)
+	for _, width := range []int{5, 11, 30, 80} {
+		cells, groups := textCells("Hola mundo extraordinario", width)
+		if len(cells) != len(all) || len(groups) != len(cells) {
+			t.Errorf("a %d columnas: %d celdas, se esperaban %d", width, len(cells), len(all))
+		}
+		for _, c := range cells {
+			if c[0] >= width {
+				t.Errorf("a %d columnas: celda en la columna %d", width, c[0])
+				break
+			}
+		}
+	}
+
+	// Las palabras que entran no se cortan
+	cells, _ := textCells("uno dos", 23)
+	rows := map[int]bool{}
+	for _, c := range cells {
+		rows[c[1]/figStep] = true
+	}
+	if len(rows) != 2 {
+		t.Errorf("\"uno dos\" a 23 columnas ocupa %d filas de letras, se esperaban 2", len(rows))
+	}
+
+	// Al achicar la terminal el texto se vuelve a cortar
+	m := initialModel()
+	m.formText("Hola mundo")
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 30, Height: 24})
+	m = next.(model)
+	for i := range m.shape {
+		if x := m.particles[i].homeX; x < 0 || x >= 30 {
+			t.Fatalf("la partícula %d quedó en la columna %.0f de 30", i, x)
+		}
+	}
+}
+
+func TestMouseFallback(t *testing.T) {
+	m := initialModel()
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m = next.(model)
+	start := m.mouseWait
+
+	// Cuenta el tiempo desde el arranque, no los ticks que llegaron
+	next, _ = m.Update(tickMsg(start.Add(mouseTimeout - 100*time.Millisecond)))
+	if m = next.(model); m.keyboard {
+		t.Fatal("el teclado se activó antes de tiempo")
+	}
+	next, _ = m.Update(tickMsg(start.Add(mouseTimeout)))
+	if m = next.(model); !m.keyboard || m.cursorX != 40 || m.cursorY != 12 {
+		t.Fatalf("después de %v: teclado %v, cursor en %d,%d, se esperaba activo en 40,12", mouseTimeout, m.keyboard, m.cursorX, m.cursorY)
+	}
+
+	// Si se vuelve al mouse con "m", los ticks siguientes no lo pisan
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("m")})
+	m = next.(model)
+	next, _ = m.Update(tickMsg(start.Add(2 * mouseTimeout)))
+	if m = next.(model); m.keyboard {
+		t.Error("un tick volvió a activar el teclado después de apagarlo con m")
+	}
+
+	// Con un evento del mouse a tiempo el teclado no se activa nunca
+	m = initialModel()
+	next, _ = m.Update(tea.MouseMsg{X: 3, Y: 4, Type: tea.MouseMotion})
+	m = next.(model)
+	next, _ = m.Update(tickMsg(m.mouseWait.Add(mouseTimeout)))
+	if m = next.(model); m.keyboard {
+		t.Error("el teclado se activó aunque llegaron eventos del mouse")
+	}
+}
+
+func TestKeyboardAcceleration(t *testing.T) {
+	m := initialModel()
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 200, Height: 24})
+	m = next.(model)
+	m.cursorX, m.cursorY = 0, 10
+
+	// Cada tres pulsaciones seguidas el paso crece en uno
+	for range 12 {
+		m.moveByKey("l")
+	}
+	if want := 2 * (3*1 + 3*2 + 3*3 + 3*4); m.cursorX != want {
+		t.Errorf("después de 12 pulsaciones el cursor está en %d, se esperaba %d", m.cursorX, want)
+	}
+
+	// Cambiar de dirección o esperar vuelve al paso mínimo
+	m.moveByKey("j")
+	if m.keyStreak != 0 || m.cursorY != 11 {
+		t.Errorf("al cambiar de dirección: racha %d, y %d, se esperaba 0 y 11", m.keyStreak, m.cursorY)
+	}
+	m.moveByKey("j")
+	m.keyLast = m.keyLast.Add(-time.Hour)
+	m.moveByKey("j")
+	if m.keyStreak != 0 || m.cursorY != 13 {
+		t.Errorf("después de una pausa: racha %d, y %d, se esperaba 0 y 13", m.keyStreak, m.cursorY)
+	}
+
+	// El cursor no sale de la pantalla
+	for range 20 {
+		m.moveByKey("up")
+	}
+	if m.cursorY != 0 {
+		t.Errorf("el cursor subió hasta %d, se esperaba 0", m.cursorY)
+	}
+
+	// Las flechas activan el teclado y el mouse lo desactiva
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRight})
+	if m = next.(model); !m.keyboard {
+		t.Error("la flecha no activó el teclado")
+	}
+	next, _ = m.Update(tea.MouseMsg{X: 7, Y: 8, Type: tea.MouseMotion})
+	if m = next.(model); m.keyboard || m.cursorX != 7 || m.cursorY != 8 {
+		t.Errorf("después del mouse: teclado %v, cursor en %d,%d, se esperaba inactivo en 7,8", m.keyboard, m.cursorX, m.cursorY)
+	}
+}